@@ -0,0 +1,137 @@
+package main
+
+import (
+	"deployment-notifications/pkg/handler"
+	"deployment-notifications/pkg/helper"
+	"deployment-notifications/pkg/lint"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+
+	"github.com/aws/aws-sdk-go/aws/session"
+)
+
+// ssmStore reads the parameters straight from SSM Parameter Store
+type ssmStore struct {
+	awsSession *session.Session
+}
+
+func (store *ssmStore) ReadParameter(name string) (string, error) {
+	if store.awsSession == nil {
+		store.awsSession = helper.NewAWSSession()
+	}
+
+	return helper.ReadAWSParameter(name, helper.NewSSMClient(store.awsSession))
+}
+
+func (store *ssmStore) ReadSecret(name string) (string, error) {
+	if store.awsSession == nil {
+		store.awsSession = helper.NewAWSSession()
+	}
+
+	return helper.ReadAWSSecret(name, helper.NewSecretsManagerClient(store.awsSession))
+}
+
+// loadValue reads a file if one is given, else the parameter named
+// by the environment variable
+func loadValue(store handler.ConfigStore, path, parameterEnv string) (string, error) {
+	if path != "" {
+		content, err := ioutil.ReadFile(path)
+		if err != nil {
+			return "", helper.WrapError(fmt.Sprintf("Error reading '%s'", path), err)
+		}
+
+		return string(content), nil
+	}
+
+	parameterName := helper.GetStringEnv(parameterEnv, "")
+	if parameterName == "" {
+		return "", nil
+	}
+
+	value, err := store.ReadParameter(parameterName)
+	if err != nil {
+		return "", helper.WrapError(fmt.Sprintf("Error reading parameter '%s' (%s)", parameterName, parameterEnv),
+			err)
+	}
+
+	return value, nil
+}
+
+func main() {
+	configPath := flag.String("config", "",
+		"JSON file with the SSM parameters, as used by cmd/notify. SSM is read when not given")
+	envPath := flag.String("env-file", "", "file with KEY=VALUE environment variables naming the parameters")
+	newRelicPath := flag.String("newrelic-mapping", "", "New Relic mapping file, instead of SSM_PARAMETER_NAME_NEW_RELIC")
+	slackPath := flag.String("slack-mapping", "", "Slack mapping file, instead of SSM_PARAMETER_NAME_SLACK")
+	templatePath := flag.String("template", "", "Slack message template file, instead of SSM_PARAMETER_MESSAGE_SLACK")
+	flag.Parse()
+
+	if *envPath != "" {
+		if err := helper.LoadEnvFile(*envPath); err != nil {
+			log.Fatalf("%v", err)
+		}
+	}
+
+	var store handler.ConfigStore = &ssmStore{}
+
+	if *configPath != "" {
+		fileStore, err := handler.NewFileConfigStore(*configPath)
+		if err != nil {
+			log.Fatalf("%v", err)
+		}
+
+		store = fileStore
+	}
+
+	var config lint.Config
+	var err error
+
+	config.NewRelicMapping, err = loadValue(store, *newRelicPath, "SSM_PARAMETER_NAME_NEW_RELIC")
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+
+	config.SlackMapping, err = loadValue(store, *slackPath, "SSM_PARAMETER_NAME_SLACK")
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+
+	config.Templates = make(map[string]string)
+
+	for _, parameterEnv := range lint.TemplateParameters() {
+		path := ""
+		if parameterEnv == "SSM_PARAMETER_MESSAGE_SLACK" {
+			path = *templatePath
+		}
+
+		template, err := loadValue(store, path, parameterEnv)
+		if err != nil {
+			log.Fatalf("%v", err)
+		}
+
+		// unset template parameters fall back to the built-in templates
+		if template != "" {
+			config.Templates[parameterEnv] = template
+		}
+	}
+
+	config.ServiceActionTemplates, err = loadValue(store, "", "SSM_PARAMETER_MESSAGE_SLACK_SERVICE_ACTION")
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+
+	findings := lint.Run(config)
+
+	for _, finding := range findings {
+		fmt.Println(finding)
+	}
+
+	if lint.HasErrors(findings) {
+		os.Exit(1)
+	}
+
+	fmt.Printf("%d template(s) and both mappings OK\n", len(config.Templates))
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"deployment-notifications/pkg/fake"
+	"deployment-notifications/pkg/handler"
+	"deployment-notifications/pkg/helper"
+	"deployment-notifications/pkg/logger"
+	"deployment-notifications/pkg/metrics"
+	"deployment-notifications/pkg/redact"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+)
+
+// printingDoer prints every request the notifier makes. Unless sending is
+// enabled it answers with a success status instead of calling out
+type printingDoer struct {
+	send   bool
+	client helper.HTTPDoer
+}
+
+func (doer printingDoer) Do(req *http.Request) (*http.Response, error) {
+	var body []byte
+
+	if req.Body != nil {
+		var err error
+
+		body, err = ioutil.ReadAll(req.Body)
+		if err != nil {
+			return nil, helper.WrapError("Error reading request body", err)
+		}
+
+		req.Body = ioutil.NopCloser(bytes.NewReader(body))
+	}
+
+	printRequest(req, body)
+
+	if doer.send {
+		return doer.client.Do(req)
+	}
+
+	// New Relic answers deployment markers with 201, Slack webhooks with 200
+	status := http.StatusOK
+	if strings.HasSuffix(req.URL.Path, "/deployments.json") {
+		status = http.StatusCreated
+	}
+
+	return &http.Response{
+		StatusCode: status,
+		Status:     http.StatusText(status),
+		Body:       ioutil.NopCloser(strings.NewReader("{}")),
+		Header:     make(http.Header),
+		Request:    req,
+	}, nil
+}
+
+func printRequest(req *http.Request, body []byte) {
+	fmt.Printf("%s %s\n", req.Method, redact.String(req.URL.String()))
+
+	var headerNames []string
+	for name := range req.Header {
+		headerNames = append(headerNames, name)
+	}
+	sort.Strings(headerNames)
+
+	for _, name := range headerNames {
+		value := strings.Join(req.Header[name], ", ")
+		if strings.EqualFold(name, "Api-Key") {
+			value = redact.Mask
+		}
+		fmt.Printf("%s: %s\n", name, value)
+	}
+
+	var indented bytes.Buffer
+	if err := json.Indent(&indented, body, "", "  "); err == nil {
+		body = indented.Bytes()
+	}
+
+	fmt.Printf("\n%s\n\n", body)
+}
+
+func main() {
+	configPath := flag.String("config", "",
+		"JSON file with the SSM parameters, secrets and AWS resources the event is looked up in, "+
+			"{\"parameters\": {...}, \"secrets\": {...}, \"aws\": {...}}")
+	envPath := flag.String("env-file", "", "file with KEY=VALUE environment variables")
+	send := flag.Bool("send", false, "send the notifications instead of only printing them")
+
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] <event.json>\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	// the payloads go to stdout, the notifier's own log lines to stderr
+	logger.SetOutput(os.Stderr)
+	metrics.SetOutput(os.Stderr)
+
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if *envPath != "" {
+		if err := helper.LoadEnvFile(*envPath); err != nil {
+			log.Fatalf("%v", err)
+		}
+	}
+
+	payload, err := ioutil.ReadFile(flag.Arg(0))
+	if err != nil {
+		log.Fatalf("Error reading event file '%s': %v", flag.Arg(0), err)
+	}
+
+	err = handler.Initialize()
+	if err != nil {
+		log.Fatalf("Environment validation failed: %v", err)
+	}
+
+	// AWS is never called, everything the event is looked up in comes
+	// from the config file and anything missing from it is not found
+	var configStore handler.ConfigStore = &handler.FileConfigStore{}
+
+	if *configPath != "" {
+		configStore, err = handler.NewFileConfigStore(*configPath)
+		if err != nil {
+			log.Fatalf("%v", err)
+		}
+	}
+
+	fixtures, err := fake.LoadFixtures(*configPath)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+
+	notifier := &handler.Notifier{
+		HTTPClient:     printingDoer{send: *send, client: helper.NewHTTPClient()},
+		ConfigStore:    configStore,
+		ECS:            fixtures.ECS,
+		DynamoDB:       fixtures.DynamoDB,
+		CloudWatch:     fixtures.CloudWatch,
+		CodeDeploy:     fixtures.CodeDeploy,
+		CodePipeline:   fixtures.CodePipeline,
+		Lambda:         fixtures.Lambda,
+		CloudFormation: fixtures.CloudFormation,
+		ECR:            fixtures.ECR,
+	}
+
+	result, err := notifier.HandleInvocation(context.Background(), json.RawMessage(payload))
+
+	if result != nil {
+		var encoded bytes.Buffer
+
+		encoder := json.NewEncoder(&encoded)
+		encoder.SetEscapeHTML(false)
+		encoder.SetIndent("", "  ")
+		_ = encoder.Encode(result)
+
+		fmt.Printf("Result: %s", encoded.String())
+	}
+
+	if err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+}
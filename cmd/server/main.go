@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"deployment-notifications/pkg/handler"
+	"deployment-notifications/pkg/helper"
+	"deployment-notifications/pkg/logger"
+	"deployment-notifications/pkg/server"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+func main() {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	err := handler.Initialize()
+
+	if err != nil {
+		logger.Fatalf(ctx, "Environment validation failed: %v", err)
+	}
+
+	config := server.LoadConfig()
+
+	// the token can be kept in Secrets Manager next to the other API tokens
+	if tokenSecret := helper.GetStringEnv("SERVER_AUTH_TOKEN_SECRET", ""); tokenSecret != "" {
+		config.AuthToken, err = helper.ReadAWSSecret(tokenSecret,
+			helper.NewSecretsManagerClient(helper.NewAWSSession()))
+		if err != nil {
+			logger.Fatalf(ctx, "Error Reading Server Auth Token Secret '%s': %v", tokenSecret, err)
+		}
+	}
+
+	eventServer, err := server.New(config, handler.HandleInvocation)
+
+	if err != nil {
+		logger.Fatalf(ctx, "Server configuration failed: %v", err)
+	}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+
+	go func() {
+		sig := <-signals
+		logger.Infof(ctx, "Received %s", sig)
+		cancel()
+	}()
+
+	err = eventServer.ListenAndServe(ctx)
+
+	if err != nil {
+		logger.Fatalf(ctx, "Server failed: %v", err)
+	}
+
+	logger.Info(ctx, "Server stopped")
+}
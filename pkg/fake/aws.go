@@ -0,0 +1,366 @@
+package fake
+
+import (
+	"encoding/base64"
+	"fmt"
+	"sync"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/aws/aws-sdk-go/service/cloudformation/cloudformationiface"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/aws/aws-sdk-go/service/cloudwatch/cloudwatchiface"
+	"github.com/aws/aws-sdk-go/service/codedeploy"
+	"github.com/aws/aws-sdk-go/service/codedeploy/codedeployiface"
+	"github.com/aws/aws-sdk-go/service/codepipeline"
+	"github.com/aws/aws-sdk-go/service/codepipeline/codepipelineiface"
+	"github.com/aws/aws-sdk-go/service/ecr"
+	"github.com/aws/aws-sdk-go/service/ecr/ecriface"
+	"github.com/aws/aws-sdk-go/service/ecs"
+	"github.com/aws/aws-sdk-go/service/ecs/ecsiface"
+	"github.com/aws/aws-sdk-go/service/lambda"
+	"github.com/aws/aws-sdk-go/service/lambda/lambdaiface"
+	"github.com/aws/aws-sdk-go/service/secretsmanager"
+	"github.com/aws/aws-sdk-go/service/secretsmanager/secretsmanageriface"
+	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/aws/aws-sdk-go/service/ssm/ssmiface"
+)
+
+// SSM serves parameters from a map. Calls it does not implement panic
+// on the embedded nil interface. SecureString parameters are only
+// handed out in plain text when asked to decrypt them
+type SSM struct {
+	ssmiface.SSMAPI
+
+	mutex            sync.Mutex
+	Parameters       map[string]string
+	SecureParameters map[string]string
+	// Err fails every call, e.g. with a throttling error
+	Err   error
+	Calls []string
+}
+
+func (fake *SSM) GetParameter(input *ssm.GetParameterInput) (*ssm.GetParameterOutput, error) {
+	fake.mutex.Lock()
+	defer fake.mutex.Unlock()
+
+	name := aws.StringValue(input.Name)
+	fake.Calls = append(fake.Calls, name)
+
+	if fake.Err != nil {
+		return nil, fake.Err
+	}
+
+	if value, ok := fake.Parameters[name]; ok {
+		return &ssm.GetParameterOutput{Parameter: &ssm.Parameter{
+			Name: input.Name, Type: aws.String(ssm.ParameterTypeString), Value: aws.String(value)}}, nil
+	}
+
+	value, ok := fake.SecureParameters[name]
+	if !ok {
+		return nil, awserr.New(ssm.ErrCodeParameterNotFound, fmt.Sprintf("parameter '%s' not found", name), nil)
+	}
+
+	if !aws.BoolValue(input.WithDecryption) {
+		value = base64.StdEncoding.EncodeToString([]byte("encrypted:" + value))
+	}
+
+	return &ssm.GetParameterOutput{Parameter: &ssm.Parameter{
+		Name: input.Name, Type: aws.String(ssm.ParameterTypeSecureString), Value: aws.String(value)}}, nil
+}
+
+// SecretsManager serves the current version of string and binary
+// secrets from maps, and other versions by secret ID and stage
+type SecretsManager struct {
+	secretsmanageriface.SecretsManagerAPI
+
+	mutex         sync.Mutex
+	Secrets       map[string]string
+	BinarySecrets map[string][]byte
+	VersionStages map[string]map[string]string
+	Err           error
+	Calls         []string
+}
+
+func (fake *SecretsManager) GetSecretValue(
+	input *secretsmanager.GetSecretValueInput) (*secretsmanager.GetSecretValueOutput, error) {
+	fake.mutex.Lock()
+	defer fake.mutex.Unlock()
+
+	secretID := aws.StringValue(input.SecretId)
+	fake.Calls = append(fake.Calls, secretID)
+
+	if fake.Err != nil {
+		return nil, fake.Err
+	}
+
+	output := &secretsmanager.GetSecretValueOutput{Name: input.SecretId}
+
+	if stage := aws.StringValue(input.VersionStage); stage != "" && stage != "AWSCURRENT" {
+		value, ok := fake.VersionStages[secretID][stage]
+		if !ok {
+			return nil, awserr.New(secretsmanager.ErrCodeResourceNotFoundException,
+				fmt.Sprintf("secret '%s' has no version in stage '%s'", secretID, stage), nil)
+		}
+
+		output.SecretString = aws.String(value)
+		return output, nil
+	}
+
+	if value, ok := fake.Secrets[secretID]; ok {
+		output.SecretString = aws.String(value)
+		return output, nil
+	}
+
+	if value, ok := fake.BinarySecrets[secretID]; ok {
+		output.SecretBinary = value
+		return output, nil
+	}
+
+	return nil, awserr.New(secretsmanager.ErrCodeResourceNotFoundException,
+		fmt.Sprintf("secret '%s' not found", secretID), nil)
+}
+
+// ECS serves services keyed "<cluster>/<service>" and the tasks each
+// deployment started, keyed by deployment id
+type ECS struct {
+	ecsiface.ECSAPI
+
+	mutex    sync.Mutex
+	Services map[string]*ecs.Service
+	Tasks    map[string][]*ecs.Task
+	Err      error
+}
+
+func (fake *ECS) DescribeServices(input *ecs.DescribeServicesInput) (*ecs.DescribeServicesOutput, error) {
+	fake.mutex.Lock()
+	defer fake.mutex.Unlock()
+
+	if fake.Err != nil {
+		return nil, fake.Err
+	}
+
+	output := &ecs.DescribeServicesOutput{}
+
+	for _, serviceName := range input.Services {
+		key := fmt.Sprintf("%s/%s", aws.StringValue(input.Cluster), aws.StringValue(serviceName))
+
+		if service, ok := fake.Services[key]; ok {
+			output.Services = append(output.Services, service)
+		} else {
+			output.Failures = append(output.Failures, &ecs.Failure{Arn: serviceName, Reason: aws.String("MISSING")})
+		}
+	}
+
+	return output, nil
+}
+
+func (fake *ECS) ListTasks(input *ecs.ListTasksInput) (*ecs.ListTasksOutput, error) {
+	fake.mutex.Lock()
+	defer fake.mutex.Unlock()
+
+	if fake.Err != nil {
+		return nil, fake.Err
+	}
+
+	output := &ecs.ListTasksOutput{}
+
+	for _, task := range fake.Tasks[aws.StringValue(input.StartedBy)] {
+		output.TaskArns = append(output.TaskArns, task.TaskArn)
+	}
+
+	return output, nil
+}
+
+func (fake *ECS) DescribeTasks(input *ecs.DescribeTasksInput) (*ecs.DescribeTasksOutput, error) {
+	fake.mutex.Lock()
+	defer fake.mutex.Unlock()
+
+	if fake.Err != nil {
+		return nil, fake.Err
+	}
+
+	wanted := make(map[string]bool)
+	for _, taskARN := range input.Tasks {
+		wanted[aws.StringValue(taskARN)] = true
+	}
+
+	output := &ecs.DescribeTasksOutput{}
+
+	for _, tasks := range fake.Tasks {
+		for _, task := range tasks {
+			if wanted[aws.StringValue(task.TaskArn)] {
+				output.Tasks = append(output.Tasks, task)
+			}
+		}
+	}
+
+	return output, nil
+}
+
+// CloudWatch keeps the metrics put to it
+type CloudWatch struct {
+	cloudwatchiface.CloudWatchAPI
+
+	mutex sync.Mutex
+	Err   error
+	// Metrics holds every PutMetricData call, in order
+	Metrics []*cloudwatch.PutMetricDataInput
+}
+
+func (fake *CloudWatch) PutMetricData(input *cloudwatch.PutMetricDataInput) (*cloudwatch.PutMetricDataOutput, error) {
+	fake.mutex.Lock()
+	defer fake.mutex.Unlock()
+
+	if fake.Err != nil {
+		return nil, fake.Err
+	}
+
+	fake.Metrics = append(fake.Metrics, input)
+
+	return &cloudwatch.PutMetricDataOutput{}, nil
+}
+
+// CodeDeploy serves deployments by id and deployment groups keyed
+// "<application>/<deploymentGroup>"
+type CodeDeploy struct {
+	codedeployiface.CodeDeployAPI
+
+	Deployments      map[string]*codedeploy.DeploymentInfo
+	DeploymentGroups map[string]*codedeploy.DeploymentGroupInfo
+	Err              error
+}
+
+func (fake *CodeDeploy) GetDeployment(input *codedeploy.GetDeploymentInput) (*codedeploy.GetDeploymentOutput, error) {
+	if fake.Err != nil {
+		return nil, fake.Err
+	}
+
+	deployment, ok := fake.Deployments[aws.StringValue(input.DeploymentId)]
+	if !ok {
+		return nil, awserr.New(codedeploy.ErrCodeDeploymentDoesNotExistException,
+			fmt.Sprintf("deployment '%s' not found", aws.StringValue(input.DeploymentId)), nil)
+	}
+
+	return &codedeploy.GetDeploymentOutput{DeploymentInfo: deployment}, nil
+}
+
+func (fake *CodeDeploy) GetDeploymentGroup(
+	input *codedeploy.GetDeploymentGroupInput) (*codedeploy.GetDeploymentGroupOutput, error) {
+	if fake.Err != nil {
+		return nil, fake.Err
+	}
+
+	key := fmt.Sprintf("%s/%s", aws.StringValue(input.ApplicationName), aws.StringValue(input.DeploymentGroupName))
+
+	group, ok := fake.DeploymentGroups[key]
+	if !ok {
+		return nil, awserr.New(codedeploy.ErrCodeDeploymentGroupDoesNotExistException,
+			fmt.Sprintf("deployment group '%s' not found", key), nil)
+	}
+
+	return &codedeploy.GetDeploymentGroupOutput{DeploymentGroupInfo: group}, nil
+}
+
+// CodePipeline serves the action executions of pipeline executions,
+// keyed by execution id
+type CodePipeline struct {
+	codepipelineiface.CodePipelineAPI
+
+	ActionExecutions map[string][]*codepipeline.ActionExecutionDetail
+	Err              error
+}
+
+func (fake *CodePipeline) ListActionExecutionsPages(input *codepipeline.ListActionExecutionsInput,
+	fn func(*codepipeline.ListActionExecutionsOutput, bool) bool) error {
+	if fake.Err != nil {
+		return fake.Err
+	}
+
+	var executionID string
+	if input.Filter != nil {
+		executionID = aws.StringValue(input.Filter.PipelineExecutionId)
+	}
+
+	fn(&codepipeline.ListActionExecutionsOutput{ActionExecutionDetails: fake.ActionExecutions[executionID]}, true)
+
+	return nil
+}
+
+// Lambda serves function configurations keyed "<function>:<qualifier>"
+type Lambda struct {
+	lambdaiface.LambdaAPI
+
+	Functions map[string]*lambda.FunctionConfiguration
+	Err       error
+}
+
+func (fake *Lambda) GetFunctionConfiguration(
+	input *lambda.GetFunctionConfigurationInput) (*lambda.FunctionConfiguration, error) {
+	if fake.Err != nil {
+		return nil, fake.Err
+	}
+
+	key := fmt.Sprintf("%s:%s", aws.StringValue(input.FunctionName), aws.StringValue(input.Qualifier))
+
+	function, ok := fake.Functions[key]
+	if !ok {
+		return nil, awserr.New(lambda.ErrCodeResourceNotFoundException,
+			fmt.Sprintf("function '%s' not found", key), nil)
+	}
+
+	return function, nil
+}
+
+// CloudFormation serves stacks keyed by the name or id they are asked for
+type CloudFormation struct {
+	cloudformationiface.CloudFormationAPI
+
+	Stacks map[string]*cloudformation.Stack
+	Err    error
+}
+
+func (fake *CloudFormation) DescribeStacks(
+	input *cloudformation.DescribeStacksInput) (*cloudformation.DescribeStacksOutput, error) {
+	if fake.Err != nil {
+		return nil, fake.Err
+	}
+
+	stack, ok := fake.Stacks[aws.StringValue(input.StackName)]
+	if !ok {
+		return nil, awserr.New("ValidationError",
+			fmt.Sprintf("Stack with id %s does not exist", aws.StringValue(input.StackName)), nil)
+	}
+
+	return &cloudformation.DescribeStacksOutput{Stacks: []*cloudformation.Stack{stack}}, nil
+}
+
+// ECR serves scan findings keyed "<repository>@<digest>", or
+// "<repository>:<tag>" for images asked for by tag
+type ECR struct {
+	ecriface.ECRAPI
+
+	Findings map[string]*ecr.ImageScanFindings
+	Err      error
+}
+
+func (fake *ECR) DescribeImageScanFindings(
+	input *ecr.DescribeImageScanFindingsInput) (*ecr.DescribeImageScanFindingsOutput, error) {
+	if fake.Err != nil {
+		return nil, fake.Err
+	}
+
+	key := fmt.Sprintf("%s:%s", aws.StringValue(input.RepositoryName), aws.StringValue(input.ImageId.ImageTag))
+	if input.ImageId.ImageDigest != nil {
+		key = fmt.Sprintf("%s@%s", aws.StringValue(input.RepositoryName), aws.StringValue(input.ImageId.ImageDigest))
+	}
+
+	findings, ok := fake.Findings[key]
+	if !ok {
+		return nil, awserr.New(ecr.ErrCodeScanNotFoundException,
+			fmt.Sprintf("no scan found for '%s'", key), nil)
+	}
+
+	return &ecr.DescribeImageScanFindingsOutput{ImageScanFindings: findings}, nil
+}
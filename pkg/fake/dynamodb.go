@@ -0,0 +1,267 @@
+package fake
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+)
+
+// DynamoDB keeps the items of each table in memory. Items with the same
+// values of the attributes Keys names for a table replace each other,
+// e.g. {"crash-loops": {"ServiceName", "StopKey"}}. Expressions support
+// comparisons, BETWEEN, attribute_exists and attribute_not_exists joined
+// by AND, which is what the notifier uses
+type DynamoDB struct {
+	dynamodbiface.DynamoDBAPI
+
+	mutex sync.Mutex
+	Keys  map[string][]string
+	Items map[string][]map[string]*dynamodb.AttributeValue
+	Err   error
+	// Writes lists the table of every item written, in order
+	Writes []string
+}
+
+func (fake *DynamoDB) PutItem(input *dynamodb.PutItemInput) (*dynamodb.PutItemOutput, error) {
+	fake.mutex.Lock()
+	defer fake.mutex.Unlock()
+
+	if fake.Err != nil {
+		return nil, fake.Err
+	}
+
+	tableName := aws.StringValue(input.TableName)
+	items := fake.Items[tableName]
+
+	existing := -1
+	for i, item := range items {
+		if fake.sameKey(tableName, item, input.Item) {
+			existing = i
+		}
+	}
+
+	if input.ConditionExpression != nil {
+		current := map[string]*dynamodb.AttributeValue{}
+		if existing >= 0 {
+			current = items[existing]
+		}
+
+		matches, err := evaluate(aws.StringValue(input.ConditionExpression), input.ExpressionAttributeNames,
+			input.ExpressionAttributeValues, current)
+		if err != nil {
+			return nil, err
+		}
+
+		if !matches {
+			return nil, awserr.New(dynamodb.ErrCodeConditionalCheckFailedException,
+				"The conditional request failed", nil)
+		}
+	}
+
+	if fake.Items == nil {
+		fake.Items = make(map[string][]map[string]*dynamodb.AttributeValue)
+	}
+
+	if existing >= 0 {
+		fake.Items[tableName][existing] = input.Item
+	} else {
+		fake.Items[tableName] = append(items, input.Item)
+	}
+
+	fake.Writes = append(fake.Writes, tableName)
+
+	return &dynamodb.PutItemOutput{}, nil
+}
+
+func (fake *DynamoDB) QueryPages(input *dynamodb.QueryInput,
+	fn func(*dynamodb.QueryOutput, bool) bool) error {
+	items, err := fake.filter(aws.StringValue(input.TableName), aws.StringValue(input.KeyConditionExpression),
+		input.ExpressionAttributeNames, input.ExpressionAttributeValues)
+	if err != nil {
+		return err
+	}
+
+	output := &dynamodb.QueryOutput{Count: aws.Int64(int64(len(items)))}
+	if aws.StringValue(input.Select) != dynamodb.SelectCount {
+		output.Items = items
+	}
+
+	fn(output, true)
+
+	return nil
+}
+
+func (fake *DynamoDB) ScanPages(input *dynamodb.ScanInput, fn func(*dynamodb.ScanOutput, bool) bool) error {
+	items, err := fake.filter(aws.StringValue(input.TableName), aws.StringValue(input.FilterExpression),
+		input.ExpressionAttributeNames, input.ExpressionAttributeValues)
+	if err != nil {
+		return err
+	}
+
+	fn(&dynamodb.ScanOutput{Count: aws.Int64(int64(len(items))), Items: items}, true)
+
+	return nil
+}
+
+func (fake *DynamoDB) filter(tableName, expression string, names map[string]*string,
+	values map[string]*dynamodb.AttributeValue) ([]map[string]*dynamodb.AttributeValue, error) {
+	fake.mutex.Lock()
+	defer fake.mutex.Unlock()
+
+	if fake.Err != nil {
+		return nil, fake.Err
+	}
+
+	var items []map[string]*dynamodb.AttributeValue
+
+	for _, item := range fake.Items[tableName] {
+		matches, err := evaluate(expression, names, values, item)
+		if err != nil {
+			return nil, err
+		}
+
+		if matches {
+			items = append(items, item)
+		}
+	}
+
+	return items, nil
+}
+
+func (fake *DynamoDB) sameKey(tableName string, item, other map[string]*dynamodb.AttributeValue) bool {
+	keys := fake.Keys[tableName]
+	if len(keys) == 0 {
+		return false
+	}
+
+	for _, key := range keys {
+		if item[key] == nil || other[key] == nil || attributeString(item[key]) != attributeString(other[key]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+var (
+	functionPattern   = regexp.MustCompile(`^(attribute_exists|attribute_not_exists)\(\s*([#\w]+)\s*\)`)
+	betweenPattern    = regexp.MustCompile(`^([#\w]+)\s+BETWEEN\s+(:\w+)\s+AND\s+(:\w+)`)
+	comparisonPattern = regexp.MustCompile(`^([#\w]+)\s*(<>|<=|>=|=|<|>)\s*(:\w+)`)
+	andPattern        = regexp.MustCompile(`^\s+AND\s+`)
+)
+
+// evaluate tells whether item matches an expression, an empty one
+// matches every item
+func evaluate(expression string, names map[string]*string, values map[string]*dynamodb.AttributeValue,
+	item map[string]*dynamodb.AttributeValue) (bool, error) {
+	name := func(name string) string {
+		if strings.HasPrefix(name, "#") {
+			return aws.StringValue(names[name])
+		}
+
+		return name
+	}
+
+	value := func(placeholder string) (*dynamodb.AttributeValue, error) {
+		if attribute, ok := values[placeholder]; ok {
+			return attribute, nil
+		}
+
+		return nil, awserr.New("ValidationException", fmt.Sprintf("value '%s' is not defined", placeholder), nil)
+	}
+
+	rest := strings.TrimSpace(expression)
+	matches := true
+
+	for rest != "" {
+		if match := functionPattern.FindStringSubmatch(rest); match != nil {
+			_, exists := item[name(match[2])]
+			matches = matches && exists == (match[1] == "attribute_exists")
+			rest = rest[len(match[0]):]
+		} else if match := betweenPattern.FindStringSubmatch(rest); match != nil {
+			low, err := value(match[2])
+			if err != nil {
+				return false, err
+			}
+
+			high, err := value(match[3])
+			if err != nil {
+				return false, err
+			}
+
+			attribute := item[name(match[1])]
+			matches = matches && attribute != nil && compare(attribute, low) >= 0 && compare(attribute, high) <= 0
+			rest = rest[len(match[0]):]
+		} else if match := comparisonPattern.FindStringSubmatch(rest); match != nil {
+			operand, err := value(match[3])
+			if err != nil {
+				return false, err
+			}
+
+			attribute := item[name(match[1])]
+			matches = matches && attribute != nil && compareWith(match[2], compare(attribute, operand))
+			rest = rest[len(match[0]):]
+		} else {
+			return false, awserr.New("ValidationException", fmt.Sprintf("unsupported expression '%s'", rest), nil)
+		}
+
+		if and := andPattern.FindString(rest); and != "" {
+			rest = rest[len(and):]
+		} else if strings.TrimSpace(rest) != "" {
+			return false, awserr.New("ValidationException", fmt.Sprintf("unsupported expression '%s'", rest), nil)
+		}
+	}
+
+	return matches, nil
+}
+
+func compareWith(operator string, comparison int) bool {
+	switch operator {
+	case "=":
+		return comparison == 0
+	case "<>":
+		return comparison != 0
+	case "<":
+		return comparison < 0
+	case "<=":
+		return comparison <= 0
+	case ">":
+		return comparison > 0
+	}
+
+	return comparison >= 0
+}
+
+// compare orders numbers by value and anything else as strings
+func compare(attribute, other *dynamodb.AttributeValue) int {
+	if attribute.N != nil && other.N != nil {
+		number, _ := strconv.ParseFloat(aws.StringValue(attribute.N), 64)
+		otherNumber, _ := strconv.ParseFloat(aws.StringValue(other.N), 64)
+
+		switch {
+		case number < otherNumber:
+			return -1
+		case number > otherNumber:
+			return 1
+		}
+
+		return 0
+	}
+
+	return strings.Compare(attributeString(attribute), attributeString(other))
+}
+
+func attributeString(attribute *dynamodb.AttributeValue) string {
+	if attribute.N != nil {
+		return aws.StringValue(attribute.N)
+	}
+
+	return aws.StringValue(attribute.S)
+}
@@ -0,0 +1,77 @@
+package fake
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+)
+
+// Fixtures are the AWS clients of a local run. They serve what the "aws"
+// object of a config file holds instead of calling AWS, e.g.
+// {"aws": {"ecs": {"services": {"<cluster>/<service>": {...}}}}}. The
+// values are shaped like the AWS API responses, anything missing is
+// answered as not found
+type Fixtures struct {
+	ECS            *ECS            `json:"ecs"`
+	CodeDeploy     *CodeDeploy     `json:"codeDeploy"`
+	CodePipeline   *CodePipeline   `json:"codePipeline"`
+	Lambda         *Lambda         `json:"lambda"`
+	CloudFormation *CloudFormation `json:"cloudFormation"`
+	ECR            *ECR            `json:"ecr"`
+	DynamoDB       *DynamoDB       `json:"dynamoDB"`
+	CloudWatch     *CloudWatch     `json:"-"`
+}
+
+// LoadFixtures reads the fixtures of a config file, an empty path
+// gives clients that find nothing
+func LoadFixtures(path string) (*Fixtures, error) {
+	var configFile struct {
+		AWS Fixtures `json:"aws"`
+	}
+
+	if path != "" {
+		content, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("error reading config file '%s': %w", path, err)
+		}
+
+		err = json.Unmarshal(content, &configFile)
+		if err != nil {
+			return nil, fmt.Errorf("error decoding AWS fixtures of config file '%s': %w", path, err)
+		}
+	}
+
+	fixtures := configFile.AWS
+
+	if fixtures.ECS == nil {
+		fixtures.ECS = &ECS{}
+	}
+
+	if fixtures.CodeDeploy == nil {
+		fixtures.CodeDeploy = &CodeDeploy{}
+	}
+
+	if fixtures.CodePipeline == nil {
+		fixtures.CodePipeline = &CodePipeline{}
+	}
+
+	if fixtures.Lambda == nil {
+		fixtures.Lambda = &Lambda{}
+	}
+
+	if fixtures.CloudFormation == nil {
+		fixtures.CloudFormation = &CloudFormation{}
+	}
+
+	if fixtures.ECR == nil {
+		fixtures.ECR = &ECR{}
+	}
+
+	if fixtures.DynamoDB == nil {
+		fixtures.DynamoDB = &DynamoDB{}
+	}
+
+	fixtures.CloudWatch = &CloudWatch{}
+
+	return &fixtures, nil
+}
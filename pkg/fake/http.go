@@ -0,0 +1,75 @@
+package fake
+
+import (
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// Request is a request the HTTP client was asked to send
+type Request struct {
+	Method string
+	URL    string
+	Header http.Header
+	Body   string
+}
+
+// HTTPClient answers every request itself instead of sending it. Unless
+// told otherwise it answers like the sinks do on success, 201 for New
+// Relic deployment markers and 200 for anything else
+type HTTPClient struct {
+	mutex    sync.Mutex
+	requests []Request
+
+	// Respond overrides the status and body of the answer to a request
+	Respond func(req Request) (int, string)
+	// Err fails every request as if the sink could not be reached
+	Err error
+}
+
+func (client *HTTPClient) Do(req *http.Request) (*http.Response, error) {
+	request := Request{Method: req.Method, URL: req.URL.String(), Header: req.Header.Clone()}
+
+	if req.Body != nil {
+		body, err := ioutil.ReadAll(req.Body)
+		if err != nil {
+			return nil, err
+		}
+
+		request.Body = string(body)
+	}
+
+	client.mutex.Lock()
+	client.requests = append(client.requests, request)
+	client.mutex.Unlock()
+
+	if client.Err != nil {
+		return nil, client.Err
+	}
+
+	status, body := http.StatusOK, "ok"
+	if strings.HasSuffix(req.URL.Path, "/deployments.json") {
+		status, body = http.StatusCreated, "{}"
+	}
+
+	if client.Respond != nil {
+		status, body = client.Respond(request)
+	}
+
+	return &http.Response{
+		StatusCode: status,
+		Status:     http.StatusText(status),
+		Body:       ioutil.NopCloser(strings.NewReader(body)),
+		Header:     make(http.Header),
+		Request:    req,
+	}, nil
+}
+
+// Requests returns the requests made so far, in order
+func (client *HTTPClient) Requests() []Request {
+	client.mutex.Lock()
+	defer client.mutex.Unlock()
+
+	return append([]Request(nil), client.requests...)
+}
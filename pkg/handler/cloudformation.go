@@ -0,0 +1,116 @@
+package handler
+
+import (
+	"context"
+	"deployment-notifications/pkg/helper"
+	"deployment-notifications/pkg/logger"
+	"deployment-notifications/pkg/validate"
+	"fmt"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+func handleCloudFormationEvent(ctx context.Context, request events.CloudWatchEvent) (LambdaResponse, error) {
+	cloudFormationInfo, err := helper.ParseCloudFormationDetails(request)
+
+	if err != nil {
+		logger.Errorf(ctx, "Error validating CloudFormation event: %v", err)
+		return LambdaResponse{Reason: "CloudFormation Details Parsing Error"}, helper.FilteredError("", err)
+	}
+
+	status := helper.GetCloudFormationStatus(request.DetailType, cloudFormationInfo)
+
+	if !helper.IsTrackedCloudFormationEvent(status) {
+		msg := fmt.Sprintf("We received CloudFormation status '%s' which we don't track", status)
+		logger.Info(ctx, msg)
+		return LambdaResponse{Reason: msg}, helper.FilteredError(msg, nil)
+	}
+
+	stackName := helper.GetStackNameFromID(cloudFormationInfo.StackID)
+	ctx = withService(ctx, stackName)
+
+	logger.Infof(ctx, "Event Source: %s", request.Source)
+	logger.Infof(ctx, "Event ID: %s", request.ID)
+	logger.Infof(ctx, "Event Detail Type: %s", request.DetailType)
+	logger.Infof(ctx, "CloudFormation Stack: %s (%s)", stackName, status)
+
+	// tags only widen the mapping, the stack name alone may still match
+	stackTags, err := helper.GetCloudFormationStackTags(cloudFormationInfo.StackID, notifierFrom(ctx).CloudFormation)
+	if err != nil {
+		logger.Warnf(ctx, "Unable to get CloudFormation stack tags: %v", err)
+	}
+
+	runEnv, _ := validate.EnvValidate()
+
+	serviceNewRelicMap, errorMessage, err := loadNewRelicMapping(ctx, runEnv["SSM_PARAMETER_NAME_NEW_RELIC"])
+	if err != nil {
+		return LambdaResponse{Reason: errorMessage}, err
+	}
+
+	serviceSlackMap, errorMessage, err := loadSlackMapping(ctx, runEnv["SSM_PARAMETER_NAME_SLACK"])
+	if err != nil {
+		return LambdaResponse{Reason: errorMessage}, err
+	}
+
+	slackMessageTemplate, err := readTemplateParameter(ctx, "SSM_PARAMETER_MESSAGE_SLACK_CLOUDFORMATION",
+		helper.DefaultSlackCloudFormationTemplate)
+	if err != nil {
+		return LambdaResponse{Reason: "SSM Slack CloudFormation Message Template Read Failure"}, err
+	}
+
+	mappingKeys := helper.GetCloudFormationMappingKeys(stackName, stackTags)
+	serviceName := ""
+	newRelicTargetApp := ""
+
+	for _, key := range mappingKeys {
+		if appID, ok := serviceNewRelicMap[key]; ok {
+			serviceName = key
+			newRelicTargetApp = appID
+			break
+		}
+	}
+
+	if serviceName == "" {
+		logger.Infof(ctx, "We did not find a mapping for stack '%s'. Aborting notification", stackName)
+		return LambdaResponse{Reason: "CloudFormation Stack not configured for notification"},
+			helper.UnmappedError("CloudFormation Stack Not Configured", nil)
+	}
+
+	slackKey := serviceName
+	for _, key := range mappingKeys {
+		if len(helper.LocateValueMultiple(key, serviceSlackMap)) > 0 {
+			slackKey = key
+			break
+		}
+	}
+
+	slackPayload := helper.GenerateCloudFormationNotificationStruct(request, cloudFormationInfo, serviceName)
+
+	newRelicAPIToken, err := readSecret(ctx, runEnv["NEW_RELIC_API_TOKEN"])
+	if err != nil {
+		logger.Errorf(ctx, "Error Reading New Relic API Token Secret '%s': %v", runEnv["NEW_RELIC_API_TOKEN"], err)
+		return LambdaResponse{Reason: "SSM New Relic Token Secret Read Failure"}, err
+	}
+
+	newRelicError := postNewRelicDeployment(ctx,
+		helper.GetCloudFormationNewRelicPayload(request, cloudFormationInfo, slackPayload),
+		runEnv["NEW_RELIC_BASE_DOMAIN"], newRelicTargetApp, newRelicAPIToken)
+
+	slackError := postSlackNotifications(ctx, slackMessageTemplate, slackPayload,
+		serviceWebhooks(ctx, slackKey, serviceSlackMap))
+
+	if newRelicError {
+		logger.Warn(ctx, "New Relic submission did not complete")
+	}
+
+	if slackError {
+		logger.Warn(ctx, "Slack submission did not complete for one or more webhooks")
+	}
+
+	if !newRelicError && !slackError {
+		return LambdaResponse{Reason: "Notification complete!"}, nil
+	}
+
+	return LambdaResponse{Reason: "Notification incomplete!"},
+		deliveryError(ctx, "One or more notification failures")
+}
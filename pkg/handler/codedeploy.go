@@ -0,0 +1,170 @@
+package handler
+
+import (
+	"context"
+	"deployment-notifications/pkg/helper"
+	"deployment-notifications/pkg/logger"
+	"deployment-notifications/pkg/validate"
+	"fmt"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/codedeploy"
+)
+
+func handleCodeDeployEvent(ctx context.Context, request events.CloudWatchEvent) (LambdaResponse, error) {
+	codeDeployDetail, err := helper.ParseCodeDeployDetails(request)
+
+	if err != nil {
+		logger.Errorf(ctx, "Error validating CodeDeploy event: %v", err)
+		return LambdaResponse{Reason: "CodeDeploy Details Parsing Error"}, helper.FilteredError("", err)
+	}
+
+	ctx = logger.WithField(ctx, logger.FieldDeploymentID, codeDeployDetail.DeploymentID)
+
+	logger.Infof(ctx, "Event Source: %s", request.Source)
+	logger.Infof(ctx, "Event ID: %s", request.ID)
+	logger.Infof(ctx, "Event Detail Type: %s", request.DetailType)
+	logger.Infof(ctx, "CodeDeploy Deployment: %s (%s)", codeDeployDetail.DeploymentID, codeDeployDetail.State)
+	logger.Infof(ctx, "CodeDeploy Deployment Group: %s/%s", codeDeployDetail.Application, codeDeployDetail.DeploymentGroup)
+
+	// the deployment details tell rollbacks and failure reasons apart,
+	// but the event alone is still worth reporting when they are missing
+	deploymentInfo, err := helper.GetCodeDeployment(codeDeployDetail.DeploymentID, notifierFrom(ctx).CodeDeploy)
+	if err != nil {
+		logger.Warnf(ctx, "Unable to get CodeDeploy deployment details: %v", err)
+	}
+
+	runEnv, _ := validate.EnvValidate()
+
+	serviceNewRelicMap, errorMessage, err := loadNewRelicMapping(ctx, runEnv["SSM_PARAMETER_NAME_NEW_RELIC"])
+	if err != nil {
+		return LambdaResponse{Reason: errorMessage}, err
+	}
+
+	ecsServiceName, err := resolveCodeDeployService(ctx, codeDeployDetail, serviceNewRelicMap)
+	if err != nil {
+		logger.Errorf(ctx, "Error mapping deployment group to an ECS service: %v", err)
+		return LambdaResponse{Reason: "CodeDeploy Deployment Group not configured for notification"}, err
+	}
+
+	ctx = withService(ctx, ecsServiceName)
+
+	newRelicTargetApp, ok := serviceNewRelicMap[ecsServiceName]
+
+	if !ok {
+		logger.Infof(ctx, "We did not find a mapping for '%s'. Aborting notification", ecsServiceName)
+		return LambdaResponse{Reason: "ECS Service not configured for notification"},
+			helper.UnmappedError("ECS Service Not Configured", nil)
+	}
+
+	serviceSlackMap, errorMessage, err := loadSlackMapping(ctx, runEnv["SSM_PARAMETER_NAME_SLACK"])
+	if err != nil {
+		return LambdaResponse{Reason: errorMessage}, err
+	}
+
+	slackMessageTemplate, err := readTemplateParameter(ctx, "SSM_PARAMETER_MESSAGE_SLACK_CODEDEPLOY",
+		helper.DefaultSlackCodeDeployTemplate)
+	if err != nil {
+		return LambdaResponse{Reason: "SSM Slack CodeDeploy Message Template Read Failure"}, err
+	}
+
+	slackPayload := helper.GenerateCodeDeployNotificationStruct(request, ecsServiceName, codeDeployDetail, deploymentInfo)
+	logger.Infof(ctx, "Blue/green phase: %s", slackPayload.Phase)
+
+	newRelicError := false
+
+	switch codeDeployDetail.State {
+	case events.CodeDeployDeploymentStateSuccess:
+		outcome := helper.DeploymentOutcomeSuccess
+		if slackPayload.IsRollback {
+			outcome = helper.DeploymentOutcomeRollback
+		}
+		recordOutcome(ctx, request, ecsServiceName, codeDeployDetail.DeploymentID, outcome, request.Time)
+
+		// only completed traffic shifts are deployments as far as New Relic is concerned
+		newRelicAPIToken, err := readSecret(ctx, runEnv["NEW_RELIC_API_TOKEN"])
+		if err != nil {
+			logger.Errorf(ctx, "Error Reading New Relic API Token Secret '%s': %v", runEnv["NEW_RELIC_API_TOKEN"], err)
+			return LambdaResponse{Reason: "SSM New Relic Token Secret Read Failure"}, err
+		}
+
+		newRelicError = postNewRelicDeployment(ctx, helper.GetCodeDeployNewRelicPayload(request, slackPayload),
+			runEnv["NEW_RELIC_BASE_DOMAIN"], newRelicTargetApp, newRelicAPIToken)
+	case events.CodeDeployDeploymentStateFailure:
+		recordOutcome(ctx, request, ecsServiceName, codeDeployDetail.DeploymentID, helper.DeploymentOutcomeFailure,
+			request.Time)
+	}
+
+	slackError := postSlackNotifications(ctx, slackMessageTemplate, slackPayload,
+		serviceWebhooks(ctx, ecsServiceName, serviceSlackMap))
+
+	if newRelicError {
+		logger.Warn(ctx, "New Relic submission did not complete")
+	}
+
+	if slackError {
+		logger.Warn(ctx, "Slack submission did not complete for one or more webhooks")
+	}
+
+	if !newRelicError && !slackError {
+		return LambdaResponse{Reason: "Notification complete!"}, nil
+	}
+
+	return LambdaResponse{Reason: "Notification incomplete!"},
+		deliveryError(ctx, "One or more notification failures")
+}
+
+func resolveCodeDeployService(ctx context.Context, codeDeployDetail events.CodeDeployEventDetail,
+	serviceNewRelicMap map[string]string) (string, error) {
+	// an explicit mapping wins, otherwise the ECS service is taken
+	// from the deployment group and named the way the ECS ARNs are
+	codeDeployParameter := helper.GetStringEnv("SSM_PARAMETER_NAME_CODEDEPLOY", "")
+
+	if codeDeployParameter != "" {
+		codeDeployMapping, err := readParameter(ctx, codeDeployParameter)
+		if err != nil {
+			logger.Errorf(ctx, "Error Reading SSM Parameter '%s': %v", codeDeployParameter, err)
+			return "", err
+		}
+
+		deploymentGroupMap, err := helper.DecodeStringJSON(codeDeployMapping)
+		if err != nil {
+			logger.Errorf(ctx, "Error Decoding SSM Parameter '%s': %v", codeDeployParameter, err)
+			return "", helper.ConfigurationError("", err)
+		}
+
+		for _, key := range helper.GetCodeDeployMappingKeys(codeDeployDetail.Application, codeDeployDetail.DeploymentGroup) {
+			if ecsServiceName, ok := deploymentGroupMap[key]; ok {
+				return ecsServiceName, nil
+			}
+		}
+	}
+
+	ecsServices, err := helper.GetCodeDeployECSServices(codeDeployDetail.Application,
+		codeDeployDetail.DeploymentGroup, notifierFrom(ctx).CodeDeploy)
+	if err != nil {
+		return "", err
+	}
+
+	return selectCodeDeployService(ecsServices, serviceNewRelicMap)
+}
+
+func selectCodeDeployService(ecsServices []*codedeploy.ECSService, serviceNewRelicMap map[string]string) (string, error) {
+	for _, ecsService := range ecsServices {
+		serviceName := aws.StringValue(ecsService.ServiceName)
+		clusterServiceName := fmt.Sprintf("%s/%s", aws.StringValue(ecsService.ClusterName), serviceName)
+
+		for _, candidate := range []string{clusterServiceName, serviceName} {
+			if _, ok := serviceNewRelicMap[candidate]; ok {
+				return candidate, nil
+			}
+		}
+	}
+
+	if len(ecsServices) > 0 {
+		return aws.StringValue(ecsServices[0].ServiceName), nil
+	}
+
+	return "", helper.UnmappedError("deployment group has no ECS services", nil)
+}
@@ -0,0 +1,184 @@
+package handler
+
+import (
+	"context"
+	"deployment-notifications/pkg/helper"
+	"deployment-notifications/pkg/logger"
+	"deployment-notifications/pkg/validate"
+	"fmt"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+func handleCodePipelineEvent(ctx context.Context, request events.CloudWatchEvent) (LambdaResponse, error) {
+	codePipelineInfo, err := helper.ParseCodePipelineDetails(request)
+
+	if err != nil {
+		logger.Errorf(ctx, "Error validating CodePipeline event: %v", err)
+		return LambdaResponse{Reason: "CodePipeline Details Parsing Error"}, helper.FilteredError("", err)
+	}
+
+	ctx = withService(ctx, codePipelineInfo.Pipeline)
+	ctx = logger.WithField(ctx, logger.FieldDeploymentID, codePipelineInfo.ExecutionID)
+
+	level := helper.GetCodePipelineLevel(request.DetailType)
+
+	if !helper.IsTrackedCodePipelineEvent(level, codePipelineInfo.State) {
+		msg := fmt.Sprintf("We received CodePipeline '%s:%s' which we don't track", level, codePipelineInfo.State)
+		logger.Info(ctx, msg)
+		return LambdaResponse{Reason: msg}, helper.FilteredError(msg, nil)
+	}
+
+	logger.Infof(ctx, "Event Source: %s", request.Source)
+	logger.Infof(ctx, "Event ID: %s", request.ID)
+	logger.Infof(ctx, "Event Detail Type: %s", request.DetailType)
+	logger.Infof(ctx, "Pipeline: %s (%s)", codePipelineInfo.Pipeline, codePipelineInfo.ExecutionID)
+	logger.Infof(ctx, "Pipeline State: %s %s", level, codePipelineInfo.State)
+
+	// the summary makes the notification useful, but the
+	// event alone is still worth reporting when it is missing
+	var summary helper.CodePipelineSummary
+
+	actionExecutions, err := helper.ListCodePipelineActionExecutions(codePipelineInfo.Pipeline,
+		codePipelineInfo.ExecutionID, notifierFrom(ctx).CodePipeline)
+	if err != nil {
+		logger.Warnf(ctx, "Unable to list CodePipeline action executions: %v", err)
+	} else {
+		summary = helper.SummarizeCodePipelineExecution(actionExecutions)
+	}
+
+	runEnv, _ := validate.EnvValidate()
+
+	serviceNewRelicMap, errorMessage, err := loadNewRelicMapping(ctx, runEnv["SSM_PARAMETER_NAME_NEW_RELIC"])
+	if err != nil {
+		return LambdaResponse{Reason: errorMessage}, err
+	}
+
+	serviceName, err := resolveCodePipelineService(ctx, codePipelineInfo.Pipeline, summary, serviceNewRelicMap)
+	if err != nil {
+		return LambdaResponse{Reason: "SSM CodePipeline Parameter Read Failure"}, err
+	}
+
+	ctx = withService(ctx, serviceName)
+
+	if serviceName == "" {
+		// pipelines that don't deploy a known service are
+		// still summarised, routed on the pipeline name
+		logger.Infof(ctx, "No ECS service found for pipeline '%s'", codePipelineInfo.Pipeline)
+	}
+
+	serviceSlackMap, errorMessage, err := loadSlackMapping(ctx, runEnv["SSM_PARAMETER_NAME_SLACK"])
+	if err != nil {
+		return LambdaResponse{Reason: errorMessage}, err
+	}
+
+	slackMessageTemplate, err := readTemplateParameter(ctx, "SSM_PARAMETER_MESSAGE_SLACK_CODEPIPELINE",
+		helper.DefaultSlackCodePipelineTemplate)
+	if err != nil {
+		return LambdaResponse{Reason: "SSM Slack CodePipeline Message Template Read Failure"}, err
+	}
+
+	slackPayload := helper.GenerateCodePipelineNotificationStruct(request, codePipelineInfo, serviceName, summary)
+	slackPayload.Deployment = describePipelineDeployment(ctx, summary.DeployTargets)
+
+	routingName := serviceName
+	if routingName == "" {
+		routingName = codePipelineInfo.Pipeline
+	}
+
+	slackError := postSlackNotifications(ctx, slackMessageTemplate, slackPayload,
+		serviceWebhooks(ctx, routingName, serviceSlackMap))
+
+	if slackError {
+		logger.Warn(ctx, "Slack submission did not complete for one or more webhooks")
+		return LambdaResponse{Reason: "Notification incomplete!"},
+			deliveryError(ctx, "One or more notification failures")
+	}
+
+	return LambdaResponse{Reason: "Notification complete!"}, nil
+}
+
+func resolveCodePipelineService(ctx context.Context, pipeline string, summary helper.CodePipelineSummary,
+	serviceNewRelicMap map[string]string) (string, error) {
+	// an explicit mapping wins, otherwise the service is taken
+	// from the pipeline's ECS or CodeDeploy deploy actions
+	codePipelineParameter := helper.GetStringEnv("SSM_PARAMETER_NAME_CODEPIPELINE", "")
+
+	if codePipelineParameter != "" {
+		codePipelineMapping, err := readParameter(ctx, codePipelineParameter)
+		if err != nil {
+			logger.Errorf(ctx, "Error Reading SSM Parameter '%s': %v", codePipelineParameter, err)
+			return "", err
+		}
+
+		pipelineMap, err := helper.DecodeStringJSON(codePipelineMapping)
+		if err != nil {
+			logger.Errorf(ctx, "Error Decoding SSM Parameter '%s': %v", codePipelineParameter, err)
+			return "", helper.ConfigurationError("", err)
+		}
+
+		if ecsServiceName, ok := pipelineMap[pipeline]; ok {
+			return ecsServiceName, nil
+		}
+	}
+
+	for _, target := range summary.DeployTargets {
+		if target.Provider == "CodeDeployToECS" {
+			ecsServiceName, err := resolveCodeDeployService(ctx, events.CodeDeployEventDetail{
+				Application:     target.Application,
+				DeploymentGroup: target.DeploymentGroup,
+			}, serviceNewRelicMap)
+
+			if err != nil {
+				logger.Warnf(ctx, "Unable to map deployment group '%s/%s' to an ECS service: %v",
+					target.Application, target.DeploymentGroup, err)
+				continue
+			}
+
+			return ecsServiceName, nil
+		}
+
+		clusterServiceName := fmt.Sprintf("%s/%s", target.ClusterName, target.ServiceName)
+
+		for _, candidate := range []string{clusterServiceName, target.ServiceName} {
+			if _, ok := serviceNewRelicMap[candidate]; ok {
+				return candidate, nil
+			}
+		}
+
+		if target.ServiceName != "" {
+			return target.ServiceName, nil
+		}
+	}
+
+	return "", nil
+}
+
+func describePipelineDeployment(ctx context.Context, deployTargets []helper.CodePipelineDeployTarget) string {
+	// ties the pipeline to the deployment its last deploy action
+	// triggered, so the summary runs through to the rollout state
+	if len(deployTargets) == 0 {
+		return ""
+	}
+
+	target := deployTargets[len(deployTargets)-1]
+
+	if target.Provider == "CodeDeployToECS" {
+		return fmt.Sprintf("CodeDeploy %s/%s %s: %s", target.Application, target.DeploymentGroup,
+			target.DeploymentID, target.Status)
+	}
+
+	ecsService, err := helper.DescribeECSService(target.ClusterName, target.ServiceName, notifierFrom(ctx).ECS)
+	if err != nil {
+		logger.Warnf(ctx, "Unable to describe ECS service for pipeline deployment: %v", err)
+		return ""
+	}
+
+	deployment := helper.FindECSDeployment(ecsService.Deployments, target.DeploymentID)
+	if deployment == nil {
+		return ""
+	}
+
+	return fmt.Sprintf("%s %s", helper.FormatECSDeployment(target.ServiceName, deployment),
+		formatDeploymentStart(deployment))
+}
@@ -0,0 +1,191 @@
+package handler
+
+import (
+	"context"
+	"deployment-notifications/pkg/helper"
+	"deployment-notifications/pkg/logger"
+	"deployment-notifications/pkg/redact"
+	"deployment-notifications/pkg/tracing"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/aws/aws-sdk-go/service/secretsmanager/secretsmanageriface"
+	"github.com/aws/aws-sdk-go/service/ssm/ssmiface"
+)
+
+// ConfigStore is where mappings, templates and API tokens are read from.
+// It is SSM Parameter Store and Secrets Manager unless replaced
+type ConfigStore interface {
+	ReadParameter(name string) (string, error)
+	ReadSecret(name string) (string, error)
+}
+
+type awsConfigStore struct {
+	ssm            ssmiface.SSMAPI
+	secretsManager secretsmanageriface.SecretsManagerAPI
+}
+
+func (store awsConfigStore) ReadParameter(name string) (string, error) {
+	return helper.ReadAWSParameter(name, store.ssm)
+}
+
+func (store awsConfigStore) ReadSecret(name string) (string, error) {
+	return helper.ReadAWSSecret(name, store.secretsManager)
+}
+
+func readParameter(ctx context.Context, name string) (string, error) {
+	_, span := tracing.Start(ctx, "SSM GetParameter", tracing.KindClient)
+	defer span.End()
+
+	span.SetAttribute("rpc.system", "aws-api")
+	span.SetAttribute("rpc.service", "SSM")
+	span.SetAttribute("rpc.method", "GetParameter")
+	span.SetAttribute("aws.ssm.parameter", name)
+
+	value, err := notifierFrom(ctx).configStore().ReadParameter(name)
+	if helper.IsConfigurationAWSError(err) {
+		err = helper.ConfigurationError("", err)
+	}
+
+	span.RecordError(err)
+
+	return value, err
+}
+
+// readTemplateParameter reads the Slack template named by parameterEnv,
+// or returns defaultTemplate when no parameter is configured
+func readTemplateParameter(ctx context.Context, parameterEnv, defaultTemplate string) (string, error) {
+	templateParameter := helper.GetStringEnv(parameterEnv, "")
+
+	if templateParameter == "" {
+		return defaultTemplate, nil
+	}
+
+	template, err := readParameter(ctx, templateParameter)
+	if err != nil {
+		logger.Errorf(ctx, "Error Reading SSM Parameter '%s': %v", templateParameter, err)
+		return "", err
+	}
+
+	return template, nil
+}
+
+func readSecret(ctx context.Context, name string) (string, error) {
+	notifier := notifierFrom(ctx)
+
+	if value, ok := notifier.cachedSecret(name); ok {
+		return value, nil
+	}
+
+	_, span := tracing.Start(ctx, "SecretsManager GetSecretValue", tracing.KindClient)
+	defer span.End()
+
+	span.SetAttribute("rpc.system", "aws-api")
+	span.SetAttribute("rpc.service", "SecretsManager")
+	span.SetAttribute("rpc.method", "GetSecretValue")
+	span.SetAttribute("aws.secretsmanager.secret", name)
+
+	value, err := notifier.configStore().ReadSecret(name)
+	if helper.IsConfigurationAWSError(err) {
+		err = helper.ConfigurationError("", err)
+	}
+
+	if err == nil {
+		redact.RegisterSecret(value)
+		notifier.cacheSecret(name, value)
+	}
+
+	span.RecordError(err)
+
+	return value, err
+}
+
+// refreshSecret reads the secrets value was read from again, e.g. after
+// a sink rejected it, and returns the new value if one has been rotated
+func refreshSecret(ctx context.Context, value string) (string, bool) {
+	for _, name := range notifierFrom(ctx).forgetSecret(value) {
+		refreshed, err := readSecret(ctx, name)
+		if err != nil {
+			logger.Warnf(ctx, "Error refreshing secret '%s': %v", name, err)
+			continue
+		}
+
+		if refreshed != value {
+			logger.Infof(ctx, "Secret '%s' has been rotated", name)
+			return refreshed, true
+		}
+	}
+
+	return "", false
+}
+
+// FileConfigStore serves parameters and secrets from a local JSON file
+// shaped {"parameters": {...}, "secrets": {...}}. Values that are not
+// strings, e.g. the service mappings, are handed out JSON encoded
+type FileConfigStore struct {
+	Parameters map[string]string
+	Secrets    map[string]string
+}
+
+func NewFileConfigStore(path string) (*FileConfigStore, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, helper.WrapError(fmt.Sprintf("Error reading config file '%s'", path), err)
+	}
+
+	var configFile struct {
+		Parameters map[string]json.RawMessage `json:"parameters"`
+		Secrets    map[string]json.RawMessage `json:"secrets"`
+	}
+
+	err = json.Unmarshal(content, &configFile)
+	if err != nil {
+		return nil, helper.WrapError(fmt.Sprintf("Error decoding config file '%s'", path), err)
+	}
+
+	return &FileConfigStore{
+		Parameters: decodeConfigValues(configFile.Parameters),
+		Secrets:    decodeConfigValues(configFile.Secrets),
+	}, nil
+}
+
+func decodeConfigValues(values map[string]json.RawMessage) map[string]string {
+	result := make(map[string]string)
+
+	for name, value := range values {
+		var stringValue string
+
+		if err := json.Unmarshal(value, &stringValue); err == nil {
+			result[name] = stringValue
+			continue
+		}
+
+		result[name] = string(value)
+	}
+
+	return result
+}
+
+func (store *FileConfigStore) ReadParameter(name string) (string, error) {
+	value, ok := store.Parameters[name]
+	if !ok {
+		return "", helper.ConfigurationError(fmt.Sprintf("Parameter '%s' not found in config file", name), nil)
+	}
+
+	return value, nil
+}
+
+// ReadSecret takes the same references as Secrets Manager. Version
+// stages are ignored, the file only holds one version of each secret
+func (store *FileConfigStore) ReadSecret(name string) (string, error) {
+	secretReference := helper.ParseSecretReference(name)
+
+	value, ok := store.Secrets[secretReference.SecretID]
+	if !ok {
+		return "", helper.ConfigurationError(
+			fmt.Sprintf("Secret '%s' not found in config file", secretReference.SecretID), nil)
+	}
+
+	return helper.GetSecretJSONKey(value, secretReference.JSONKey)
+}
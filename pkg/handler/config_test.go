@@ -0,0 +1,67 @@
+package handler_test
+
+import (
+	"deployment-notifications/pkg/handler"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeConfigFile(t *testing.T, content string) string {
+	dir, err := ioutil.TempDir("", "config")
+	assert.Nil(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	path := filepath.Join(dir, "config.json")
+	assert.Nil(t, ioutil.WriteFile(path, []byte(content), 0600))
+
+	return path
+}
+
+func TestNewFileConfigStore(t *testing.T) {
+	path := writeConfigFile(t, `{
+		"parameters": {
+			"template": "{\"text\":\"hello\"}",
+			"mapping": {"my-service": "123"}
+		},
+		"secrets": {"token": "abc", "tokens": {"newrelic": "NRAK-TEST"}}
+	}`)
+
+	store, err := handler.NewFileConfigStore(path)
+	assert.Nil(t, err)
+
+	value, err := store.ReadParameter("template")
+	assert.Nil(t, err)
+	assert.Equal(t, `{"text":"hello"}`, value)
+
+	value, err = store.ReadParameter("mapping")
+	assert.Nil(t, err)
+	assert.JSONEq(t, `{"my-service": "123"}`, value)
+
+	value, err = store.ReadSecret("token")
+	assert.Nil(t, err)
+	assert.Equal(t, "abc", value)
+
+	value, err = store.ReadSecret("tokens#newrelic")
+	assert.Nil(t, err)
+	assert.Equal(t, "NRAK-TEST", value)
+
+	_, err = store.ReadSecret("tokens#slack")
+	assert.NotNil(t, err)
+
+	_, err = store.ReadParameter("missing")
+	assert.NotNil(t, err)
+
+	_, err = store.ReadSecret("template")
+	assert.NotNil(t, err)
+}
+
+func TestNewFileConfigStoreErrors(t *testing.T) {
+	_, err := handler.NewFileConfigStore(filepath.Join(os.TempDir(), "does-not-exist.json"))
+	assert.NotNil(t, err)
+
+	_, err = handler.NewFileConfigStore(writeConfigFile(t, `{"parameters": `))
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,178 @@
+package handler
+
+import (
+	"context"
+	"deployment-notifications/pkg/helper"
+	"deployment-notifications/pkg/logger"
+	"deployment-notifications/pkg/validate"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go/aws"
+)
+
+func handleTaskStateChangeEvent(ctx context.Context, request events.CloudWatchEvent) (LambdaResponse, error) {
+	taskStateInfo, err := helper.ParseTaskStateDetails(request)
+
+	if err != nil {
+		logger.Errorf(ctx, "Error validating task state change event: %v", err)
+		return LambdaResponse{Reason: "Task State Details Parsing Error"}, helper.FilteredError("", err)
+	}
+
+	if taskStateInfo.LastStatus != "STOPPED" {
+		msg := fmt.Sprintf("We received task status '%s' which we don't track. We only want 'STOPPED'",
+			taskStateInfo.LastStatus)
+		return LambdaResponse{Reason: msg}, helper.FilteredError(msg, nil)
+	}
+
+	ecsServiceName, err := helper.GetServiceNameFromGroup(taskStateInfo.Group)
+
+	if err != nil {
+		logger.Warnf(ctx, "Ignoring stopped task '%s': %v", taskStateInfo.TaskARN, err)
+		return LambdaResponse{Reason: "Stopped task does not belong to a service"}, helper.FilteredError("", err)
+	}
+
+	ctx = withService(ctx, ecsServiceName)
+
+	if !helper.IsCrashStop(taskStateInfo) {
+		msg := fmt.Sprintf("Task '%s' stopped with code '%s', which is not a crash",
+			taskStateInfo.TaskARN, taskStateInfo.StopCode)
+		logger.Info(ctx, msg)
+		return LambdaResponse{Reason: msg}, helper.FilteredError(msg, nil)
+	}
+
+	logger.Infof(ctx, "Event Source: %s", request.Source)
+	logger.Infof(ctx, "Event ID: %s", request.ID)
+	logger.Infof(ctx, "Event Detail Type: %s", request.DetailType)
+	logger.Infof(ctx, "Stopped Task: %s", taskStateInfo.TaskARN)
+	logger.Infof(ctx, "Stopped Reason: %s", taskStateInfo.StoppedReason)
+	logger.Infof(ctx, "Container Exit Codes: %s", helper.FormatContainerExitCodes(taskStateInfo.Containers))
+
+	tableName := helper.GetCrashLoopTableName()
+
+	if tableName == "" {
+		logger.Info(ctx, "Stopped task received but CRASH_LOOP_TABLE_NAME is not set")
+		return LambdaResponse{Reason: "Crash loop table not configured"},
+			helper.ConfigurationError("Crash loop table not configured", nil)
+	}
+
+	stoppedAt, err := time.Parse(time.RFC3339, taskStateInfo.StoppedAt)
+	if err != nil {
+		stoppedAt = request.Time
+	}
+
+	windowMinutes := helper.GetCrashLoopWindowMinutes()
+	window := time.Duration(windowMinutes) * time.Minute
+
+	record := helper.NewTaskStopRecord(ecsServiceName, taskStateInfo.TaskARN, stoppedAt, 2*window)
+
+	dryRun := helper.IsDryRunWrite()
+
+	if dryRun {
+		recordDryRunWrite(ctx, helper.StoreDynamoDB, tableName, record)
+	} else if err = helper.PutTaskStopRecord(tableName, record, notifierFrom(ctx).DynamoDB); err != nil {
+		logger.Errorf(ctx, "Error recording stopped task: %v", err)
+		return LambdaResponse{Reason: "Crash Loop Record Failure"}, err
+	}
+
+	stopCount, err := helper.CountTaskStops(tableName, ecsServiceName, stoppedAt.Add(-window), notifierFrom(ctx).DynamoDB)
+	if err != nil {
+		logger.Errorf(ctx, "Error counting stopped tasks: %v", err)
+		return LambdaResponse{Reason: "Crash Loop Count Failure"}, err
+	}
+
+	if dryRun {
+		// counted as if the stop had been written
+		stopCount++
+	}
+
+	threshold := helper.GetCrashLoopThreshold()
+	logger.Infof(ctx, "%d task(s) of '%s' stopped in the last %d minutes, threshold is %d",
+		stopCount, ecsServiceName, windowMinutes, threshold)
+
+	if stopCount < threshold {
+		return LambdaResponse{Reason: "Crash loop threshold not crossed"}, nil
+	}
+
+	slackPayload := helper.GenerateCrashLoopNotificationStruct(request, taskStateInfo, stopCount, windowMinutes)
+
+	clusterName := slackPayload.ClusterName
+	if clusterName == "" {
+		clusterName = helper.GetStringEnv("ECS_CLUSTER_NAME", "default")
+	}
+
+	deployment, err := describePrimaryDeployment(ctx, clusterName, ecsServiceName)
+	if err != nil {
+		logger.Warnf(ctx, "Unable to look up last deployment, not alerting: %v", err)
+		return LambdaResponse{Reason: "Last Deployment Lookup Failure"}, err
+	}
+
+	deploymentWindow := time.Duration(helper.GetCrashLoopDeploymentWindowMinutes()) * time.Minute
+
+	if deployment.CreatedAt == nil || stoppedAt.Sub(*deployment.CreatedAt) > deploymentWindow {
+		msg := fmt.Sprintf("Tasks of '%s' are stopping but there was no deployment in the last %s",
+			ecsServiceName, deploymentWindow)
+		logger.Info(ctx, msg)
+		return LambdaResponse{Reason: msg}, nil
+	}
+
+	slackPayload.DeploymentID = aws.StringValue(deployment.Id)
+	ctx = logger.WithField(ctx, logger.FieldDeploymentID, slackPayload.DeploymentID)
+	slackPayload.DeploymentStartedAt = formatDeploymentStart(deployment)
+
+	logger.Infof(ctx, "Crash loop detected for '%s' after deployment '%s'", ecsServiceName, slackPayload.DeploymentID)
+
+	// the count can jump past the threshold, e.g. when stops arrive out
+	// of order or together, so the marker keeps a crash loop to one
+	// notification per window instead of one per task
+	alertRecord := helper.NewCrashLoopAlertRecord(ecsServiceName, stoppedAt, window)
+	alerted := true
+
+	if dryRun {
+		recordDryRunWrite(ctx, helper.StoreDynamoDB, tableName, alertRecord)
+	} else if alerted, err = helper.PutCrashLoopAlertRecord(tableName, alertRecord,
+		notifierFrom(ctx).DynamoDB); err != nil {
+		logger.Errorf(ctx, "Error recording crash loop alert: %v", err)
+		return LambdaResponse{Reason: "Crash Loop Alert Record Failure"}, err
+	}
+
+	if !alerted {
+		msg := fmt.Sprintf("Crash loop of '%s' was already alerted in this window", ecsServiceName)
+		logger.Info(ctx, msg)
+		return LambdaResponse{Reason: msg}, nil
+	}
+
+	runEnv, _ := validate.EnvValidate()
+
+	// crash loops go to the same on-call channels as service actions
+	slackParameter := helper.GetStringEnv("SSM_PARAMETER_NAME_SLACK_SERVICE_ACTION", runEnv["SSM_PARAMETER_NAME_SLACK"])
+
+	serviceSlackMap, errorMessage, err := loadSlackMapping(ctx, slackParameter)
+	if err != nil {
+		return LambdaResponse{Reason: errorMessage}, err
+	}
+
+	slackMessageTemplate, err := readTemplateParameter(ctx, "SSM_PARAMETER_MESSAGE_SLACK_CRASH_LOOP",
+		helper.DefaultSlackCrashLoopTemplate)
+	if err != nil {
+		return LambdaResponse{Reason: "SSM Slack Crash Loop Message Template Read Failure"}, err
+	}
+
+	// service mappings are keyed like the deployment ARNs, which
+	// include the cluster for new style ARNs
+	webhooks := serviceWebhooks(ctx, ecsServiceName, serviceSlackMap)
+	webhooks = append(webhooks, helper.LocateValueMultiple(
+		strings.Join([]string{clusterName, ecsServiceName}, "/"), serviceSlackMap)...)
+
+	slackError := postSlackNotifications(ctx, slackMessageTemplate, slackPayload, webhooks)
+
+	if slackError {
+		logger.Warn(ctx, "Slack submission did not complete for one or more webhooks")
+		return LambdaResponse{Reason: "Notification incomplete!"},
+			deliveryError(ctx, "One or more notification failures")
+	}
+
+	return LambdaResponse{Reason: "Notification complete!"}, nil
+}
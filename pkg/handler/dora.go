@@ -0,0 +1,220 @@
+package handler
+
+import (
+	"context"
+	"deployment-notifications/pkg/helper"
+	"deployment-notifications/pkg/logger"
+	"deployment-notifications/pkg/validate"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+)
+
+func recordDeploymentOutcome(ctx context.Context, request events.CloudWatchEvent, eventDetails helper.EventInfo,
+	rollbackInfo helper.RollbackInfo) {
+	outcome, ok := helper.GetDeploymentOutcome(eventDetails.EventName)
+
+	if !ok {
+		return
+	}
+
+	if outcome == helper.DeploymentOutcomeSuccess && rollbackInfo.IsRollback {
+		outcome = helper.DeploymentOutcomeRollback
+	}
+
+	ecsServiceName, err := helper.GetServiceNameFromARN(request.Resources[0])
+
+	if err != nil {
+		logger.Warnf(ctx, "Not recording deployment outcome, service name parse failed: %v", err)
+		return
+	}
+
+	deploymentTime, err := time.Parse(time.RFC3339, eventDetails.UpdatedAt)
+
+	if err != nil {
+		deploymentTime = request.Time
+	}
+
+	recordOutcome(ctx, request, ecsServiceName, eventDetails.DeploymentID, outcome, deploymentTime)
+}
+
+func recordOutcome(ctx context.Context, request events.CloudWatchEvent, serviceName, deploymentID, outcome string,
+	deploymentTime time.Time) {
+	// recording is best effort - a DynamoDB problem must
+	// never stop the deployment from being notified
+	tableName := helper.GetDORATableName()
+
+	if tableName == "" {
+		return
+	}
+
+	record := helper.NewDeploymentRecord(serviceName, deploymentID, outcome,
+		deploymentTime, request.ID, request.AccountID, request.Region)
+
+	if helper.IsDryRunWrite() {
+		recordDryRunWrite(ctx, helper.StoreDynamoDB, tableName, record)
+		return
+	}
+
+	err := helper.PutDeploymentRecord(tableName, record, notifierFrom(ctx).DynamoDB)
+
+	if err != nil {
+		logger.Errorf(ctx, "Error recording deployment outcome for '%s': %v", serviceName, err)
+		return
+	}
+
+	logger.Infof(ctx, "Recorded '%s' deployment outcome for '%s'", outcome, serviceName)
+}
+
+func handleScheduledEvent(ctx context.Context, request events.CloudWatchEvent) (LambdaResponse, error) {
+	tableName := helper.GetDORATableName()
+
+	if tableName == "" {
+		logger.Info(ctx, "Scheduled event received but DORA_TABLE_NAME is not set")
+		return LambdaResponse{Reason: "DORA table not configured"},
+			helper.ConfigurationError("DORA table not configured", nil)
+	}
+
+	runEnv, _ := validate.EnvValidate()
+
+	windowEnd := request.Time
+	if windowEnd.IsZero() {
+		windowEnd = time.Now()
+	}
+	windowStart := windowEnd.AddDate(0, 0, -helper.GetDORAWindowDays())
+
+	logger.Infof(ctx, "Computing DORA metrics from %s to %s", windowStart.Format(time.RFC3339),
+		windowEnd.Format(time.RFC3339))
+
+	records, err := helper.ScanDeploymentRecords(tableName, windowStart, windowEnd, notifierFrom(ctx).DynamoDB)
+	if err != nil {
+		logger.Errorf(ctx, "Error reading deployment records: %v", err)
+		return LambdaResponse{Reason: "DORA Deployment Records Read Failure"}, err
+	}
+
+	groupBy := helper.GetDORAGroupBy()
+	teamMap := make(map[string]string)
+
+	if groupBy == "team" {
+		teamParameter := helper.GetStringEnv("SSM_PARAMETER_NAME_TEAMS", "")
+
+		if teamParameter == "" {
+			logger.Info(ctx, "DORA_GROUP_BY is 'team' but SSM_PARAMETER_NAME_TEAMS is not set")
+			return LambdaResponse{Reason: "SSM Teams Parameter not configured"},
+				helper.ConfigurationError("SSM Teams Parameter not configured", nil)
+		}
+
+		teamMapping, err := readParameter(ctx, teamParameter)
+		if err != nil {
+			logger.Errorf(ctx, "Error Reading SSM Parameter '%s': %v", teamParameter, err)
+			return LambdaResponse{Reason: "SSM Teams Parameter Read Failure"}, err
+		}
+
+		teamMap, err = helper.DecodeStringJSON(teamMapping)
+		if err != nil {
+			logger.Errorf(ctx, "Error Decoding SSM Parameter '%s': %v", teamParameter, err)
+			return LambdaResponse{Reason: "SSM Teams Parameter Decode Failure"}, helper.ConfigurationError("", err)
+		}
+	}
+
+	metrics := helper.ComputeDORAMetrics(records, windowStart, windowEnd, groupBy, teamMap)
+	logger.Infof(ctx, "Computed DORA metrics for %d %s(s) from %d records", len(metrics), groupBy, len(records))
+
+	digestPayload, err := helper.GenerateDORADigestPayload(metrics, windowStart, windowEnd)
+	if err != nil {
+		logger.Errorf(ctx, "Error generating DORA digest: %v", err)
+		return LambdaResponse{Reason: "DORA Digest Generation Failure"}, err
+	}
+
+	serviceSlackMap, errorMessage, err := loadSlackMapping(ctx, runEnv["SSM_PARAMETER_NAME_SLACK"])
+	if err != nil {
+		return LambdaResponse{Reason: errorMessage}, err
+	}
+
+	// a dedicated "dora-digest" entry wins over the default channel
+	digestWebhooks := helper.LocateValueMultiple("dora-digest", serviceSlackMap)
+
+	if len(digestWebhooks) == 0 {
+		digestWebhooks = []string{helper.GetDefaultWebhook(serviceSlackMap)}
+	}
+
+	slackError := false
+	var metricsError error
+
+	slackCtx := logger.WithField(ctx, logger.FieldSink, helper.SinkSlack)
+
+	for _, webhook := range digestWebhooks {
+		if helper.IsDryRun(helper.SinkSlack) {
+			slackError = recordDryRun(slackCtx, helper.SinkSlack, webhook, []byte(digestPayload),
+				helper.ValidateSlackPayload(digestPayload)) || slackError
+			continue
+		}
+
+		startedAt := time.Now()
+		slackStatus, attempts, err := retryDelivery(slackCtx, func() (int, int, error) {
+			return postSlackWebhook(slackCtx, webhook, func(webhookURL string) (int, error) {
+				return helper.PostSlackPayload(slackCtx, digestPayload, webhookURL)
+			})
+		})
+		recordDelivery(slackCtx, webhook, startedAt, httpCode(slackStatus), attempts, err)
+
+		if err != nil {
+			slackError = true
+			logger.Warnf(slackCtx, "Slack digest post failed with status: %d, %v", slackStatus, err)
+		}
+	}
+
+	namespace := helper.GetDORACloudWatchNamespace()
+
+	if namespace != "" {
+		dimensionName := "Service"
+		if groupBy == "team" {
+			dimensionName = "Team"
+		}
+
+		metricData := helper.GenerateDORAMetricData(metrics, dimensionName, windowEnd)
+
+		if helper.IsDryRunWrite() {
+			recordDryRunWrite(ctx, helper.StoreCloudWatch, namespace, metricData)
+		} else if metricsError = putDORAMetrics(ctx, namespace, metricData); metricsError != nil {
+			logger.Errorf(ctx, "Error emitting DORA metrics to CloudWatch: %v", metricsError)
+		} else {
+			logger.Infof(ctx, "Emitted %d DORA metrics to namespace '%s'", len(metricData), namespace)
+		}
+	}
+
+	if !slackError && metricsError == nil {
+		return LambdaResponse{Reason: "DORA digest complete!"}, nil
+	}
+
+	if metricsError != nil {
+		// the digest is not posted again for the metrics once it has
+		// reached a channel, the write was already retried in place
+		if delivered, _ := deliveryCounts(ctx); delivered > 0 {
+			return LambdaResponse{Reason: "DORA digest incomplete!"},
+				helper.PermanentSinkError("DORA metrics not emitted after the digest was posted", metricsError)
+		}
+
+		return LambdaResponse{Reason: "DORA digest incomplete!"},
+			helper.TransientSinkError("One or more DORA digest failures", metricsError)
+	}
+
+	return LambdaResponse{Reason: "DORA digest incomplete!"},
+		deliveryError(ctx, "One or more DORA digest failures")
+}
+
+// putDORAMetrics writes the digest metrics, trying again in place while
+// CloudWatch fails in a way that may pass
+func putDORAMetrics(ctx context.Context, namespace string, metricData []*cloudwatch.MetricDatum) error {
+	_, _, err := retryDelivery(ctx, func() (int, int, error) {
+		err := helper.PutCloudWatchMetrics(namespace, metricData, notifierFrom(ctx).CloudWatch)
+		if helper.IsConfigurationAWSError(err) {
+			err = helper.ConfigurationError("", err)
+		}
+
+		return 0, 1, err
+	})
+
+	return err
+}
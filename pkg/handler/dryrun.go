@@ -0,0 +1,77 @@
+package handler
+
+import (
+	"context"
+	"deployment-notifications/pkg/helper"
+	"deployment-notifications/pkg/logger"
+	"deployment-notifications/pkg/redact"
+	"encoding/json"
+)
+
+// DryRunReport is returned instead of the usual response when DRY_RUN
+// is set, listing what every dry-run sink would have sent
+type DryRunReport struct {
+	Message    string                  `json:"message"`
+	Error      string                  `json:"error,omitempty"`
+	DryRun     []string                `json:"dryRun"`
+	Deliveries []helper.DryRunDelivery `json:"deliveries"`
+	WouldWrite []helper.DryRunWrite    `json:"wouldWrite"`
+	Response   *LambdaResponse         `json:"response,omitempty"`
+}
+
+type dryRunReportKey struct{}
+
+func withDryRunReport(ctx context.Context) (context.Context, *DryRunReport) {
+	report := &DryRunReport{DryRun: helper.GetDryRunSinks(), Deliveries: []helper.DryRunDelivery{},
+		WouldWrite: []helper.DryRunWrite{}}
+
+	return context.WithValue(ctx, dryRunReportKey{}, report), report
+}
+
+func recordDryRun(ctx context.Context, sink, target string, payload []byte, validationErr error) bool {
+	// logs what would have been sent and returns true if it is invalid,
+	// the same way a failed delivery is reported
+	ctx = logger.WithField(ctx, logger.FieldSink, sink)
+
+	// the destination is checked as it would be when sending, referenced
+	// webhooks are only read when sending
+	if store, _ := helper.ParseWebhookReference(target); validationErr == nil && store == "" {
+		validationErr = helper.CheckDestination(sink, target)
+	}
+
+	delivery := helper.DryRunDelivery{Sink: sink, Target: redact.String(target), Valid: validationErr == nil}
+
+	if json.Valid(payload) {
+		delivery.Payload = json.RawMessage(payload)
+	}
+
+	if validationErr != nil {
+		delivery.Error = redact.String(validationErr.Error())
+		logger.Warnf(ctx, "Dry run: invalid %s payload for '%s': %v", sink, target, validationErr)
+	}
+
+	logger.Infof(ctx, "Dry run: would send %s payload to '%s': %s", sink, target, payload)
+
+	recordSinkResult(ctx, SinkResult{Sink: sink, Target: target, Status: SinkStatusDryRun, Error: delivery.Error})
+
+	if report, ok := ctx.Value(dryRunReportKey{}).(*DryRunReport); ok {
+		report.Deliveries = append(report.Deliveries, delivery)
+	}
+
+	return validationErr != nil
+}
+
+func recordDryRunWrite(ctx context.Context, store, target string, item interface{}) {
+	// logs what would have been written instead of writing it
+	encoded, err := json.Marshal(item)
+	if err != nil {
+		logger.Warnf(ctx, "Dry run: unable to encode %s item for '%s': %v", store, target, err)
+	}
+
+	logger.Infof(ctx, "Dry run: would write %s item to '%s': %s", store, target, encoded)
+
+	if report, ok := ctx.Value(dryRunReportKey{}).(*DryRunReport); ok {
+		report.WouldWrite = append(report.WouldWrite, helper.DryRunWrite{Store: store, Target: target,
+			Item: json.RawMessage(encoded)})
+	}
+}
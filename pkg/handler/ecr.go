@@ -0,0 +1,203 @@
+package handler
+
+import (
+	"context"
+	"deployment-notifications/pkg/helper"
+	"deployment-notifications/pkg/logger"
+	"deployment-notifications/pkg/validate"
+	"fmt"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+func handleECREvent(ctx context.Context, request events.CloudWatchEvent) (LambdaResponse, error) {
+	var repositoryName string
+	var slackPayload helper.ECRNotificationFields
+
+	if helper.IsECRImageScanEvent(request.DetailType) {
+		imageScanInfo, err := helper.ParseECRImageScanDetails(request)
+		if err != nil {
+			logger.Errorf(ctx, "Error validating ECR image scan event: %v", err)
+			return LambdaResponse{Reason: "ECR Image Scan Details Parsing Error"}, helper.FilteredError("", err)
+		}
+
+		// clean scans are not worth a heads-up
+		if !helper.IsECRScanComplete(imageScanInfo.ScanStatus) || imageScanInfo.FindingSeverityCounts["CRITICAL"] == 0 {
+			msg := fmt.Sprintf("ECR scan of '%s' is '%s' without critical findings",
+				imageScanInfo.RepositoryName, imageScanInfo.ScanStatus)
+			logger.Info(ctx, msg)
+			return LambdaResponse{Reason: msg}, helper.FilteredError(msg, nil)
+		}
+
+		repositoryName = imageScanInfo.RepositoryName
+		slackPayload = helper.GenerateECRImageScanNotificationStruct(request, "", imageScanInfo)
+	} else {
+		imageActionInfo, err := helper.ParseECRImageActionDetails(request)
+		if err != nil {
+			logger.Errorf(ctx, "Error validating ECR image action event: %v", err)
+			return LambdaResponse{Reason: "ECR Image Action Details Parsing Error"}, helper.FilteredError("", err)
+		}
+
+		if imageActionInfo.ActionType != "PUSH" || imageActionInfo.Result != "SUCCESS" {
+			msg := fmt.Sprintf("We received ECR image action '%s' (%s) which we don't track. We only want successful 'PUSH'",
+				imageActionInfo.ActionType, imageActionInfo.Result)
+			logger.Info(ctx, msg)
+			return LambdaResponse{Reason: msg}, helper.FilteredError(msg, nil)
+		}
+
+		repositoryName = imageActionInfo.RepositoryName
+		slackPayload = helper.GenerateECRImageActionNotificationStruct(request, "", imageActionInfo)
+	}
+
+	logger.Infof(ctx, "Event Source: %s", request.Source)
+	logger.Infof(ctx, "Event ID: %s", request.ID)
+	logger.Infof(ctx, "Event Detail Type: %s", request.DetailType)
+	logger.Infof(ctx, "ECR Repository: %s (%s)", repositoryName, slackPayload.ImageDigest)
+
+	runEnv, _ := validate.EnvValidate()
+
+	serviceNewRelicMap, errorMessage, err := loadNewRelicMapping(ctx, runEnv["SSM_PARAMETER_NAME_NEW_RELIC"])
+	if err != nil {
+		return LambdaResponse{Reason: errorMessage}, err
+	}
+
+	ecsServiceName, err := resolveECRService(ctx, repositoryName, serviceNewRelicMap)
+	if err != nil {
+		return LambdaResponse{Reason: "SSM ECR Parameter Read Failure"}, err
+	}
+
+	ctx = withService(ctx, ecsServiceName)
+
+	if ecsServiceName == "" {
+		logger.Infof(ctx, "We did not find a mapping for repository '%s'. Aborting notification", repositoryName)
+		return LambdaResponse{Reason: "ECR Repository not configured for notification"},
+			helper.UnmappedError("ECR Repository Not Configured", nil)
+	}
+
+	serviceSlackMap, errorMessage, err := loadSlackMapping(ctx, runEnv["SSM_PARAMETER_NAME_SLACK"])
+	if err != nil {
+		return LambdaResponse{Reason: errorMessage}, err
+	}
+
+	slackMessageTemplate, err := readTemplateParameter(ctx, "SSM_PARAMETER_MESSAGE_SLACK_ECR",
+		helper.DefaultSlackECRTemplate)
+	if err != nil {
+		return LambdaResponse{Reason: "SSM Slack ECR Message Template Read Failure"}, err
+	}
+
+	slackPayload.ServiceName = ecsServiceName
+
+	slackError := postSlackNotifications(ctx, slackMessageTemplate, slackPayload,
+		serviceWebhooks(ctx, ecsServiceName, serviceSlackMap))
+
+	if slackError {
+		logger.Warn(ctx, "Slack submission did not complete for one or more webhooks")
+		return LambdaResponse{Reason: "Notification incomplete!"},
+			deliveryError(ctx, "One or more notification failures")
+	}
+
+	return LambdaResponse{Reason: "Notification complete!"}, nil
+}
+
+func resolveECRService(ctx context.Context, repositoryName string,
+	serviceNewRelicMap map[string]string) (string, error) {
+	// an explicit repository mapping wins, otherwise a repository
+	// named like a mapped service is taken to be that service
+	ecrParameter := helper.GetStringEnv("SSM_PARAMETER_NAME_ECR", "")
+
+	if ecrParameter != "" {
+		ecrMapping, err := readParameter(ctx, ecrParameter)
+		if err != nil {
+			logger.Errorf(ctx, "Error Reading SSM Parameter '%s': %v", ecrParameter, err)
+			return "", err
+		}
+
+		repositoryMap, err := helper.DecodeStringJSON(ecrMapping)
+		if err != nil {
+			logger.Errorf(ctx, "Error Decoding SSM Parameter '%s': %v", ecrParameter, err)
+			return "", helper.ConfigurationError("", err)
+		}
+
+		if ecsServiceName, ok := repositoryMap[repositoryName]; ok {
+			return ecsServiceName, nil
+		}
+	}
+
+	if _, ok := serviceNewRelicMap[repositoryName]; ok {
+		return repositoryName, nil
+	}
+
+	return "", nil
+}
+
+func warnCriticalFindings(ctx context.Context, request events.CloudWatchEvent, eventDetails helper.EventInfo,
+	ecsServiceName string, serviceSlackMap map[string][]string) {
+	// best effort - the deployment has been notified already and a
+	// missing scan or permission must not fail the notification
+	if !helper.GetECRDeployScanCheck() {
+		return
+	}
+
+	clusterName, serviceName, err := helper.GetClusterAndServiceFromARN(request.Resources[0])
+	if err != nil {
+		logger.Warnf(ctx, "Not checking image scan findings: %v", err)
+		return
+	}
+
+	images, err := helper.ListDeploymentImages(clusterName, serviceName, eventDetails.DeploymentID,
+		notifierFrom(ctx).ECS)
+	if err != nil {
+		logger.Warnf(ctx, "Not checking image scan findings: %v", err)
+		return
+	}
+
+	var flaggedImages []helper.DeployedImage
+
+	for _, image := range images {
+		ecrImage, ok := helper.ParseECRImage(image.Image)
+		if !ok {
+			continue
+		}
+
+		if image.Digest != "" {
+			ecrImage.Digest = image.Digest
+		}
+
+		criticalCount, err := helper.GetECRCriticalFindings(ecrImage, notifierFrom(ctx).ECR)
+		if err != nil {
+			logger.Warnf(ctx, "Unable to check scan findings of '%s': %v", image.Image, err)
+			continue
+		}
+
+		if criticalCount > 0 {
+			image.CriticalCount = criticalCount
+			flaggedImages = append(flaggedImages, image)
+		}
+	}
+
+	if len(flaggedImages) == 0 {
+		return
+	}
+
+	logger.Infof(ctx, "Deployment '%s' rolled out %d image(s) with critical findings", eventDetails.DeploymentID,
+		len(flaggedImages))
+
+	warningTemplate, err := readTemplateParameter(ctx, "SSM_PARAMETER_MESSAGE_SLACK_ECR_DEPLOY",
+		helper.DefaultSlackECRDeployWarningTemplate)
+	if err != nil {
+		return
+	}
+
+	warning := helper.ECRDeployWarningFields{
+		ServiceName:  ecsServiceName,
+		DeploymentID: eventDetails.DeploymentID,
+		Images:       helper.FormatDeployedImages(flaggedImages),
+		AWSReference: request.ID,
+		AWSRegion:    request.Region,
+		AWSAccount:   request.AccountID,
+	}
+
+	if postSlackNotifications(ctx, warningTemplate, warning, serviceWebhooks(ctx, ecsServiceName, serviceSlackMap)) {
+		logger.Warn(ctx, "Slack critical findings warning did not complete for one or more webhooks")
+	}
+}
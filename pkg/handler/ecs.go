@@ -0,0 +1,34 @@
+package handler
+
+import (
+	"context"
+	"deployment-notifications/pkg/helper"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ecs"
+)
+
+func describePrimaryDeployment(ctx context.Context, clusterName, ecsServiceName string) (*ecs.Deployment, error) {
+	ecsService, err := helper.DescribeECSService(clusterName, ecsServiceName, notifierFrom(ctx).ECS)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, deployment := range ecsService.Deployments {
+		if aws.StringValue(deployment.Status) == "PRIMARY" {
+			return deployment, nil
+		}
+	}
+
+	return nil, helper.WrapError(fmt.Sprintf("No primary deployment found for ECS service '%s'", ecsServiceName), nil)
+}
+
+func formatDeploymentStart(deployment *ecs.Deployment) string {
+	if deployment.CreatedAt == nil {
+		return ""
+	}
+
+	return fmt.Sprintf("(started %s)", deployment.CreatedAt.UTC().Format("2006-01-02T15:04:05Z"))
+}
@@ -0,0 +1,405 @@
+package handler
+
+import (
+	"context"
+	"deployment-notifications/pkg/helper"
+	"deployment-notifications/pkg/logger"
+	"deployment-notifications/pkg/metrics"
+	"deployment-notifications/pkg/redact"
+	"deployment-notifications/pkg/tracing"
+	"deployment-notifications/pkg/validate"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambdacontext"
+)
+
+// Initialize validates the environment and sets up the notifier the
+// package level handlers use. It must be called once
+// before handling events
+func Initialize() error {
+	ctx := context.Background()
+	runEnv, err := validate.EnvValidate()
+
+	if err != nil {
+		return err
+	}
+
+	logRunEnv(ctx, runEnv)
+
+	defaultNotifier = NewNotifier(helper.NewAWSSession())
+
+	return nil
+}
+
+func validators(request events.CloudWatchEvent) (string, error) {
+	message, err := validate.SourceValidate(request)
+	if err != nil {
+		return message, err
+	}
+
+	message, err = validate.DetailValidate(request)
+
+	if err != nil {
+		return message, err
+	}
+
+	_, err = helper.ParseEventDetails(request)
+
+	if err != nil {
+		return "Event Details Parsing Error", err
+	}
+
+	return "", nil
+}
+
+func eventNameValidate(eventDetails helper.EventInfo) (string, error) {
+	// circuit breaker rollbacks are let through alongside completed
+	// deployments, once for the failed event of the deployment
+	if eventDetails.EventName != "SERVICE_DEPLOYMENT_COMPLETED" && !helper.IsRollbackEvent(eventDetails) {
+		msg := fmt.Sprintf("We received '%s' which we don't track. We only want 'SERVICE_DEPLOYMENT_COMPLETED'",
+			eventDetails.EventName)
+		return msg, errors.New(msg)
+	}
+
+	return "", nil
+}
+
+func logRequest(ctx context.Context, request events.CloudWatchEvent) {
+	eventDetails, _ := helper.ParseEventDetails(request)
+
+	logger.Infof(ctx, "Event Source: %s", request.Source)
+	logger.Infof(ctx, "Event ID: %s", request.ID)
+	logger.Infof(ctx, "Event Detail Type: %s", request.DetailType)
+	logger.Infof(ctx, "Event Region: %s", request.Region)
+	logger.Infof(ctx, "Event Timestamp: %s", request.Time)
+	logger.Infof(ctx, "Event Name: '%s'", eventDetails.EventName)
+	logger.Infof(ctx, "ECS ARN: %s", request.Resources[0])
+}
+
+func logRunEnv(ctx context.Context, runEnv map[string]string) {
+	logger.Infof(ctx, "SSM New Relic Parameter Used: %s", runEnv["SSM_PARAMETER_NAME_NEW_RELIC"])
+	logger.Infof(ctx, "SSM Slack Parameter Used: %s", runEnv["SSM_PARAMETER_NAME_SLACK"])
+	logger.Infof(ctx, "SSM Slack Message Parameter Used: %s", runEnv["SSM_PARAMETER_MESSAGE_SLACK"])
+	logger.Infof(ctx, "New Relic API Token Secret Name: %s", runEnv["NEW_RELIC_API_TOKEN"])
+	logger.Infof(ctx, "Slack Token Secret Name: %s", runEnv["SLACK_API_TOKEN"])
+	logger.Infof(ctx, "New Relic Base Domain for API Calls: %s", runEnv["NEW_RELIC_BASE_DOMAIN"])
+	logger.Infof(ctx, "AWS Account Number: %s", runEnv["AWS_ACCOUNT_NUMBER"])
+	logger.Infof(ctx, "AWS Region: %s", helper.GetAwsDefaultRegion())
+	logger.Infof(ctx, "SSM Slack Rollback Message Parameter Used: %s",
+		helper.GetStringEnv("SSM_PARAMETER_MESSAGE_SLACK_ROLLBACK", ""))
+	logger.Infof(ctx, "SSM Slack Service Action Parameter Used: %s",
+		helper.GetStringEnv("SSM_PARAMETER_NAME_SLACK_SERVICE_ACTION", ""))
+	logger.Infof(ctx, "SSM Slack Service Action Message Parameter Used: %s",
+		helper.GetStringEnv("SSM_PARAMETER_MESSAGE_SLACK_SERVICE_ACTION", ""))
+	logger.Infof(ctx, "SSM CodeDeploy Parameter Used: %s", helper.GetStringEnv("SSM_PARAMETER_NAME_CODEDEPLOY", ""))
+	logger.Infof(ctx, "SSM CodePipeline Parameter Used: %s", helper.GetStringEnv("SSM_PARAMETER_NAME_CODEPIPELINE", ""))
+	logger.Infof(ctx, "SSM Slack Lambda Message Parameter Used: %s",
+		helper.GetStringEnv("SSM_PARAMETER_MESSAGE_SLACK_LAMBDA", ""))
+	logger.Infof(ctx, "SSM Slack CloudFormation Message Parameter Used: %s",
+		helper.GetStringEnv("SSM_PARAMETER_MESSAGE_SLACK_CLOUDFORMATION", ""))
+	logger.Infof(ctx, "SSM ECR Parameter Used: %s", helper.GetStringEnv("SSM_PARAMETER_NAME_ECR", ""))
+	logger.Infof(ctx, "Crash Loop Table Name: %s", helper.GetCrashLoopTableName())
+	logger.Infof(ctx, "DORA Table Name: %s", helper.GetDORATableName())
+	logger.Infof(ctx, "SSM Teams Parameter Used: %s", helper.GetStringEnv("SSM_PARAMETER_NAME_TEAMS", ""))
+	logger.Infof(ctx, "Secret Cache TTL: %s", helper.GetSecretCacheTTL())
+	logger.Infof(ctx, "Allowed Slack Hosts: %v", helper.GetAllowedHosts(helper.SinkSlack))
+	logger.Infof(ctx, "Allowed New Relic Hosts: %v", helper.GetAllowedHosts(helper.SinkNewRelic))
+	logger.Infof(ctx, "Dry Run Sinks: %v", helper.GetDryRunSinks())
+	logger.Infof(ctx, "Log Level: %s", logger.GetLevel())
+}
+
+func HandleRequest(ctx context.Context, request events.CloudWatchEvent) (LambdaResponse, error) {
+	ctx = notifierFrom(ctx).withContext(ctx)
+	ctx = logger.WithFields(ctx, logger.Fields{
+		logger.FieldEventID:   request.ID,
+		logger.FieldEventType: request.DetailType,
+	})
+
+	ctx, span := tracing.Start(ctx, "HandleRequest", tracing.KindInternal)
+	defer span.End()
+
+	span.SetAttribute("event.id", request.ID)
+	span.SetAttribute("event.source", request.Source)
+	span.SetAttribute("event.type", request.DetailType)
+
+	metrics.Count(ctx, metrics.EventsReceived, 1)
+
+	ctx, result := withEventResult(ctx)
+	response, err := handleRequest(ctx, request)
+
+	switch helper.GetErrorKind(err) {
+	case helper.ErrorKindFiltered, helper.ErrorKindUnmapped:
+		metrics.Count(ctx, metrics.EventsFiltered, 1)
+	}
+
+	if err == nil {
+		metrics.Count(ctx, metrics.EventsProcessed, 1)
+	}
+
+	response.EventID = request.ID
+	response.EventType = request.DetailType
+	response = result.complete(response, err)
+
+	span.SetAttribute("decision", string(response.Decision))
+	span.SetAttribute("reason", response.Reason)
+	span.RecordError(err)
+
+	// the response keeps the error, it is only returned when handling
+	// the event again may succeed, so that Lambda or SQS retry it
+	if err != nil && !helper.IsRetryable(err) {
+		logger.Infof(ctx, "Not retrying %s error: %v", helper.GetErrorKind(err), err)
+		err = nil
+	}
+
+	return response, err
+}
+
+func handleRequest(ctx context.Context, request events.CloudWatchEvent) (LambdaResponse, error) {
+	if validate.IsScheduledEvent(request) {
+		return handleScheduledEvent(ctx, request)
+	}
+
+	if validate.IsServiceActionEvent(request) {
+		return handleServiceActionEvent(ctx, request)
+	}
+
+	if validate.IsTaskStateChangeEvent(request) {
+		return handleTaskStateChangeEvent(ctx, request)
+	}
+
+	if validate.IsCodeDeployEvent(request) {
+		return handleCodeDeployEvent(ctx, request)
+	}
+
+	if validate.IsCodePipelineEvent(request) {
+		return handleCodePipelineEvent(ctx, request)
+	}
+
+	if validate.IsLambdaDeployEvent(request) {
+		return handleLambdaDeployEvent(ctx, request)
+	}
+
+	if validate.IsCloudFormationEvent(request) {
+		return handleCloudFormationEvent(ctx, request)
+	}
+
+	if validate.IsECREvent(request) {
+		return handleECREvent(ctx, request)
+	}
+
+	errorMessage, err := validators(request)
+
+	if err != nil {
+		logger.Errorf(ctx, "Error validating execution setup: %s", errorMessage)
+		logger.Errorf(ctx, "Error: %v", err)
+		return LambdaResponse{Reason: errorMessage}, helper.FilteredError("", err)
+	}
+
+	errorMessage, err = validate.ResourcesValidate(request)
+
+	if err != nil {
+		logger.Errorf(ctx, "Error validating event: %s", errorMessage)
+		return LambdaResponse{Reason: errorMessage}, err
+	}
+
+	eventDetails, _ := helper.ParseEventDetails(request)
+	serviceName, _ := helper.GetServiceNameFromARN(request.Resources[0])
+	ctx = withService(ctx, serviceName)
+	ctx = logger.WithField(ctx, logger.FieldDeploymentID, eventDetails.DeploymentID)
+
+	rollbackInfo := detectRollback(ctx, request, eventDetails)
+
+	// outcomes are recorded before filtering so that failed
+	// deployments count towards the DORA change failure rate
+	recordDeploymentOutcome(ctx, request, eventDetails, rollbackInfo)
+
+	errorMessage, err = eventNameValidate(eventDetails)
+
+	if err != nil {
+		logger.Errorf(ctx, "Error validating execution setup: %s", errorMessage)
+		logger.Errorf(ctx, "Error: %v", err)
+		return LambdaResponse{Reason: errorMessage}, helper.FilteredError("", err)
+	}
+
+	logRequest(ctx, request)
+	runEnv, _ := validate.EnvValidate()
+
+	serviceNewRelicMap, errorMessage, err := loadNewRelicMapping(ctx, runEnv["SSM_PARAMETER_NAME_NEW_RELIC"])
+	if err != nil {
+		return LambdaResponse{Reason: errorMessage}, err
+	}
+
+	serviceSlackMap, errorMessage, err := loadSlackMapping(ctx, runEnv["SSM_PARAMETER_NAME_SLACK"])
+	if err != nil {
+		return LambdaResponse{Reason: errorMessage}, err
+	}
+
+	slackMessageTemplate, err := readParameter(ctx, runEnv["SSM_PARAMETER_MESSAGE_SLACK"])
+	if err != nil {
+		logger.Errorf(ctx, "Error Reading SSM Parameter '%s': %v", runEnv["SSM_PARAMETER_MESSAGE_SLACK"], err)
+		return LambdaResponse{Reason: "SSM Slack Message Template Read Failure"}, err
+	}
+
+	if rollbackInfo.IsRollback {
+		slackMessageTemplate, err = readTemplateParameter(ctx, "SSM_PARAMETER_MESSAGE_SLACK_ROLLBACK",
+			helper.DefaultSlackRollbackTemplate)
+		if err != nil {
+			return LambdaResponse{Reason: "SSM Slack Rollback Message Template Read Failure"}, err
+		}
+	}
+
+	ecsARN := request.Resources[0]
+	ecsServiceName, err := helper.GetServiceNameFromARN(ecsARN)
+
+	if err != nil {
+		logger.Errorf(ctx, "Error Parsing Service Name '%s': %v", ecsARN, err)
+		return LambdaResponse{Reason: "ECS Service Name Parse Failure"}, err
+	}
+
+	newRelicTargetApp, ok := serviceNewRelicMap[ecsServiceName]
+
+	if !ok {
+		// this means that the mapping did not contain an entry
+		// for the service which is notifying us - it either means
+		// we missed to configure it or we don't care about this
+		// but this Lambda has no choice but to exit
+		logger.Infof(ctx, "We did not find a mapping for '%s'. Aborting notification", ecsARN)
+		return LambdaResponse{Reason: "ECS Service not configured for notification"},
+			helper.UnmappedError("ECS Service Not Configured", nil)
+	}
+
+	newRelicPayload := helper.MarkNewRelicPayloadRollback(helper.GetNewRelicPayload(request), rollbackInfo)
+
+	newRelicAPIToken, err := readSecret(ctx, runEnv["NEW_RELIC_API_TOKEN"])
+	if err != nil {
+		logger.Errorf(ctx, "Error Reading New Relic API Token Secret '%s': %v", runEnv["NEW_RELIC_API_TOKEN"], err)
+		return LambdaResponse{Reason: "SSM New Relic Token Secret Read Failure"}, err
+	}
+
+	newRelicError := postNewRelicDeployment(ctx, newRelicPayload, runEnv["NEW_RELIC_BASE_DOMAIN"],
+		newRelicTargetApp, newRelicAPIToken)
+
+	slackPayload := helper.GenerateSlackNotificationStruct(request)
+	slackPayload.IsRollback = rollbackInfo.IsRollback
+	slackPayload.FailedRevision = rollbackInfo.FailedRevision
+	slackPayload.RollbackTarget = rollbackInfo.RollbackTarget
+	slackError := postSlackNotifications(ctx, slackMessageTemplate, slackPayload,
+		serviceWebhooks(ctx, ecsServiceName, serviceSlackMap))
+
+	if !rollbackInfo.IsRollback {
+		warnCriticalFindings(ctx, request, eventDetails, ecsServiceName, serviceSlackMap)
+	}
+
+	if newRelicError {
+		logger.Warn(ctx, "New Relic submission did not complete")
+	}
+
+	if slackError {
+		logger.Warn(ctx, "Slack submission did not complete for one or more webhooks")
+	}
+
+	if !newRelicError && !slackError {
+		return LambdaResponse{Reason: "Notification complete!"}, nil
+	}
+
+	return LambdaResponse{Reason: "Notification incomplete!"},
+		deliveryError(ctx, "One or more notification failures")
+}
+
+func HandleInvocation(ctx context.Context, payload json.RawMessage) (interface{}, error) {
+	ctx = notifierFrom(ctx).withContext(ctx)
+
+	if lambdaContext, ok := lambdacontext.FromContext(ctx); ok {
+		ctx = logger.WithField(ctx, logger.FieldRequestID, lambdaContext.AwsRequestID)
+
+		// Lambda passes the trace of the invocation in the environment
+		if parent, ok := tracing.ParseXRayTraceHeader(os.Getenv("_X_AMZN_TRACE_ID")); ok {
+			ctx = tracing.ContextWithRemoteParent(ctx, parent)
+		}
+	}
+
+	// the metrics and spans of the invocation are written once it is handled
+	ctx = metrics.WithRecorder(ctx)
+	ctx = tracing.WithRecorder(ctx)
+	defer metrics.Flush(ctx)
+	defer tracing.Flush(ctx)
+
+	ctx, span := tracing.Start(ctx, "HandleInvocation", tracing.KindServer)
+	defer span.End()
+
+	// errors are logged by Lambda and sent back by the server as they are
+	if !helper.IsAnyDryRun() {
+		result, err := handleInvocation(ctx, payload)
+		return result, redact.Error(err)
+	}
+
+	ctx, report := withDryRunReport(ctx)
+	result, err := handleInvocation(ctx, payload)
+	err = redact.Error(err)
+
+	if response, ok := result.(LambdaResponse); ok {
+		report.Message = response.Message()
+		report.Response = &response
+	}
+
+	if err != nil {
+		report.Error = err.Error()
+	}
+
+	if encoded, encodeErr := json.Marshal(report); encodeErr == nil {
+		logger.Infof(ctx, "Dry run report: %s", encoded)
+	}
+
+	// SQS batch responses are kept as they are, so Lambda
+	// still sees which messages failed
+	if _, ok := result.(helper.SQSEventResponse); ok {
+		return result, err
+	}
+
+	return report, err
+}
+
+func handleInvocation(ctx context.Context, payload json.RawMessage) (interface{}, error) {
+	// the Lambda is triggered either by EventBridge directly, by an
+	// SQS queue buffering the events or by an SNS topic forwarding them
+	if helper.IsSQSEvent(payload) {
+		var sqsEvent events.SQSEvent
+
+		if err := json.Unmarshal(payload, &sqsEvent); err != nil {
+			return invalidPayload(ctx, helper.InvalidEventError("Error unmarshaling SQS event", err))
+		}
+
+		return handleSQSEvent(ctx, sqsEvent), nil
+	}
+
+	if helper.IsSNSEvent(payload) {
+		var snsEvent events.SNSEvent
+
+		if err := json.Unmarshal(payload, &snsEvent); err != nil {
+			return invalidPayload(ctx, helper.InvalidEventError("Error unmarshaling SNS event", err))
+		}
+
+		return handleSNSEvent(ctx, snsEvent)
+	}
+
+	var request events.CloudWatchEvent
+
+	if err := json.Unmarshal([]byte(helper.UnwrapSNSNotification(string(payload))), &request); err != nil {
+		return invalidPayload(ctx, helper.InvalidEventError("Error unmarshaling CloudWatch event", err))
+	}
+
+	return HandleRequest(ctx, request)
+}
+
+// invalidPayload answers a payload that cannot be read. It is not
+// returned as an error, reading it again on a retry will fail the same way
+func invalidPayload(ctx context.Context, err error) (LambdaResponse, error) {
+	logger.Errorf(ctx, "Not retrying %s error: %v", helper.GetErrorKind(err), err)
+
+	return LambdaResponse{Reason: "Payload Parsing Error", Decision: DecisionFailed,
+		Deliveries: []SinkResult{}, Error: redact.String(err.Error())}, nil
+}
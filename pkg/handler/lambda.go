@@ -0,0 +1,117 @@
+package handler
+
+import (
+	"context"
+	"deployment-notifications/pkg/helper"
+	"deployment-notifications/pkg/logger"
+	"deployment-notifications/pkg/validate"
+	"fmt"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+func handleLambdaDeployEvent(ctx context.Context, request events.CloudWatchEvent) (LambdaResponse, error) {
+	lambdaDeployInfo, err := helper.ParseLambdaDeployDetails(request)
+
+	if err != nil {
+		logger.Errorf(ctx, "Error validating Lambda deployment event: %v", err)
+		return LambdaResponse{Reason: "Lambda Deployment Details Parsing Error"}, helper.FilteredError("", err)
+	}
+
+	ctx = withService(ctx, lambdaDeployInfo.FunctionName)
+	ctx = logger.WithField(ctx, logger.FieldDeploymentID, lambdaDeployInfo.Version)
+
+	if !helper.IsTrackedLambdaDeployEvent(lambdaDeployInfo.EventName) {
+		msg := fmt.Sprintf("We received Lambda API call '%s' which we don't track", lambdaDeployInfo.EventName)
+		logger.Info(ctx, msg)
+		return LambdaResponse{Reason: msg}, helper.FilteredError(msg, nil)
+	}
+
+	logger.Infof(ctx, "Event Source: %s", request.Source)
+	logger.Infof(ctx, "Event ID: %s", request.ID)
+	logger.Infof(ctx, "Event Detail Type: %s", request.DetailType)
+	logger.Infof(ctx, "Event Name: '%s'", lambdaDeployInfo.EventName)
+	logger.Infof(ctx, "Lambda Function: %s (version '%s', alias '%s')", lambdaDeployInfo.FunctionName,
+		lambdaDeployInfo.Version, lambdaDeployInfo.Alias)
+
+	if lambdaDeployInfo.CodeSHA256 == "" && lambdaDeployInfo.Version != "" {
+		lambdaDeployInfo.CodeSHA256, err = helper.GetLambdaCodeSHA(lambdaDeployInfo.FunctionName,
+			lambdaDeployInfo.Version, notifierFrom(ctx).Lambda)
+		if err != nil {
+			logger.Warnf(ctx, "Unable to get Lambda code SHA: %v", err)
+		}
+	}
+
+	runEnv, _ := validate.EnvValidate()
+
+	serviceNewRelicMap, errorMessage, err := loadNewRelicMapping(ctx, runEnv["SSM_PARAMETER_NAME_NEW_RELIC"])
+	if err != nil {
+		return LambdaResponse{Reason: errorMessage}, err
+	}
+
+	serviceSlackMap, errorMessage, err := loadSlackMapping(ctx, runEnv["SSM_PARAMETER_NAME_SLACK"])
+	if err != nil {
+		return LambdaResponse{Reason: errorMessage}, err
+	}
+
+	slackMessageTemplate, err := readTemplateParameter(ctx, "SSM_PARAMETER_MESSAGE_SLACK_LAMBDA",
+		helper.DefaultSlackLambdaTemplate)
+	if err != nil {
+		return LambdaResponse{Reason: "SSM Slack Lambda Message Template Read Failure"}, err
+	}
+
+	// functions are mapped like ECS services, optionally per alias
+	functionKey := ""
+	newRelicTargetApp := ""
+
+	for _, key := range helper.GetLambdaMappingKeys(lambdaDeployInfo) {
+		if appID, ok := serviceNewRelicMap[key]; ok {
+			functionKey = key
+			newRelicTargetApp = appID
+			break
+		}
+	}
+
+	if functionKey == "" {
+		logger.Infof(ctx, "We did not find a mapping for '%s'. Aborting notification", lambdaDeployInfo.FunctionName)
+		return LambdaResponse{Reason: "Lambda Function not configured for notification"},
+			helper.UnmappedError("Lambda Function Not Configured", nil)
+	}
+
+	slackPayload := helper.GenerateLambdaNotificationStruct(request, lambdaDeployInfo)
+
+	newRelicAPIToken, err := readSecret(ctx, runEnv["NEW_RELIC_API_TOKEN"])
+	if err != nil {
+		logger.Errorf(ctx, "Error Reading New Relic API Token Secret '%s': %v", runEnv["NEW_RELIC_API_TOKEN"], err)
+		return LambdaResponse{Reason: "SSM New Relic Token Secret Read Failure"}, err
+	}
+
+	newRelicError := postNewRelicDeployment(ctx, helper.GetLambdaNewRelicPayload(request, slackPayload),
+		runEnv["NEW_RELIC_BASE_DOMAIN"], newRelicTargetApp, newRelicAPIToken)
+
+	slackKey := lambdaDeployInfo.FunctionName
+	for _, key := range helper.GetLambdaMappingKeys(lambdaDeployInfo) {
+		if len(helper.LocateValueMultiple(key, serviceSlackMap)) > 0 {
+			slackKey = key
+			break
+		}
+	}
+
+	slackError := postSlackNotifications(ctx, slackMessageTemplate, slackPayload,
+		serviceWebhooks(ctx, slackKey, serviceSlackMap))
+
+	if newRelicError {
+		logger.Warn(ctx, "New Relic submission did not complete")
+	}
+
+	if slackError {
+		logger.Warn(ctx, "Slack submission did not complete for one or more webhooks")
+	}
+
+	if !newRelicError && !slackError {
+		return LambdaResponse{Reason: "Notification complete!"}, nil
+	}
+
+	return LambdaResponse{Reason: "Notification incomplete!"},
+		deliveryError(ctx, "One or more notification failures")
+}
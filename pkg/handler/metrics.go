@@ -0,0 +1,90 @@
+package handler
+
+import (
+	"context"
+	"deployment-notifications/pkg/helper"
+	"deployment-notifications/pkg/logger"
+	"deployment-notifications/pkg/metrics"
+	"time"
+)
+
+func httpCode(status int) int {
+	// the New Relic helper reports 999 when no request was made
+	if status == 999 {
+		return 0
+	}
+
+	return status
+}
+
+// recordDelivery counts a delivery to the sink of the context and
+// how long the sink took to answer, and adds it to the response
+func recordDelivery(ctx context.Context, target string, startedAt time.Time, httpCode, attempts int, err error) {
+	metrics.Count(ctx, metrics.DeliveriesAttempted, 1)
+	metrics.Duration(ctx, metrics.SinkLatency, time.Since(startedAt))
+
+	sinkResult := SinkResult{
+		Sink:     logger.FieldsFrom(ctx)[logger.FieldSink],
+		Target:   target,
+		Status:   SinkStatusDelivered,
+		HTTPCode: httpCode,
+		Attempts: attempts,
+	}
+
+	if err != nil {
+		metrics.Count(ctx, metrics.DeliveriesFailed, 1)
+		sinkResult.Status = SinkStatusFailed
+		sinkResult.Error = err.Error()
+		sinkResult.Retryable = helper.IsRetryable(err)
+	} else {
+		metrics.Count(ctx, metrics.DeliveriesSucceeded, 1)
+	}
+
+	recordSinkResult(ctx, sinkResult)
+}
+
+// deliveryCounts tells how many deliveries of the event in ctx
+// succeeded and whether any failure may pass on a retry
+func deliveryCounts(ctx context.Context) (int, bool) {
+	result := eventResultFrom(ctx)
+	if result == nil {
+		return 0, false
+	}
+
+	result.mutex.Lock()
+	defer result.mutex.Unlock()
+
+	delivered := 0
+	retryable := false
+
+	for _, sinkResult := range result.deliveries {
+		switch {
+		case sinkResult.Status == SinkStatusDelivered:
+			delivered++
+		case sinkResult.Status == SinkStatusFailed && sinkResult.Retryable:
+			retryable = true
+		}
+	}
+
+	return delivered, retryable
+}
+
+// deliveryError is the error of an event some deliveries failed for.
+// Failures that may pass were already retried in place, so the event is
+// only handed back when nothing was delivered. Handling it again after
+// a delivery succeeded would send that delivery twice, e.g. a second New
+// Relic deployment marker, so it is permanent then
+func deliveryError(ctx context.Context, errorMessage string) error {
+	delivered, retryable := deliveryCounts(ctx)
+
+	if retryable && delivered == 0 {
+		return helper.TransientSinkError(errorMessage, nil)
+	}
+
+	if retryable {
+		logger.Warnf(ctx, "Not handing the event back, %d delivery(s) already succeeded and would be repeated",
+			delivered)
+	}
+
+	return helper.PermanentSinkError(errorMessage, nil)
+}
@@ -0,0 +1,71 @@
+package handler
+
+import (
+	"context"
+	"deployment-notifications/pkg/helper"
+	"deployment-notifications/pkg/logger"
+	"time"
+)
+
+func loadNewRelicMapping(ctx context.Context, parameterName string) (map[string]string, string, error) {
+	newRelicMapping, err := readParameter(ctx, parameterName)
+	if err != nil {
+		logger.Errorf(ctx, "Error Reading SSM Parameter '%s': %v", parameterName, err)
+		return nil, "SSM New Relic Parameter Read Failure", err
+	}
+
+	serviceNewRelicMap, err := helper.DecodeStringJSON(newRelicMapping)
+	if err != nil {
+		logger.Errorf(ctx, "Error Decoding SSM Parameter '%s': %v", parameterName, err)
+		return nil, "SSM New Relic Parameter Decode Failure", helper.ConfigurationError("", err)
+	}
+
+	return serviceNewRelicMap, "", nil
+}
+
+func postNewRelicDeployment(ctx context.Context, newRelicPayload map[string]string,
+	baseDomain, appID, apiKey string) bool {
+	// returns true if the deployment could not be submitted
+	ctx = logger.WithField(ctx, logger.FieldSink, helper.SinkNewRelic)
+	baseURL := notifierFrom(ctx).newRelicBaseURL(baseDomain)
+
+	if helper.IsDryRun(helper.SinkNewRelic) {
+		body, _ := helper.GetNewRelicDeploymentBody(newRelicPayload)
+		return recordDryRun(ctx, helper.SinkNewRelic, helper.GetNewRelicDeploymentURL(baseURL, appID), body,
+			helper.ValidateNewRelicDeployment(newRelicPayload, appID, apiKey))
+	}
+
+	startedAt := time.Now()
+	deployStatus, attempts, err := retryDelivery(ctx, func() (int, int, error) {
+		deployStatus, err := helper.PostNewRelicDeployment(ctx, newRelicPayload, baseURL, appID, apiKey)
+
+		// a rejected key may have been rotated since it was cached
+		if helper.IsAuthorizationStatus(deployStatus) {
+			if refreshedKey, ok := refreshSecret(ctx, apiKey); ok {
+				logger.Infof(ctx, "New Relic rejected the API key with status %d, retrying with the rotated key",
+					deployStatus)
+				apiKey = refreshedKey
+				deployStatus, err = helper.PostNewRelicDeployment(ctx, newRelicPayload, baseURL, appID, apiKey)
+				return deployStatus, 2, err
+			}
+		}
+
+		return deployStatus, 1, err
+	})
+
+	recordDelivery(ctx, helper.GetNewRelicDeploymentURL(baseURL, appID), startedAt, httpCode(deployStatus),
+		attempts, err)
+
+	if err != nil {
+		if deployStatus == 999 {
+			logger.Warnf(ctx, "New Relic submit aborted: %v", err)
+		} else {
+			logger.Warnf(ctx, "New Relic submit failed with status: %d, %v", deployStatus, err)
+		}
+		logger.Info(ctx, "We will attempt slack notification")
+		return true
+	}
+
+	logger.Infof(ctx, "New Relic Payload submitted: %v, status: %d", newRelicPayload, deployStatus)
+	return false
+}
@@ -0,0 +1,167 @@
+package handler
+
+import (
+	"context"
+	"deployment-notifications/pkg/helper"
+	"encoding/json"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/cloudformation/cloudformationiface"
+	"github.com/aws/aws-sdk-go/service/cloudwatch/cloudwatchiface"
+	"github.com/aws/aws-sdk-go/service/codedeploy/codedeployiface"
+	"github.com/aws/aws-sdk-go/service/codepipeline/codepipelineiface"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+	"github.com/aws/aws-sdk-go/service/ecr/ecriface"
+	"github.com/aws/aws-sdk-go/service/ecs/ecsiface"
+	"github.com/aws/aws-sdk-go/service/lambda/lambdaiface"
+	"github.com/aws/aws-sdk-go/service/secretsmanager/secretsmanageriface"
+	"github.com/aws/aws-sdk-go/service/ssm/ssmiface"
+)
+
+// Notifier holds everything the handlers reach out to. The Lambda uses
+// the one set up by Initialize, tests and local runs build their own
+// with fakes in place of the AWS clients and the sinks
+type Notifier struct {
+	// HTTPClient sends the sink requests, a shared default client when nil
+	HTTPClient helper.HTTPDoer
+	// NewRelicBaseURL replaces "https://" + NEW_RELIC_BASE_DOMAIN
+	NewRelicBaseURL string
+
+	SSM            ssmiface.SSMAPI
+	SecretsManager secretsmanageriface.SecretsManagerAPI
+	ECS            ecsiface.ECSAPI
+	DynamoDB       dynamodbiface.DynamoDBAPI
+	CloudWatch     cloudwatchiface.CloudWatchAPI
+	CodeDeploy     codedeployiface.CodeDeployAPI
+	CodePipeline   codepipelineiface.CodePipelineAPI
+	Lambda         lambdaiface.LambdaAPI
+	CloudFormation cloudformationiface.CloudFormationAPI
+	ECR            ecriface.ECRAPI
+
+	// ConfigStore replaces reading SSM and SecretsManager directly
+	ConfigStore ConfigStore
+
+	secretsMutex sync.Mutex
+	secrets      map[string]cachedSecret
+}
+
+// secrets are kept for SECRET_CACHE_TTL seconds, so warm Lambdas do not
+// read them for every event
+type cachedSecret struct {
+	value  string
+	readAt time.Time
+}
+
+// NewNotifier returns a notifier using the AWS clients of awsSession.
+// Its HTTP client is built once, so connections and TLS sessions to the
+// sinks are reused across events
+func NewNotifier(awsSession *session.Session) *Notifier {
+	return &Notifier{
+		HTTPClient:     helper.NewHTTPClient(),
+		SSM:            helper.NewSSMClient(awsSession),
+		SecretsManager: helper.NewSecretsManagerClient(awsSession),
+		ECS:            helper.NewECSClient(awsSession),
+		DynamoDB:       helper.NewDynamoDBClient(awsSession),
+		CloudWatch:     helper.NewCloudWatchClient(awsSession),
+		CodeDeploy:     helper.NewCodeDeployClient(awsSession),
+		CodePipeline:   helper.NewCodePipelineClient(awsSession),
+		Lambda:         helper.NewLambdaClient(awsSession),
+		CloudFormation: helper.NewCloudFormationClient(awsSession),
+		ECR:            helper.NewECRClient(awsSession),
+	}
+}
+
+var defaultNotifier = &Notifier{}
+
+type notifierKey struct{}
+
+func (notifier *Notifier) withContext(ctx context.Context) context.Context {
+	ctx = context.WithValue(ctx, notifierKey{}, notifier)
+
+	if notifier.HTTPClient != nil {
+		ctx = helper.WithHTTPDoer(ctx, notifier.HTTPClient)
+	}
+
+	return ctx
+}
+
+func notifierFrom(ctx context.Context) *Notifier {
+	if notifier, ok := ctx.Value(notifierKey{}).(*Notifier); ok {
+		return notifier
+	}
+
+	return defaultNotifier
+}
+
+func (notifier *Notifier) configStore() ConfigStore {
+	if notifier.ConfigStore != nil {
+		return notifier.ConfigStore
+	}
+
+	return awsConfigStore{ssm: notifier.SSM, secretsManager: notifier.SecretsManager}
+}
+
+func (notifier *Notifier) cachedSecret(name string) (string, bool) {
+	notifier.secretsMutex.Lock()
+	defer notifier.secretsMutex.Unlock()
+
+	secret, ok := notifier.secrets[name]
+	if !ok || time.Since(secret.readAt) >= helper.GetSecretCacheTTL() {
+		return "", false
+	}
+
+	return secret.value, true
+}
+
+func (notifier *Notifier) cacheSecret(name, value string) {
+	notifier.secretsMutex.Lock()
+	defer notifier.secretsMutex.Unlock()
+
+	if notifier.secrets == nil {
+		notifier.secrets = make(map[string]cachedSecret)
+	}
+
+	notifier.secrets[name] = cachedSecret{value: value, readAt: time.Now()}
+}
+
+// forgetSecret drops the secrets holding value from the cache and
+// returns their names
+func (notifier *Notifier) forgetSecret(value string) []string {
+	notifier.secretsMutex.Lock()
+	defer notifier.secretsMutex.Unlock()
+
+	var names []string
+
+	for name, secret := range notifier.secrets {
+		if secret.value == value {
+			names = append(names, name)
+			delete(notifier.secrets, name)
+		}
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+func (notifier *Notifier) newRelicBaseURL(baseDomain string) string {
+	if notifier.NewRelicBaseURL != "" {
+		return notifier.NewRelicBaseURL
+	}
+
+	return helper.GetNewRelicBaseURL(baseDomain)
+}
+
+// HandleInvocation handles a Lambda payload like the package level
+// HandleInvocation does, using the clients of the notifier
+func (notifier *Notifier) HandleInvocation(ctx context.Context, payload json.RawMessage) (interface{}, error) {
+	return HandleInvocation(notifier.withContext(ctx), payload)
+}
+
+func (notifier *Notifier) HandleRequest(ctx context.Context, request events.CloudWatchEvent) (LambdaResponse, error) {
+	return HandleRequest(notifier.withContext(ctx), request)
+}
@@ -0,0 +1,129 @@
+package handler
+
+import (
+	"context"
+	"deployment-notifications/pkg/helper"
+	"deployment-notifications/pkg/logger"
+	"deployment-notifications/pkg/redact"
+	"sync"
+)
+
+// Decision is what was made of an event
+type Decision string
+
+const (
+	DecisionProcessed Decision = "processed"
+	DecisionFiltered  Decision = "filtered"
+	DecisionUnmapped  Decision = "unmapped"
+	DecisionFailed    Decision = "failed"
+)
+
+// the outcome of a single delivery
+const (
+	SinkStatusDelivered = "delivered"
+	SinkStatusFailed    = "failed"
+	SinkStatusDryRun    = "dry-run"
+)
+
+// SinkResult is the outcome of one delivery to a sink. Targets are
+// redacted, so webhook URLs only show their host and IDs
+type SinkResult struct {
+	Sink      string `json:"sink"`
+	Target    string `json:"target"`
+	Status    string `json:"status"`
+	HTTPCode  int    `json:"httpCode,omitempty"`
+	Attempts  int    `json:"attempts"`
+	Error     string `json:"error,omitempty"`
+	Retryable bool   `json:"retryable,omitempty"`
+}
+
+// LambdaResponse is the result of handling one event. It is what Lambda
+// destinations receive as the response payload and what the CLI prints
+type LambdaResponse struct {
+	EventID    string       `json:"eventId,omitempty"`
+	EventType  string       `json:"eventType,omitempty"`
+	Service    string       `json:"service,omitempty"`
+	Decision   Decision     `json:"decision,omitempty"`
+	Reason     string       `json:"reason"`
+	Deliveries []SinkResult `json:"deliveries"`
+	Error      string       `json:"error,omitempty"`
+}
+
+func (response LambdaResponse) Message() string {
+	return response.Reason
+}
+
+// eventResult collects what the handlers learn about an event while
+// handling it, to be added to the response they return
+type eventResult struct {
+	mutex      sync.Mutex
+	service    string
+	deliveries []SinkResult
+}
+
+type eventResultKey struct{}
+
+func withEventResult(ctx context.Context) (context.Context, *eventResult) {
+	result := &eventResult{deliveries: []SinkResult{}}
+
+	return context.WithValue(ctx, eventResultKey{}, result), result
+}
+
+func eventResultFrom(ctx context.Context) *eventResult {
+	result, _ := ctx.Value(eventResultKey{}).(*eventResult)
+	return result
+}
+
+// withService sets the service an event is about, both for the log
+// lines and the response
+func withService(ctx context.Context, serviceName string) context.Context {
+	if result := eventResultFrom(ctx); result != nil {
+		result.mutex.Lock()
+		result.service = serviceName
+		result.mutex.Unlock()
+	}
+
+	return logger.WithField(ctx, logger.FieldService, serviceName)
+}
+
+func recordSinkResult(ctx context.Context, sinkResult SinkResult) {
+	sinkResult.Target = redact.String(sinkResult.Target)
+	sinkResult.Error = redact.String(sinkResult.Error)
+
+	if result := eventResultFrom(ctx); result != nil {
+		result.mutex.Lock()
+		result.deliveries = append(result.deliveries, sinkResult)
+		result.mutex.Unlock()
+	}
+}
+
+// complete fills in the response of a handler with what was recorded.
+// The decision follows from the kind of error. Events that were neither
+// filtered nor unmapped are processed when a delivery was attempted or
+// nothing went wrong
+func (result *eventResult) complete(response LambdaResponse, err error) LambdaResponse {
+	result.mutex.Lock()
+	defer result.mutex.Unlock()
+
+	response.Service = result.service
+	response.Deliveries = result.deliveries
+
+	switch helper.GetErrorKind(err) {
+	case helper.ErrorKindFiltered:
+		response.Decision = DecisionFiltered
+	case helper.ErrorKindUnmapped:
+		response.Decision = DecisionUnmapped
+	default:
+		if err == nil || len(result.deliveries) > 0 {
+			response.Decision = DecisionProcessed
+		} else {
+			response.Decision = DecisionFailed
+		}
+	}
+
+	if err != nil {
+		response.Error = redact.String(err.Error())
+	}
+
+	return response
+}
@@ -0,0 +1,62 @@
+package handler_test
+
+import (
+	"context"
+	"deployment-notifications/pkg/handler"
+	"encoding/json"
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestLambdaResponseJSON(t *testing.T) {
+	response := handler.LambdaResponse{
+		EventID:  "1",
+		Service:  "my-service",
+		Decision: handler.DecisionProcessed,
+		Reason:   "Notification incomplete!",
+		Deliveries: []handler.SinkResult{
+			{Sink: "slack", Target: "https://hooks.slack.com/services/T0/B0/<redacted>",
+				Status: handler.SinkStatusFailed, HTTPCode: 404, Attempts: 1, Error: "no_service"},
+		},
+	}
+
+	encoded, err := json.Marshal(response)
+	assert.Nil(t, err)
+	assert.JSONEq(t, `{
+		"eventId": "1",
+		"service": "my-service",
+		"decision": "processed",
+		"reason": "Notification incomplete!",
+		"deliveries": [{
+			"sink": "slack",
+			"target": "https://hooks.slack.com/services/T0/B0/<redacted>",
+			"status": "failed",
+			"httpCode": 404,
+			"attempts": 1,
+			"error": "no_service"
+		}]
+	}`, string(encoded))
+	assert.Equal(t, "Notification incomplete!", response.Message())
+}
+
+func TestHandleRequestFiltered(t *testing.T) {
+	request := events.CloudWatchEvent{
+		ID:         "task-1",
+		Source:     "aws.ecs",
+		DetailType: "ECS Task State Change",
+		Detail: json.RawMessage(`{"lastStatus": "RUNNING",
+			"taskArn": "arn:aws:ecs:eu-west-1:111122223333:task/my-cluster/1", "group": "service:my-service"}`),
+	}
+
+	response, err := handler.HandleRequest(context.Background(), request)
+
+	// filtered events are not retried
+	assert.Nil(t, err)
+	assert.Equal(t, "task-1", response.EventID)
+	assert.Equal(t, "ECS Task State Change", response.EventType)
+	assert.Equal(t, handler.DecisionFiltered, response.Decision)
+	assert.Contains(t, response.Reason, "'RUNNING'")
+	assert.Equal(t, []handler.SinkResult{}, response.Deliveries)
+	assert.Contains(t, response.Error, "which we don't track")
+}
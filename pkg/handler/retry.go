@@ -0,0 +1,34 @@
+package handler
+
+import (
+	"context"
+	"deployment-notifications/pkg/helper"
+	"deployment-notifications/pkg/logger"
+	"time"
+)
+
+// retryDelivery tries a delivery again while its failure may pass, up to
+// SINK_MAX_ATTEMPTS times. Only the failed sink is tried again, handing
+// the event back instead would repeat the deliveries that succeeded.
+// deliver returns the status, the attempts it made and the error
+func retryDelivery(ctx context.Context, deliver func() (int, int, error)) (int, int, error) {
+	status, attempts, err := deliver()
+	delay := helper.GetSinkRetryDelay()
+
+	for try := 1; try < helper.GetSinkMaxAttempts() && helper.IsRetryable(err); try++ {
+		logger.Infof(ctx, "Attempt %d failed, retrying in %s: %v", try, delay, err)
+
+		select {
+		case <-ctx.Done():
+			return status, attempts, err
+		case <-time.After(delay):
+		}
+
+		var retryAttempts int
+		status, retryAttempts, err = deliver()
+		attempts += retryAttempts
+		delay *= 2
+	}
+
+	return status, attempts, err
+}
@@ -0,0 +1,44 @@
+package handler
+
+import (
+	"context"
+	"deployment-notifications/pkg/helper"
+	"deployment-notifications/pkg/logger"
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go/service/ecs"
+)
+
+func detectRollback(ctx context.Context, request events.CloudWatchEvent,
+	eventDetails helper.EventInfo) helper.RollbackInfo {
+	// only completed deployments and circuit breaker failures can
+	// be rollbacks - skip the ECS lookup for anything else
+	if eventDetails.EventName != "SERVICE_DEPLOYMENT_COMPLETED" && !helper.IsRollbackEvent(eventDetails) {
+		return helper.RollbackInfo{}
+	}
+
+	var deployments []*ecs.Deployment
+
+	clusterName, ecsServiceName, err := helper.GetClusterAndServiceFromARN(request.Resources[0])
+
+	if err == nil {
+		ecsService, describeErr := helper.DescribeECSService(clusterName, ecsServiceName, notifierFrom(ctx).ECS)
+		err = describeErr
+
+		if err == nil {
+			deployments = ecsService.Deployments
+		}
+	}
+
+	if err != nil {
+		logger.Warnf(ctx, "Unable to compare task definition revisions, using event reason only: %v", err)
+	}
+
+	rollbackInfo := helper.DetectRollback(eventDetails, deployments)
+
+	if rollbackInfo.IsRollback {
+		logger.Infof(ctx, "Rollback detected: failed revision '%s', rolled back to '%s'",
+			rollbackInfo.FailedRevision, rollbackInfo.RollbackTarget)
+	}
+
+	return rollbackInfo
+}
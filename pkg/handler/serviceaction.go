@@ -0,0 +1,117 @@
+package handler
+
+import (
+	"context"
+	"deployment-notifications/pkg/helper"
+	"deployment-notifications/pkg/logger"
+	"deployment-notifications/pkg/validate"
+	"fmt"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go/aws"
+)
+
+func handleServiceActionEvent(ctx context.Context, request events.CloudWatchEvent) (LambdaResponse, error) {
+	serviceActionInfo, err := helper.ParseServiceActionDetails(request)
+
+	if err != nil {
+		logger.Errorf(ctx, "Error validating service action event: %v", err)
+		return LambdaResponse{Reason: "Service Action Details Parsing Error"}, helper.FilteredError("", err)
+	}
+
+	errorMessage, err := validate.ResourcesValidate(request)
+
+	if err != nil {
+		logger.Errorf(ctx, "Error validating service action event: %s", errorMessage)
+		return LambdaResponse{Reason: errorMessage}, err
+	}
+
+	serviceName, _ := helper.GetServiceNameFromARN(request.Resources[0])
+	ctx = withService(ctx, serviceName)
+
+	if !helper.IsTrackedServiceAction(serviceActionInfo.EventName) {
+		msg := fmt.Sprintf("We received service action '%s' which we don't track", serviceActionInfo.EventName)
+		logger.Info(ctx, msg)
+		return LambdaResponse{Reason: msg}, helper.FilteredError(msg, nil)
+	}
+
+	logger.Infof(ctx, "Event Source: %s", request.Source)
+	logger.Infof(ctx, "Event ID: %s", request.ID)
+	logger.Infof(ctx, "Event Detail Type: %s", request.DetailType)
+	logger.Infof(ctx, "Event Name: '%s' (%s)", serviceActionInfo.EventName, serviceActionInfo.EventType)
+	logger.Infof(ctx, "ECS ARN: %s", request.Resources[0])
+
+	runEnv, _ := validate.EnvValidate()
+
+	// service actions may be routed to their own channels, e.g. on-call,
+	// and fall back to the deployment channels when not configured
+	slackParameter := helper.GetStringEnv("SSM_PARAMETER_NAME_SLACK_SERVICE_ACTION", runEnv["SSM_PARAMETER_NAME_SLACK"])
+
+	serviceSlackMap, errorMessage, err := loadSlackMapping(ctx, slackParameter)
+	if err != nil {
+		return LambdaResponse{Reason: errorMessage}, err
+	}
+
+	templates := make(map[string]string)
+	templateParameter := helper.GetStringEnv("SSM_PARAMETER_MESSAGE_SLACK_SERVICE_ACTION", "")
+
+	if templateParameter != "" {
+		templateMapping, err := readParameter(ctx, templateParameter)
+		if err != nil {
+			logger.Errorf(ctx, "Error Reading SSM Parameter '%s': %v", templateParameter, err)
+			return LambdaResponse{Reason: "SSM Slack Service Action Template Read Failure"}, err
+		}
+
+		templates, err = helper.DecodeStringJSON(templateMapping)
+		if err != nil {
+			logger.Errorf(ctx, "Error Decoding SSM Parameter '%s': %v", templateParameter, err)
+			return LambdaResponse{Reason: "SSM Slack Service Action Template Decode Failure"},
+				helper.ConfigurationError("", err)
+		}
+	}
+
+	slackPayload := helper.GenerateServiceActionNotificationStruct(request, serviceActionInfo)
+
+	slackMessageTemplate, err := helper.SelectServiceActionTemplate(templates,
+		slackPayload.ServiceName, serviceActionInfo.EventName)
+	if err != nil {
+		logger.Errorf(ctx, "Error selecting Slack template: %v", err)
+		return LambdaResponse{Reason: "Slack Service Action Template Not Found"}, err
+	}
+
+	if serviceActionInfo.EventType != "INFO" {
+		// tie problems back to the deployment that most likely caused them
+		addLastDeployment(ctx, request, &slackPayload)
+	}
+
+	slackError := postSlackNotifications(ctx, slackMessageTemplate, slackPayload,
+		serviceWebhooks(ctx, slackPayload.ServiceName, serviceSlackMap))
+
+	if slackError {
+		logger.Warn(ctx, "Slack submission did not complete for one or more webhooks")
+		return LambdaResponse{Reason: "Notification incomplete!"},
+			deliveryError(ctx, "One or more notification failures")
+	}
+
+	return LambdaResponse{Reason: "Notification complete!"}, nil
+}
+
+func addLastDeployment(ctx context.Context, request events.CloudWatchEvent,
+	slackPayload *helper.ServiceActionNotificationFields) {
+	clusterName, ecsServiceName, err := helper.GetClusterAndServiceFromARN(request.Resources[0])
+
+	if err != nil {
+		logger.Warnf(ctx, "Unable to look up last deployment: %v", err)
+		return
+	}
+
+	deployment, err := describePrimaryDeployment(ctx, clusterName, ecsServiceName)
+
+	if err != nil {
+		logger.Warnf(ctx, "Unable to look up last deployment: %v", err)
+		return
+	}
+
+	slackPayload.DeploymentID = aws.StringValue(deployment.Id)
+	slackPayload.DeploymentStartedAt = formatDeploymentStart(deployment)
+}
@@ -0,0 +1,138 @@
+package handler
+
+import (
+	"context"
+	"deployment-notifications/pkg/helper"
+	"deployment-notifications/pkg/logger"
+	"deployment-notifications/pkg/redact"
+	"fmt"
+	"time"
+)
+
+func loadSlackMapping(ctx context.Context, parameterName string) (map[string][]string, string, error) {
+	// reads and decodes a service to webhooks mapping and makes sure
+	// the 'default-service' webhook every notification goes to exists
+	slackMapping, err := readParameter(ctx, parameterName)
+	if err != nil {
+		logger.Errorf(ctx, "Error Reading SSM Parameter '%s': %v", parameterName, err)
+		return nil, "SSM Slack Read Failure", err
+	}
+
+	serviceSlackMap, err := helper.DecodeSlackMapping(slackMapping)
+	if err != nil {
+		logger.Errorf(ctx, "Error Decoding SSM Parameter '%s': %v", parameterName, err)
+		return nil, "SSM Slack Parameter Decode Failure", helper.ConfigurationError("", err)
+	}
+
+	// webhook URLs are secrets, whatever service they belong to. The
+	// ones referenced are registered once they are read
+	for _, webhooks := range serviceSlackMap {
+		for _, webhook := range webhooks {
+			if store, _ := helper.ParseWebhookReference(webhook); store == "" {
+				redact.RegisterSecret(webhook)
+			}
+		}
+	}
+
+	if helper.GetDefaultWebhook(serviceSlackMap) == "" {
+		logger.Errorf(ctx, "Webhook service for 'default-service' not defined in '%s'", parameterName)
+		return nil, "Default Slack Webhook not defined", helper.ConfigurationError("Default Slack Webhook not defined", nil)
+	}
+
+	return serviceSlackMap, "", nil
+}
+
+func serviceWebhooks(ctx context.Context, serviceName string, serviceSlackMap map[string][]string) []string {
+	webhooks := []string{helper.GetDefaultWebhook(serviceSlackMap)}
+	additionalWebhooks := helper.LocateValueMultiple(serviceName, serviceSlackMap)
+
+	if len(additionalWebhooks) > 0 {
+		logger.Infof(ctx, "Additional webhooks defined for service '%s'", serviceName)
+	}
+
+	return append(webhooks, additionalWebhooks...)
+}
+
+func postSlackNotifications(ctx context.Context, messageTemplate string, templateValues interface{},
+	webhooks []string) bool {
+	// returns true if any of the webhooks failed
+	ctx = logger.WithField(ctx, logger.FieldSink, helper.SinkSlack)
+	slackError := false
+
+	for _, webhook := range webhooks {
+		if helper.IsDryRun(helper.SinkSlack) {
+			slackError = dryRunSlackMessage(ctx, messageTemplate, templateValues, webhook) || slackError
+			continue
+		}
+
+		startedAt := time.Now()
+		slackStatus, attempts, err := retryDelivery(ctx, func() (int, int, error) {
+			return postSlackWebhook(ctx, webhook, func(webhookURL string) (int, error) {
+				return helper.PostSlackMessage(ctx, messageTemplate, templateValues, webhookURL)
+			})
+		})
+		recordDelivery(ctx, webhook, startedAt, httpCode(slackStatus), attempts, err)
+
+		if err != nil {
+			slackError = true
+			logger.Warnf(ctx, "Slack post failed with status: %d, %v", slackStatus, err)
+			logger.Info(ctx, "We will attempt other webhooks, if available")
+		}
+	}
+
+	return slackError
+}
+
+// resolveWebhook returns the URL of a Slack mapping entry, reading the
+// secret or parameter it references
+func resolveWebhook(ctx context.Context, webhook string) (string, error) {
+	store, name := helper.ParseWebhookReference(webhook)
+
+	switch store {
+	case helper.WebhookStoreSecretsManager:
+		return readSecret(ctx, name)
+	case helper.WebhookStoreSSM:
+		webhookURL, err := readParameter(ctx, name)
+		if err == nil {
+			redact.RegisterSecret(webhookURL)
+		}
+
+		return webhookURL, err
+	}
+
+	return webhook, nil
+}
+
+// postSlackWebhook posts to a Slack mapping entry and returns the status
+// and the number of attempts. A webhook read from Secrets Manager that
+// is rejected is read again, in case it has been rotated
+func postSlackWebhook(ctx context.Context, webhook string,
+	post func(webhookURL string) (int, error)) (int, int, error) {
+	webhookURL, err := resolveWebhook(ctx, webhook)
+	if err != nil {
+		return 999, 0, helper.WrapError(fmt.Sprintf("Error reading webhook '%s'", webhook), err)
+	}
+
+	slackStatus, err := post(webhookURL)
+
+	if helper.IsAuthorizationStatus(slackStatus) {
+		if refreshedURL, ok := refreshSecret(ctx, webhookURL); ok {
+			logger.Infof(ctx, "Slack rejected webhook '%s' with status %d, retrying with the rotated URL",
+				webhook, slackStatus)
+			slackStatus, err = post(refreshedURL)
+			return slackStatus, 2, err
+		}
+	}
+
+	return slackStatus, 1, err
+}
+
+func dryRunSlackMessage(ctx context.Context, messageTemplate string, templateValues interface{},
+	webhook string) bool {
+	payload, err := helper.GeneratePayload(messageTemplate, templateValues, true)
+	if err == nil {
+		err = helper.ValidateSlackPayload(payload)
+	}
+
+	return recordDryRun(ctx, helper.SinkSlack, webhook, []byte(payload), err)
+}
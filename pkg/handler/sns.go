@@ -0,0 +1,45 @@
+package handler
+
+import (
+	"context"
+	"deployment-notifications/pkg/helper"
+	"deployment-notifications/pkg/logger"
+	"deployment-notifications/pkg/redact"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+func handleSNSEvent(ctx context.Context, snsEvent events.SNSEvent) (LambdaResponse, error) {
+	// SNS invokes asynchronously, so a failure is returned for
+	// Lambda to retry the whole notification
+	var lastResponse LambdaResponse
+	var lastErr error
+
+	for _, record := range snsEvent.Records {
+		ctx := logger.WithField(ctx, logger.FieldMessageID, record.SNS.MessageID)
+		logger.Infof(ctx, "Processing SNS message '%s' from '%s'", record.SNS.MessageID, record.SNS.TopicArn)
+
+		request, err := helper.ParseEventBridgeEnvelope(record.SNS.Message)
+		if err != nil {
+			// a message that is not an event will not become one on a retry
+			logger.Warnf(ctx, "SNS message '%s' failed: %v", record.SNS.MessageID, err)
+			if lastErr == nil {
+				lastResponse = LambdaResponse{Reason: "SNS Message Parsing Error", Decision: DecisionFiltered,
+					Error: redact.String(err.Error())}
+			}
+			continue
+		}
+
+		response, err := HandleRequest(ctx, request)
+		if err != nil {
+			lastResponse, lastErr = response, err
+			continue
+		}
+
+		if lastErr == nil {
+			lastResponse = response
+		}
+	}
+
+	return lastResponse, lastErr
+}
@@ -0,0 +1,42 @@
+package handler
+
+import (
+	"context"
+	"deployment-notifications/pkg/helper"
+	"deployment-notifications/pkg/logger"
+	"deployment-notifications/pkg/metrics"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+func handleSQSEvent(ctx context.Context, sqsEvent events.SQSEvent) helper.SQSEventResponse {
+	// every record is processed on its own, and only the failed
+	// ones are reported back to be retried or moved to the DLQ
+	response := helper.SQSEventResponse{BatchItemFailures: []helper.SQSBatchItemFailure{}}
+
+	for _, record := range sqsEvent.Records {
+		ctx := logger.WithField(ctx, logger.FieldMessageID, record.MessageId)
+		logger.Infof(ctx, "Processing SQS message: %s", record.MessageId)
+
+		request, err := helper.ParseEventBridgeEnvelope(record.Body)
+
+		if err == nil {
+			metrics.Count(logger.WithField(ctx, logger.FieldEventType, request.DetailType), metrics.Retries,
+				float64(helper.GetSQSRetryCount(record)))
+
+			_, err = HandleRequest(ctx, request)
+		}
+
+		// HandleRequest only fails events worth retrying, messages that
+		// are not events are reported too so they end up in the DLQ
+		if err != nil {
+			logger.Warnf(ctx, "SQS message '%s' failed: %v", record.MessageId, err)
+			response.BatchItemFailures = append(response.BatchItemFailures,
+				helper.SQSBatchItemFailure{ItemIdentifier: record.MessageId})
+		}
+	}
+
+	logger.Infof(ctx, "Processed %d SQS message(s), %d failed", len(sqsEvent.Records), len(response.BatchItemFailures))
+
+	return response
+}
@@ -6,8 +6,27 @@ import (
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/aws/aws-sdk-go/service/cloudformation/cloudformationiface"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/aws/aws-sdk-go/service/cloudwatch/cloudwatchiface"
+	"github.com/aws/aws-sdk-go/service/codedeploy"
+	"github.com/aws/aws-sdk-go/service/codedeploy/codedeployiface"
+	"github.com/aws/aws-sdk-go/service/codepipeline"
+	"github.com/aws/aws-sdk-go/service/codepipeline/codepipelineiface"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+	"github.com/aws/aws-sdk-go/service/ecr"
+	"github.com/aws/aws-sdk-go/service/ecr/ecriface"
+	"github.com/aws/aws-sdk-go/service/ecs"
+	"github.com/aws/aws-sdk-go/service/ecs/ecsiface"
+	"github.com/aws/aws-sdk-go/service/lambda"
+	"github.com/aws/aws-sdk-go/service/lambda/lambdaiface"
 	"github.com/aws/aws-sdk-go/service/secretsmanager"
+	"github.com/aws/aws-sdk-go/service/secretsmanager/secretsmanageriface"
 	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/aws/aws-sdk-go/service/ssm/ssmiface"
+	"net/url"
 	"os"
 	"strings"
 )
@@ -28,28 +47,157 @@ func GetAwsDefaultRegion() string {
 	return val
 }
 
-func ReadAWSSecret(secretID string, awsSession *session.Session) (string, error) {
-	*awsSession.Config.Region = GetAwsDefaultRegion()
+// NewAWSSession returns the session the AWS clients are built from. The
+// region is set here once, as the session is shared by requests handled
+// at the same time and must not be changed afterwards
+func NewAWSSession() *session.Session {
+	config := aws.NewConfig()
 
-	sessionSecretsManager := secretsmanager.New(awsSession)
+	if region := GetAwsDefaultRegion(); region != "" {
+		config = config.WithRegion(region)
+	}
+
+	return session.Must(session.NewSession(config))
+}
+
+func NewSSMClient(awsSession *session.Session) ssmiface.SSMAPI {
+	return ssm.New(awsSession)
+}
+
+func NewSecretsManagerClient(awsSession *session.Session) secretsmanageriface.SecretsManagerAPI {
+	return secretsmanager.New(awsSession)
+}
+
+func NewECSClient(awsSession *session.Session) ecsiface.ECSAPI {
+	return ecs.New(awsSession)
+}
+
+func NewDynamoDBClient(awsSession *session.Session) dynamodbiface.DynamoDBAPI {
+	return dynamodb.New(awsSession)
+}
+
+func NewCloudWatchClient(awsSession *session.Session) cloudwatchiface.CloudWatchAPI {
+	return cloudwatch.New(awsSession)
+}
+
+func NewCodeDeployClient(awsSession *session.Session) codedeployiface.CodeDeployAPI {
+	return codedeploy.New(awsSession)
+}
+
+func NewCodePipelineClient(awsSession *session.Session) codepipelineiface.CodePipelineAPI {
+	return codepipeline.New(awsSession)
+}
+
+func NewLambdaClient(awsSession *session.Session) lambdaiface.LambdaAPI {
+	return lambda.New(awsSession)
+}
+
+func NewCloudFormationClient(awsSession *session.Session) cloudformationiface.CloudFormationAPI {
+	return cloudformation.New(awsSession)
+}
+
+func NewECRClient(awsSession *session.Session) ecriface.ECRAPI {
+	return ecr.New(awsSession)
+}
+
+// SecretReference names a secret, e.g. "prod/tokens?versionStage=AWSPREVIOUS#newrelic".
+// The version stage defaults to AWSCURRENT, and a JSON key selects one
+// value of a secret holding a JSON object. Secret names and ARNs cannot
+// contain '?' or '#', so neither is ever part of the ID
+type SecretReference struct {
+	SecretID     string
+	VersionStage string
+	JSONKey      string
+}
+
+func ParseSecretReference(reference string) SecretReference {
+	var secretReference SecretReference
 
-	secretValue, err := sessionSecretsManager.GetSecretValue(&secretsmanager.GetSecretValueInput{SecretId: &secretID})
+	if index := strings.Index(reference, "#"); index >= 0 {
+		secretReference.JSONKey = reference[index+1:]
+		reference = reference[:index]
+	}
+
+	if index := strings.Index(reference, "?"); index >= 0 {
+		// a query that does not parse selects the current version
+		query, _ := url.ParseQuery(reference[index+1:])
+		secretReference.VersionStage = query.Get("versionStage")
+		reference = reference[:index]
+	}
+
+	secretReference.SecretID = reference
 
+	return secretReference
+}
+
+// GetSecretJSONKey returns the value of key in a secret holding a JSON
+// object, or the secret as it is when no key is asked for. Values that
+// are not strings are returned JSON encoded
+func GetSecretJSONKey(secretValue, key string) (string, error) {
+	if key == "" {
+		return secretValue, nil
+	}
+
+	var values map[string]json.RawMessage
+
+	err := json.Unmarshal([]byte(secretValue), &values)
 	if err != nil {
-		return "", fmt.Errorf("error getting secret from ID '%s': %w", secretID, err)
+		// the error would quote the secret
+		return "", ConfigurationError(fmt.Sprintf("Secret is not a JSON object, cannot select key '%s'", key), nil)
+	}
+
+	value, ok := values[key]
+	if !ok {
+		return "", ConfigurationError(fmt.Sprintf("Key '%s' not found in secret", key), nil)
+	}
+
+	var stringValue string
+	if err := json.Unmarshal(value, &stringValue); err == nil {
+		return stringValue, nil
 	}
 
-	return *secretValue.SecretString, nil
+	return string(value), nil
 }
 
-func ReadAWSParameter(paramID string, awsSession *session.Session) (string, error) {
-	*awsSession.Config.Region = GetAwsDefaultRegion()
+func ReadAWSSecret(reference string, client secretsmanageriface.SecretsManagerAPI) (string, error) {
+	secretReference := ParseSecretReference(reference)
+	input := &secretsmanager.GetSecretValueInput{SecretId: aws.String(secretReference.SecretID)}
 
-	sessionAWSParameter := ssm.New(awsSession)
+	if secretReference.VersionStage != "" {
+		input.VersionStage = aws.String(secretReference.VersionStage)
+	}
+
+	secretValue, err := client.GetSecretValue(input)
+
+	if err != nil {
+		return "", fmt.Errorf("error getting secret from ID '%s': %w", secretReference.SecretID, err)
+	}
+
+	var value string
+
+	switch {
+	case secretValue.SecretString != nil:
+		value = *secretValue.SecretString
+	case secretValue.SecretBinary != nil:
+		// the SDK has decoded the base64 already
+		value = string(secretValue.SecretBinary)
+	default:
+		return "", ConfigurationError(fmt.Sprintf("Secret '%s' has no value", secretReference.SecretID), nil)
+	}
+
+	value, err = GetSecretJSONKey(value, secretReference.JSONKey)
+	if err != nil {
+		return "", WrapError(fmt.Sprintf("Error reading secret '%s'", secretReference.SecretID), err)
+	}
+
+	return value, nil
+}
 
-	param, err := sessionAWSParameter.GetParameter(&ssm.GetParameterInput{
+func ReadAWSParameter(paramID string, client ssmiface.SSMAPI) (string, error) {
+	// SecureString parameters are decrypted, String ones are unaffected
+	param, err := client.GetParameter(&ssm.GetParameterInput{
 		Name:           aws.String(paramID),
-		WithDecryption: aws.Bool(false),
+		WithDecryption: aws.Bool(true),
 	})
 
 	if err != nil {
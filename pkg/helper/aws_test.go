@@ -1,6 +1,7 @@
 package helper_test
 
 import (
+	"deployment-notifications/pkg/fake"
 	"deployment-notifications/pkg/helper"
 	"encoding/json"
 	"github.com/aws/aws-lambda-go/events"
@@ -189,3 +190,90 @@ func TestParseEventDetailMissingTimestamp(t *testing.T) {
 
 	assert.NotNil(t, err)
 }
+
+func TestParseSecretReference(t *testing.T) {
+	assert.Equal(t, helper.SecretReference{SecretID: "dev/newrelic/apikey"},
+		helper.ParseSecretReference("dev/newrelic/apikey"))
+	assert.Equal(t, helper.SecretReference{SecretID: "prod/tokens", JSONKey: "newrelic"},
+		helper.ParseSecretReference("prod/tokens#newrelic"))
+	assert.Equal(t, helper.SecretReference{SecretID: "prod/tokens", VersionStage: "AWSPREVIOUS", JSONKey: "newrelic"},
+		helper.ParseSecretReference("prod/tokens?versionStage=AWSPREVIOUS#newrelic"))
+	assert.Equal(t, helper.SecretReference{
+		SecretID:     "arn:aws:secretsmanager:us-west-2:111122223333:secret:prod/tokens-AbCdEf",
+		VersionStage: "AWSPENDING",
+	}, helper.ParseSecretReference("arn:aws:secretsmanager:us-west-2:111122223333:secret:prod/tokens-AbCdEf?versionStage=AWSPENDING"))
+}
+
+func TestGetSecretJSONKey(t *testing.T) {
+	secret := `{"newrelic": "NRAK-TEST", "port": 443}`
+
+	value, err := helper.GetSecretJSONKey(secret, "")
+	assert.Nil(t, err)
+	assert.Equal(t, secret, value)
+
+	value, err = helper.GetSecretJSONKey(secret, "newrelic")
+	assert.Nil(t, err)
+	assert.Equal(t, "NRAK-TEST", value)
+
+	value, err = helper.GetSecretJSONKey(secret, "port")
+	assert.Nil(t, err)
+	assert.Equal(t, "443", value)
+
+	_, err = helper.GetSecretJSONKey(secret, "slack")
+	assert.Equal(t, helper.ErrorKindConfiguration, helper.GetErrorKind(err))
+
+	_, err = helper.GetSecretJSONKey("NRAK-TEST", "newrelic")
+	assert.Equal(t, helper.ErrorKindConfiguration, helper.GetErrorKind(err))
+	assert.NotContains(t, err.Error(), "NRAK-TEST")
+}
+
+func TestReadAWSSecret(t *testing.T) {
+	client := &fake.SecretsManager{
+		Secrets:       map[string]string{"prod/tokens": `{"newrelic": "NRAK-CURRENT"}`, "plain": "NRAK-PLAIN"},
+		BinarySecrets: map[string][]byte{"binary": []byte("NRAK-BINARY")},
+		VersionStages: map[string]map[string]string{
+			"prod/tokens": {"AWSPREVIOUS": `{"newrelic": "NRAK-PREVIOUS"}`},
+		},
+	}
+
+	tests := map[string]string{
+		"plain":                "NRAK-PLAIN",
+		"binary":               "NRAK-BINARY",
+		"prod/tokens#newrelic": "NRAK-CURRENT",
+		"prod/tokens?versionStage=AWSPREVIOUS#newrelic": "NRAK-PREVIOUS",
+	}
+
+	for reference, expected := range tests {
+		value, err := helper.ReadAWSSecret(reference, client)
+		assert.Nil(t, err, reference)
+		assert.Equal(t, expected, value, reference)
+	}
+
+	_, err := helper.ReadAWSSecret("prod/tokens#slack", client)
+	assert.Equal(t, helper.ErrorKindConfiguration, helper.GetErrorKind(err))
+
+	_, err = helper.ReadAWSSecret("missing", client)
+	assert.True(t, helper.IsConfigurationAWSError(err))
+}
+
+func TestReadAWSSecretNoValue(t *testing.T) {
+	client := &fake.SecretsManager{BinarySecrets: map[string][]byte{"empty": nil}}
+
+	_, err := helper.ReadAWSSecret("empty", client)
+	assert.Equal(t, helper.ErrorKindConfiguration, helper.GetErrorKind(err))
+}
+
+func TestReadAWSParameterSecureString(t *testing.T) {
+	client := &fake.SSM{
+		Parameters:       map[string]string{"template": `{"text":"hello"}`},
+		SecureParameters: map[string]string{"slack-mapping": `{"default-service": ["https://hooks.slack.com/x"]}`},
+	}
+
+	value, err := helper.ReadAWSParameter("slack-mapping", client)
+	assert.Nil(t, err)
+	assert.Equal(t, `{"default-service": ["https://hooks.slack.com/x"]}`, value)
+
+	value, err = helper.ReadAWSParameter("template", client)
+	assert.Nil(t, err)
+	assert.Equal(t, `{"text":"hello"}`, value)
+}
@@ -0,0 +1,243 @@
+package helper
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/aws/aws-sdk-go/service/cloudformation/cloudformationiface"
+	"strings"
+)
+
+// DefaultSlackCloudFormationTemplate is used when no
+// SSM_PARAMETER_MESSAGE_SLACK_CLOUDFORMATION parameter is configured
+const DefaultSlackCloudFormationTemplate = `{
+   "text":":building_construction: Infrastructure change on stack <backquote><varbegin>.StackName<varend><backquote>: <varbegin>.Status<varend>",
+   "attachments":[
+      {
+         "fallback":"Infrastructure change on stack <backquote><varbegin>.StackName<varend><backquote>: <varbegin>.Status<varend>",
+         "color":"<varbegin>if eq .Status "UPDATE_COMPLETE" "IN_SYNC"<varend>#2EB886<varbegin>else if .IsDrift<varend>#FFA500<varbegin>else<varend>#D00000<varbegin>end<varend>",
+         "fields":[
+            {
+               "title":"Stack",
+               "value":"<varbegin>.StackName<varend>",
+               "short":true
+            },
+            {
+               "title":"Service",
+               "value":"<varbegin>.ServiceName<varend>",
+               "short":true
+            },
+            {
+               "title":"Status",
+               "value":"<varbegin>.Status<varend>",
+               "short":true
+            },<varbegin>if .IsDrift<varend>
+            {
+               "title":"Drifted Resources",
+               "value":"<varbegin>.DriftedResourceCount<varend>",
+               "short":true
+            },<varbegin>end<varend><varbegin>if .StatusReason<varend>
+            {
+               "title":"Reason",
+               "value":"<varbegin>.StatusReason<varend>",
+               "short":false
+            },<varbegin>end<varend>
+            {
+               "title":"AWS Account",
+               "value":"<varbegin>.AWSAccount<varend>",
+               "short":true
+            },
+            {
+               "title":"AWS Region",
+               "value":"<varbegin>.AWSRegion<varend>",
+               "short":true
+            },
+            {
+               "title":"Timestamp",
+               "value":"<varbegin>.EventTimestamp<varend>",
+               "short":false
+            }
+         ]
+      }
+   ]
+}`
+
+type CloudFormationStatusDetails struct {
+	Status           string `json:"status"`
+	StatusReason     string `json:"status-reason"`
+	StackDriftStatus string `json:"stack-drift-status"`
+	DetectionStatus  string `json:"detection-status"`
+}
+
+type CloudFormationDriftDetails struct {
+	DriftedResourceCount int `json:"drifted-stack-resource-count"`
+}
+
+// CloudFormationInfo covers the detail of both stack status change
+// and drift detection status change events
+type CloudFormationInfo struct {
+	StackID       string                      `json:"stack-id"`
+	StatusDetails CloudFormationStatusDetails `json:"status-details"`
+	DriftDetails  CloudFormationDriftDetails  `json:"drift-detection-details"`
+}
+
+type CloudFormationNotificationFields struct {
+	StackName            string
+	StackID              string
+	ServiceName          string
+	Status               string
+	StatusReason         string
+	IsDrift              bool
+	DriftedResourceCount int
+	AWSReference         string
+	AWSRegion            string
+	AWSAccount           string
+	EventTimestamp       string
+}
+
+func IsCloudFormationDriftEvent(detailType string) bool {
+	return strings.ToLower(detailType) == "cloudformation drift detection status change"
+}
+
+func ParseCloudFormationDetails(request events.CloudWatchEvent) (CloudFormationInfo, error) {
+	var cloudFormationInfo CloudFormationInfo
+
+	err := json.Unmarshal(request.Detail, &cloudFormationInfo)
+
+	if err != nil {
+		return cloudFormationInfo, WrapError("Unexpected error unmarshaling CloudFormation details", err)
+	}
+
+	if cloudFormationInfo.StackID == "" {
+		return cloudFormationInfo, WrapError("'stack-id' attribute not found on payload", nil)
+	}
+
+	if GetCloudFormationStatus(request.DetailType, cloudFormationInfo) == "" {
+		return cloudFormationInfo, WrapError("'status-details' attribute not found on payload", nil)
+	}
+
+	return cloudFormationInfo, nil
+}
+
+func GetCloudFormationStatus(detailType string, cloudFormationInfo CloudFormationInfo) string {
+	// drift detections report the drift status of the stack, e.g. DRIFTED,
+	// and only once detection has finished
+	if IsCloudFormationDriftEvent(detailType) {
+		if cloudFormationInfo.StatusDetails.DetectionStatus != "DETECTION_COMPLETE" {
+			return cloudFormationInfo.StatusDetails.DetectionStatus
+		}
+		return cloudFormationInfo.StatusDetails.StackDriftStatus
+	}
+
+	return cloudFormationInfo.StatusDetails.Status
+}
+
+func GetStackNameFromID(stackID string) string {
+	// arn:aws:cloudformation:<region>:<account>:stack/<name>/<uuid>
+	if split := strings.Split(stackID, ":stack/"); len(split) == 2 {
+		return strings.SplitN(split[1], "/", 2)[0]
+	}
+
+	return stackID
+}
+
+func GetTrackedCloudFormationEvents() []string {
+	trackedEvents := GetStringEnv("CLOUDFORMATION_EVENTS",
+		"UPDATE_COMPLETE,UPDATE_ROLLBACK_IN_PROGRESS,UPDATE_ROLLBACK_COMPLETE,UPDATE_ROLLBACK_FAILED,DRIFTED")
+
+	var result []string
+	for _, eventName := range strings.Split(trackedEvents, ",") {
+		if trimmed := strings.TrimSpace(eventName); trimmed != "" {
+			result = append(result, trimmed)
+		}
+	}
+
+	return result
+}
+
+func IsTrackedCloudFormationEvent(status string) bool {
+	for _, trackedEvent := range GetTrackedCloudFormationEvents() {
+		if trackedEvent == status {
+			return true
+		}
+	}
+
+	return false
+}
+
+func GetCloudFormationStackTags(stackID string, client cloudformationiface.CloudFormationAPI) (map[string]string, error) {
+	output, err := client.DescribeStacks(&cloudformation.DescribeStacksInput{
+		StackName: aws.String(stackID),
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("error describing CloudFormation stack '%s': %w", stackID, err)
+	}
+
+	tags := make(map[string]string)
+
+	for _, stack := range output.Stacks {
+		for _, tag := range stack.Tags {
+			tags[aws.StringValue(tag.Key)] = aws.StringValue(tag.Value)
+		}
+	}
+
+	return tags, nil
+}
+
+func GetCloudFormationMappingKeys(stackName string, tags map[string]string) []string {
+	// keys tried, in order, against the New Relic and Slack mappings:
+	// the stack name, then the service named in the stack's service tag
+	keys := []string{stackName}
+
+	if serviceName, ok := tags[GetStringEnv("CLOUDFORMATION_SERVICE_TAG", "service")]; ok && serviceName != "" {
+		keys = append(keys, serviceName)
+	}
+
+	return keys
+}
+
+func GenerateCloudFormationNotificationStruct(request events.CloudWatchEvent, cloudFormationInfo CloudFormationInfo,
+	serviceName string) CloudFormationNotificationFields {
+	return CloudFormationNotificationFields{
+		StackName:            GetStackNameFromID(cloudFormationInfo.StackID),
+		StackID:              cloudFormationInfo.StackID,
+		ServiceName:          serviceName,
+		Status:               GetCloudFormationStatus(request.DetailType, cloudFormationInfo),
+		StatusReason:         EscapeJSONString(cloudFormationInfo.StatusDetails.StatusReason),
+		IsDrift:              IsCloudFormationDriftEvent(request.DetailType),
+		DriftedResourceCount: cloudFormationInfo.DriftDetails.DriftedResourceCount,
+		AWSReference:         request.ID,
+		AWSRegion:            request.Region,
+		AWSAccount:           request.AccountID,
+		EventTimestamp:       request.Time.UTC().Format("2006-01-02T15:04:05Z"),
+	}
+}
+
+func GetCloudFormationNewRelicPayload(request events.CloudWatchEvent, cloudFormationInfo CloudFormationInfo,
+	notificationFields CloudFormationNotificationFields) map[string]string {
+	// stack changes are submitted as deployments marked as infrastructure
+	// changes, so they show up next to application deploys
+	result := make(map[string]string)
+
+	changeKind := "stack update"
+	if notificationFields.IsDrift {
+		changeKind = "drift detected"
+	}
+
+	result["revision"] = fmt.Sprintf("%s %s", notificationFields.StackName, notificationFields.Status)
+	result["timestamp"] = notificationFields.EventTimestamp
+	result["user"] = GetDeploymentUser()
+	result["description"] = fmt.Sprintf("INFRASTRUCTURE: CloudFormation %s %s, AWS Account: %s, Region: %s, "+
+		"Deployment ID: %s", changeKind, notificationFields.StackName, request.AccountID, request.Region, request.ID)
+	result["changelog"] = fmt.Sprintf("Infrastructure change: %s", notificationFields.Status)
+
+	// the notification fields hold the reason escaped for the Slack template
+	if reason := cloudFormationInfo.StatusDetails.StatusReason; reason != "" {
+		result["changelog"] = fmt.Sprintf("%s, %s", result["changelog"], reason)
+	}
+
+	return result
+}
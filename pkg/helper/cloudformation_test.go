@@ -0,0 +1,146 @@
+package helper_test
+
+import (
+	"deployment-notifications/pkg/helper"
+	"encoding/json"
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"testing"
+)
+
+const sampleCloudFormationStackEvent = `
+{
+   "version": "0",
+   "id": "6a7e8feb-b491-4cf7-a9f1-bf3703467718",
+   "detail-type": "CloudFormation Stack Status Change",
+   "source": "aws.cloudformation",
+   "account": "111122223333",
+   "time": "2020-05-23T12:31:14Z",
+   "region": "us-west-2",
+   "resources": [
+        "arn:aws:cloudformation:us-west-2:111122223333:stack/content-api-infra/6b5f4f50-9cf2-11ea-8bbc-02e5e5b1c1a2"
+   ],
+   "detail": {
+        "stack-id": "arn:aws:cloudformation:us-west-2:111122223333:stack/content-api-infra/6b5f4f50-9cf2-11ea-8bbc-02e5e5b1c1a2",
+        "status-details": {
+            "status": "UPDATE_ROLLBACK_COMPLETE",
+            "status-reason": "The following resource(s) failed to update: [\"Database\"]."
+        },
+        "client-request-token": ""
+   }
+}
+`
+
+const sampleCloudFormationDriftDetail = `
+{
+    "stack-id": "arn:aws:cloudformation:us-west-2:111122223333:stack/content-api-infra/6b5f4f50-9cf2-11ea-8bbc-02e5e5b1c1a2",
+    "status-details": {
+        "stack-drift-status": "DRIFTED",
+        "detection-status": "DETECTION_COMPLETE"
+    },
+    "drift-detection-details": {
+        "drifted-stack-resource-count": 2
+    }
+}
+`
+
+func TestParseCloudFormationDetails(t *testing.T) {
+	var cloudwatchEvent events.CloudWatchEvent
+	err := json.Unmarshal([]byte(sampleCloudFormationStackEvent), &cloudwatchEvent)
+	assert.Nil(t, err)
+
+	cloudFormationInfo, err := helper.ParseCloudFormationDetails(cloudwatchEvent)
+	assert.Nil(t, err)
+	assert.Equal(t, "content-api-infra", helper.GetStackNameFromID(cloudFormationInfo.StackID))
+	assert.Equal(t, "UPDATE_ROLLBACK_COMPLETE", helper.GetCloudFormationStatus(cloudwatchEvent.DetailType, cloudFormationInfo))
+	assert.False(t, helper.IsCloudFormationDriftEvent(cloudwatchEvent.DetailType))
+
+	cloudwatchEvent.DetailType = "CloudFormation Drift Detection Status Change"
+	cloudwatchEvent.Detail = json.RawMessage(sampleCloudFormationDriftDetail)
+	cloudFormationInfo, err = helper.ParseCloudFormationDetails(cloudwatchEvent)
+	assert.Nil(t, err)
+	assert.Equal(t, "DRIFTED", helper.GetCloudFormationStatus(cloudwatchEvent.DetailType, cloudFormationInfo))
+	assert.Equal(t, 2, cloudFormationInfo.DriftDetails.DriftedResourceCount)
+
+	cloudFormationInfo.StatusDetails.DetectionStatus = "DETECTION_IN_PROGRESS"
+	assert.Equal(t, "DETECTION_IN_PROGRESS", helper.GetCloudFormationStatus(cloudwatchEvent.DetailType, cloudFormationInfo))
+
+	cloudwatchEvent.Detail = json.RawMessage(`{"stack-id": "content-api-infra"}`)
+	_, err = helper.ParseCloudFormationDetails(cloudwatchEvent)
+	assert.NotNil(t, err)
+
+	assert.Equal(t, "content-api-infra", helper.GetStackNameFromID("content-api-infra"))
+}
+
+func TestTrackedCloudFormationEvents(t *testing.T) {
+	currentEvents := os.Getenv("CLOUDFORMATION_EVENTS")
+	defer os.Setenv("CLOUDFORMATION_EVENTS", currentEvents)
+
+	os.Unsetenv("CLOUDFORMATION_EVENTS")
+	assert.True(t, helper.IsTrackedCloudFormationEvent("UPDATE_COMPLETE"))
+	assert.True(t, helper.IsTrackedCloudFormationEvent("UPDATE_ROLLBACK_FAILED"))
+	assert.True(t, helper.IsTrackedCloudFormationEvent("DRIFTED"))
+	assert.False(t, helper.IsTrackedCloudFormationEvent("UPDATE_IN_PROGRESS"))
+	assert.False(t, helper.IsTrackedCloudFormationEvent("IN_SYNC"))
+
+	os.Setenv("CLOUDFORMATION_EVENTS", "CREATE_COMPLETE, IN_SYNC")
+	assert.True(t, helper.IsTrackedCloudFormationEvent("IN_SYNC"))
+	assert.False(t, helper.IsTrackedCloudFormationEvent("DRIFTED"))
+}
+
+func TestCloudFormationMappingKeys(t *testing.T) {
+	currentTag := os.Getenv("CLOUDFORMATION_SERVICE_TAG")
+	defer os.Setenv("CLOUDFORMATION_SERVICE_TAG", currentTag)
+
+	tags := map[string]string{"service": "content-api", "app": "content"}
+
+	os.Unsetenv("CLOUDFORMATION_SERVICE_TAG")
+	assert.Equal(t, []string{"content-api-infra", "content-api"}, helper.GetCloudFormationMappingKeys("content-api-infra", tags))
+	assert.Equal(t, []string{"content-api-infra"}, helper.GetCloudFormationMappingKeys("content-api-infra", nil))
+
+	os.Setenv("CLOUDFORMATION_SERVICE_TAG", "app")
+	assert.Equal(t, []string{"content-api-infra", "content"}, helper.GetCloudFormationMappingKeys("content-api-infra", tags))
+}
+
+func TestCloudFormationNotification(t *testing.T) {
+	var cloudwatchEvent events.CloudWatchEvent
+	err := json.Unmarshal([]byte(sampleCloudFormationStackEvent), &cloudwatchEvent)
+	assert.Nil(t, err)
+
+	currentDeploymentUser := os.Getenv("DEPLOYMENT_USER")
+	os.Unsetenv("DEPLOYMENT_USER")
+	defer os.Setenv("DEPLOYMENT_USER", currentDeploymentUser)
+
+	cloudFormationInfo, _ := helper.ParseCloudFormationDetails(cloudwatchEvent)
+	slackStruct := helper.GenerateCloudFormationNotificationStruct(cloudwatchEvent, cloudFormationInfo, "content-api")
+	assert.Equal(t, "content-api-infra", slackStruct.StackName)
+	assert.Equal(t, `The following resource(s) failed to update: [\"Database\"].`, slackStruct.StatusReason)
+
+	parsedMessage, err := helper.GeneratePayload(helper.DefaultSlackCloudFormationTemplate, slackStruct, true)
+	assert.Nil(t, err)
+	assert.True(t, json.Valid([]byte(parsedMessage)))
+	assert.Contains(t, parsedMessage, "Infrastructure change on stack `content-api-infra`: UPDATE_ROLLBACK_COMPLETE")
+	assert.Contains(t, parsedMessage, `"color":"#D00000"`)
+	assert.NotContains(t, parsedMessage, `"title":"Drifted Resources"`)
+
+	newRelicMap := helper.GetCloudFormationNewRelicPayload(cloudwatchEvent, cloudFormationInfo, slackStruct)
+	assert.Equal(t, "content-api-infra UPDATE_ROLLBACK_COMPLETE", newRelicMap["revision"])
+	assert.Equal(t, "INFRASTRUCTURE: CloudFormation stack update content-api-infra, AWS Account: 111122223333, "+
+		"Region: us-west-2, Deployment ID: 6a7e8feb-b491-4cf7-a9f1-bf3703467718", newRelicMap["description"])
+	assert.Equal(t, `Infrastructure change: UPDATE_ROLLBACK_COMPLETE, The following resource(s) failed to update: `+
+		`["Database"].`, newRelicMap["changelog"])
+
+	cloudwatchEvent.DetailType = "CloudFormation Drift Detection Status Change"
+	cloudwatchEvent.Detail = json.RawMessage(sampleCloudFormationDriftDetail)
+	cloudFormationInfo, _ = helper.ParseCloudFormationDetails(cloudwatchEvent)
+	slackStruct = helper.GenerateCloudFormationNotificationStruct(cloudwatchEvent, cloudFormationInfo, "content-api")
+
+	parsedMessage, err = helper.GeneratePayload(helper.DefaultSlackCloudFormationTemplate, slackStruct, true)
+	assert.Nil(t, err)
+	assert.True(t, json.Valid([]byte(parsedMessage)))
+	assert.Contains(t, parsedMessage, `"color":"#FFA500"`)
+	assert.Contains(t, parsedMessage, `"title":"Drifted Resources"`)
+	assert.Contains(t, helper.GetCloudFormationNewRelicPayload(cloudwatchEvent, cloudFormationInfo, slackStruct)["description"],
+		"INFRASTRUCTURE: CloudFormation drift detected content-api-infra")
+}
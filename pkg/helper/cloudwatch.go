@@ -0,0 +1,70 @@
+package helper
+
+import (
+	"fmt"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/aws/aws-sdk-go/service/cloudwatch/cloudwatchiface"
+	"time"
+)
+
+// PutMetricData accepts at most 20 metrics per call
+const cloudWatchMetricBatchSize = 20
+
+func GenerateDORAMetricData(metrics []DORAMetrics, dimensionName string, timestamp time.Time) []*cloudwatch.MetricDatum {
+	var metricData []*cloudwatch.MetricDatum
+
+	for _, groupMetrics := range metrics {
+		dimensions := []*cloudwatch.Dimension{
+			{Name: aws.String(dimensionName), Value: aws.String(groupMetrics.Group)},
+		}
+
+		metricData = append(metricData,
+			&cloudwatch.MetricDatum{
+				MetricName: aws.String("DeploymentFrequency"),
+				Dimensions: dimensions,
+				Timestamp:  aws.Time(timestamp),
+				Unit:       aws.String(cloudwatch.StandardUnitCount),
+				Value:      aws.Float64(groupMetrics.DeploymentsPerDay),
+			},
+			&cloudwatch.MetricDatum{
+				MetricName: aws.String("ChangeFailureRate"),
+				Dimensions: dimensions,
+				Timestamp:  aws.Time(timestamp),
+				Unit:       aws.String(cloudwatch.StandardUnitPercent),
+				Value:      aws.Float64(groupMetrics.ChangeFailureRate),
+			})
+
+		if groupMetrics.RestoredFailures > 0 {
+			metricData = append(metricData, &cloudwatch.MetricDatum{
+				MetricName: aws.String("TimeToRestore"),
+				Dimensions: dimensions,
+				Timestamp:  aws.Time(timestamp),
+				Unit:       aws.String(cloudwatch.StandardUnitSeconds),
+				Value:      aws.Float64(groupMetrics.MeanTimeToRestore.Seconds()),
+			})
+		}
+	}
+
+	return metricData
+}
+
+func PutCloudWatchMetrics(namespace string, metricData []*cloudwatch.MetricDatum, client cloudwatchiface.CloudWatchAPI) error {
+	for start := 0; start < len(metricData); start += cloudWatchMetricBatchSize {
+		end := start + cloudWatchMetricBatchSize
+		if end > len(metricData) {
+			end = len(metricData)
+		}
+
+		_, err := client.PutMetricData(&cloudwatch.PutMetricDataInput{
+			Namespace:  aws.String(namespace),
+			MetricData: metricData[start:end],
+		})
+
+		if err != nil {
+			return fmt.Errorf("error putting metrics to namespace '%s': %w", namespace, err)
+		}
+	}
+
+	return nil
+}
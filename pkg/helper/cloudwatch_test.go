@@ -0,0 +1,31 @@
+package helper_test
+
+import (
+	"deployment-notifications/pkg/helper"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestGenerateDORAMetricData(t *testing.T) {
+	timestamp, _ := time.Parse(time.RFC3339, "2020-05-23T00:00:00Z")
+
+	metrics := []helper.DORAMetrics{
+		{Group: "content-api", DeploymentsPerDay: 1.5, ChangeFailureRate: 10,
+			MeanTimeToRestore: 30 * time.Minute, RestoredFailures: 1},
+		{Group: "auth-api", DeploymentsPerDay: 0.5},
+	}
+
+	metricData := helper.GenerateDORAMetricData(metrics, "Service", timestamp)
+
+	assert.Equal(t, 5, len(metricData))
+	assert.Equal(t, "DeploymentFrequency", *metricData[0].MetricName)
+	assert.Equal(t, "Service", *metricData[0].Dimensions[0].Name)
+	assert.Equal(t, "content-api", *metricData[0].Dimensions[0].Value)
+	assert.Equal(t, 1.5, *metricData[0].Value)
+	assert.Equal(t, "ChangeFailureRate", *metricData[1].MetricName)
+	assert.Equal(t, "TimeToRestore", *metricData[2].MetricName)
+	assert.Equal(t, float64(1800), *metricData[2].Value)
+	assert.Equal(t, "auth-api", *metricData[3].Dimensions[0].Value)
+	assert.Equal(t, "ChangeFailureRate", *metricData[4].MetricName)
+}
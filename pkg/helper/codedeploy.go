@@ -0,0 +1,238 @@
+package helper
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/codedeploy"
+	"github.com/aws/aws-sdk-go/service/codedeploy/codedeployiface"
+)
+
+// DefaultSlackCodeDeployTemplate is used when no
+// SSM_PARAMETER_MESSAGE_SLACK_CODEDEPLOY parameter is configured
+const DefaultSlackCodeDeployTemplate = `{
+   "text":"<varbegin>if .IsRollback<varend><!here> :rotating_light: ROLLBACK <varbegin>end<varend>Blue/green deployment of <backquote><varbegin>.ServiceName<varend><backquote>: <varbegin>.Phase<varend>",
+   "attachments":[
+      {
+         "fallback":"Blue/green deployment of <backquote><varbegin>.ServiceName<varend><backquote>: <varbegin>.Phase<varend>",
+         "color":"<varbegin>if eq .State "SUCCESS"<varend>#2EB886<varbegin>else if eq .State "FAILURE" "STOP"<varend>#D00000<varbegin>else<varend>#439FE0<varbegin>end<varend>",
+         "fields":[
+            {
+               "title":"Service",
+               "value":"<varbegin>.ServiceName<varend>",
+               "short":true
+            },
+            {
+               "title":"Deployment Group",
+               "value":"<varbegin>.Application<varend>/<varbegin>.DeploymentGroup<varend>",
+               "short":true
+            },
+            {
+               "title":"Deployment",
+               "value":"<varbegin>.DeploymentID<varend>",
+               "short":true
+            },
+            {
+               "title":"State",
+               "value":"<varbegin>.State<varend>",
+               "short":true
+            },
+            {
+               "title":"Details",
+               "value":"<varbegin>.Message<varend>",
+               "short":false
+            },
+            {
+               "title":"AWS Account",
+               "value":"<varbegin>.AWSAccount<varend>",
+               "short":true
+            },
+            {
+               "title":"AWS Region",
+               "value":"<varbegin>.AWSRegion<varend>",
+               "short":true
+            },
+            {
+               "title":"Timestamp",
+               "value":"<varbegin>.EventTimestamp<varend>",
+               "short":false
+            }
+         ]
+      }
+   ]
+}`
+
+type CodeDeployNotificationFields struct {
+	ServiceName     string
+	Application     string
+	DeploymentGroup string
+	DeploymentID    string
+	State           string
+	Phase           string
+	Message         string
+	IsRollback      bool
+	AWSReference    string
+	AWSRegion       string
+	AWSAccount      string
+	EventTimestamp  string
+}
+
+func ParseCodeDeployDetails(request events.CloudWatchEvent) (events.CodeDeployEventDetail, error) {
+	var codeDeployDetail events.CodeDeployEventDetail
+
+	err := json.Unmarshal(request.Detail, &codeDeployDetail)
+
+	if err != nil {
+		return codeDeployDetail, WrapError("Unexpected error unmarshaling CodeDeploy details", err)
+	}
+
+	if codeDeployDetail.DeploymentID == "" {
+		return codeDeployDetail, WrapError("'deploymentId' attribute not found on payload", nil)
+	}
+	if codeDeployDetail.State == "" {
+		return codeDeployDetail, WrapError("'state' attribute not found on payload", nil)
+	}
+	if codeDeployDetail.DeploymentGroup == "" {
+		return codeDeployDetail, WrapError("'deploymentGroup' attribute not found on payload", nil)
+	}
+
+	return codeDeployDetail, nil
+}
+
+func GetCodeDeployment(deploymentID string, client codedeployiface.CodeDeployAPI) (*codedeploy.DeploymentInfo, error) {
+	output, err := client.GetDeployment(&codedeploy.GetDeploymentInput{
+		DeploymentId: aws.String(deploymentID),
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("error getting CodeDeploy deployment '%s': %w", deploymentID, err)
+	}
+
+	return output.DeploymentInfo, nil
+}
+
+func GetCodeDeployECSServices(application, deploymentGroup string,
+	client codedeployiface.CodeDeployAPI) ([]*codedeploy.ECSService, error) {
+	output, err := client.GetDeploymentGroup(&codedeploy.GetDeploymentGroupInput{
+		ApplicationName:     aws.String(application),
+		DeploymentGroupName: aws.String(deploymentGroup),
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("error getting CodeDeploy deployment group '%s/%s': %w", application, deploymentGroup, err)
+	}
+
+	if output.DeploymentGroupInfo == nil {
+		return nil, fmt.Errorf("CodeDeploy deployment group '%s/%s' has no info", application, deploymentGroup)
+	}
+
+	return output.DeploymentGroupInfo.EcsServices, nil
+}
+
+func GetCodeDeployMappingKeys(application, deploymentGroup string) []string {
+	// keys tried, in order, against the CodeDeploy to ECS service mapping
+	return []string{fmt.Sprintf("%s/%s", application, deploymentGroup), deploymentGroup}
+}
+
+func IsCodeDeployRollback(deploymentInfo *codedeploy.DeploymentInfo) bool {
+	// automatic and manual rollbacks are new deployments that carry the
+	// deployment they roll back in their rollback info
+	if deploymentInfo == nil {
+		return false
+	}
+
+	if aws.StringValue(deploymentInfo.Creator) == codedeploy.DeploymentCreatorCodeDeployRollback {
+		return true
+	}
+
+	return deploymentInfo.RollbackInfo != nil &&
+		aws.StringValue(deploymentInfo.RollbackInfo.RollbackTriggeringDeploymentId) != ""
+}
+
+func GetCodeDeployPhase(state string, deploymentInfo *codedeploy.DeploymentInfo) string {
+	// CodeDeploy only emits state changes, so the blue/green lifecycle
+	// phase is derived from the state and the deployment's details
+	rollback := IsCodeDeployRollback(deploymentInfo)
+
+	switch state {
+	case "START":
+		if rollback {
+			return "Rollback started"
+		}
+		return "Deployment started, replacement task set is being created"
+	case "READY":
+		return "Replacement task set ready, waiting for traffic shift"
+	case "SUCCESS":
+		if rollback {
+			return "Rollback complete, traffic is back on the original task set"
+		}
+		return "Traffic shifted and bake complete, original task set terminated"
+	case "FAILURE":
+		if deploymentInfo != nil && deploymentInfo.RollbackInfo != nil &&
+			aws.StringValue(deploymentInfo.RollbackInfo.RollbackDeploymentId) != "" {
+			return "Deployment failed, rolling back"
+		}
+		return "Deployment failed"
+	case "STOP":
+		return "Deployment stopped"
+	}
+
+	if deploymentInfo != nil && aws.StringValue(deploymentInfo.Status) == codedeploy.DeploymentStatusBaking {
+		return "Traffic shifted, baking before the original task set is terminated"
+	}
+
+	return state
+}
+
+func GetCodeDeployMessage(deploymentInfo *codedeploy.DeploymentInfo) string {
+	if deploymentInfo == nil {
+		return ""
+	}
+
+	if deploymentInfo.RollbackInfo != nil && aws.StringValue(deploymentInfo.RollbackInfo.RollbackMessage) != "" {
+		return aws.StringValue(deploymentInfo.RollbackInfo.RollbackMessage)
+	}
+
+	if deploymentInfo.ErrorInformation != nil {
+		return fmt.Sprintf("%s: %s", aws.StringValue(deploymentInfo.ErrorInformation.Code),
+			aws.StringValue(deploymentInfo.ErrorInformation.Message))
+	}
+
+	return aws.StringValue(deploymentInfo.Description)
+}
+
+func GenerateCodeDeployNotificationStruct(request events.CloudWatchEvent, serviceName string,
+	codeDeployDetail events.CodeDeployEventDetail, deploymentInfo *codedeploy.DeploymentInfo) CodeDeployNotificationFields {
+	return CodeDeployNotificationFields{
+		ServiceName:     serviceName,
+		Application:     codeDeployDetail.Application,
+		DeploymentGroup: codeDeployDetail.DeploymentGroup,
+		DeploymentID:    codeDeployDetail.DeploymentID,
+		State:           string(codeDeployDetail.State),
+		Phase:           GetCodeDeployPhase(string(codeDeployDetail.State), deploymentInfo),
+		Message:         EscapeJSONString(GetCodeDeployMessage(deploymentInfo)),
+		IsRollback:      IsCodeDeployRollback(deploymentInfo),
+		AWSReference:    request.ID,
+		AWSRegion:       request.Region,
+		AWSAccount:      request.AccountID,
+		EventTimestamp:  request.Time.UTC().Format("2006-01-02T15:04:05Z"),
+	}
+}
+
+func GetCodeDeployNewRelicPayload(request events.CloudWatchEvent, notificationFields CodeDeployNotificationFields) map[string]string {
+	result := make(map[string]string)
+
+	result["revision"] = notificationFields.DeploymentID
+	result["timestamp"] = notificationFields.EventTimestamp
+	result["user"] = GetDeploymentUser()
+	result["description"] = fmt.Sprintf("CodeDeploy blue/green %s/%s, AWS Account: %s, Region: %s, Deployment ID: %s",
+		notificationFields.Application, notificationFields.DeploymentGroup, request.AccountID, request.Region, request.ID)
+	result["changelog"] = notificationFields.Phase
+
+	if notificationFields.IsRollback {
+		result["description"] = fmt.Sprintf("ROLLBACK: %s", result["description"])
+	}
+
+	return result
+}
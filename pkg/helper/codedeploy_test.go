@@ -0,0 +1,168 @@
+package helper_test
+
+import (
+	"deployment-notifications/pkg/fake"
+	"deployment-notifications/pkg/helper"
+	"encoding/json"
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/codedeploy"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"testing"
+)
+
+const sampleCodeDeployEvent = `
+{
+   "version": "0",
+   "id": "c071bfbf-83c4-49ca-a6ff-3df053957145",
+   "detail-type": "CodeDeploy Deployment State-change Notification",
+   "source": "aws.codedeploy",
+   "account": "111122223333",
+   "time": "2020-05-23T12:31:14Z",
+   "region": "us-west-2",
+   "resources": [
+        "arn:aws:codedeploy:us-west-2:111122223333:application:AppECS-content",
+        "arn:aws:codedeploy:us-west-2:111122223333:deploymentgroup:AppECS-content/DgpECS-content-api"
+   ],
+   "detail": {
+        "instanceGroupId": "9fd2fbef-2157-40d8-91e7-6845af69e2d2",
+        "region": "us-west-2",
+        "application": "AppECS-content",
+        "deploymentId": "d-1A2B3C4D5",
+        "state": "SUCCESS",
+        "deploymentGroup": "DgpECS-content-api"
+   }
+}
+`
+
+func TestParseCodeDeployDetails(t *testing.T) {
+	var cloudwatchEvent events.CloudWatchEvent
+	err := json.Unmarshal([]byte(sampleCodeDeployEvent), &cloudwatchEvent)
+	assert.Nil(t, err)
+
+	codeDeployDetail, err := helper.ParseCodeDeployDetails(cloudwatchEvent)
+	assert.Nil(t, err)
+	assert.Equal(t, "d-1A2B3C4D5", codeDeployDetail.DeploymentID)
+	assert.Equal(t, events.CodeDeployDeploymentStateSuccess, codeDeployDetail.State)
+	assert.Equal(t, "AppECS-content", codeDeployDetail.Application)
+	assert.Equal(t, "DgpECS-content-api", codeDeployDetail.DeploymentGroup)
+
+	cloudwatchEvent.Detail = json.RawMessage(`{"deploymentId": "d-1A2B3C4D5", "state": "START"}`)
+	_, err = helper.ParseCodeDeployDetails(cloudwatchEvent)
+	assert.NotNil(t, err)
+}
+
+func TestCodeDeployMappingKeys(t *testing.T) {
+	assert.Equal(t, []string{"AppECS-content/DgpECS-content-api", "DgpECS-content-api"},
+		helper.GetCodeDeployMappingKeys("AppECS-content", "DgpECS-content-api"))
+}
+
+func TestCodeDeployPhase(t *testing.T) {
+	assert.Equal(t, "Deployment started, replacement task set is being created", helper.GetCodeDeployPhase("START", nil))
+	assert.Equal(t, "Replacement task set ready, waiting for traffic shift", helper.GetCodeDeployPhase("READY", nil))
+	assert.Equal(t, "Traffic shifted and bake complete, original task set terminated",
+		helper.GetCodeDeployPhase("SUCCESS", nil))
+	assert.Equal(t, "Deployment failed", helper.GetCodeDeployPhase("FAILURE", nil))
+
+	rolledBack := &codedeploy.DeploymentInfo{
+		RollbackInfo: &codedeploy.RollbackInfo{
+			RollbackDeploymentId: aws.String("d-ROLLBACK1"),
+			RollbackMessage:      aws.String("Deployment d-1A2B3C4D5 failed, rolled back by d-ROLLBACK1"),
+		},
+	}
+	assert.Equal(t, "Deployment failed, rolling back", helper.GetCodeDeployPhase("FAILURE", rolledBack))
+	assert.False(t, helper.IsCodeDeployRollback(rolledBack))
+	assert.Equal(t, "Deployment d-1A2B3C4D5 failed, rolled back by d-ROLLBACK1", helper.GetCodeDeployMessage(rolledBack))
+
+	rollback := &codedeploy.DeploymentInfo{
+		Creator: aws.String(codedeploy.DeploymentCreatorCodeDeployRollback),
+	}
+	assert.True(t, helper.IsCodeDeployRollback(rollback))
+	assert.Equal(t, "Rollback started", helper.GetCodeDeployPhase("START", rollback))
+	assert.Equal(t, "Rollback complete, traffic is back on the original task set",
+		helper.GetCodeDeployPhase("SUCCESS", rollback))
+
+	baking := &codedeploy.DeploymentInfo{Status: aws.String(codedeploy.DeploymentStatusBaking)}
+	assert.Equal(t, "Traffic shifted, baking before the original task set is terminated",
+		helper.GetCodeDeployPhase("IN_PROGRESS", baking))
+
+	failed := &codedeploy.DeploymentInfo{
+		ErrorInformation: &codedeploy.ErrorInformation{
+			Code:    aws.String("ECS_UPDATE_ERROR"),
+			Message: aws.String("The ECS service cannot be updated"),
+		},
+	}
+	assert.Equal(t, "ECS_UPDATE_ERROR: The ECS service cannot be updated", helper.GetCodeDeployMessage(failed))
+}
+
+func TestCodeDeployNotification(t *testing.T) {
+	var cloudwatchEvent events.CloudWatchEvent
+	err := json.Unmarshal([]byte(sampleCodeDeployEvent), &cloudwatchEvent)
+	assert.Nil(t, err)
+
+	currentDeploymentUser := os.Getenv("DEPLOYMENT_USER")
+	os.Unsetenv("DEPLOYMENT_USER")
+	defer os.Setenv("DEPLOYMENT_USER", currentDeploymentUser)
+
+	codeDeployDetail, _ := helper.ParseCodeDeployDetails(cloudwatchEvent)
+	rollback := &codedeploy.DeploymentInfo{Creator: aws.String(codedeploy.DeploymentCreatorCodeDeployRollback)}
+
+	slackStruct := helper.GenerateCodeDeployNotificationStruct(cloudwatchEvent, "content-api", codeDeployDetail, rollback)
+	assert.Equal(t, "content-api", slackStruct.ServiceName)
+	assert.Equal(t, "SUCCESS", slackStruct.State)
+	assert.True(t, slackStruct.IsRollback)
+	assert.Equal(t, "2020-05-23T12:31:14Z", slackStruct.EventTimestamp)
+
+	parsedMessage, err := helper.GeneratePayload(helper.DefaultSlackCodeDeployTemplate, slackStruct, true)
+	assert.Nil(t, err)
+	assert.True(t, json.Valid([]byte(parsedMessage)))
+	assert.Contains(t, parsedMessage, "ROLLBACK Blue/green deployment of `content-api`")
+	assert.Contains(t, parsedMessage, `"color":"#2EB886"`)
+
+	newRelicMap := helper.GetCodeDeployNewRelicPayload(cloudwatchEvent, slackStruct)
+	assert.Equal(t, "d-1A2B3C4D5", newRelicMap["revision"])
+	assert.Equal(t, "services@graphcms.com", newRelicMap["user"])
+	assert.Equal(t, "Rollback complete, traffic is back on the original task set", newRelicMap["changelog"])
+	assert.Equal(t, "ROLLBACK: CodeDeploy blue/green AppECS-content/DgpECS-content-api, AWS Account: 111122223333, "+
+		"Region: us-west-2, Deployment ID: c071bfbf-83c4-49ca-a6ff-3df053957145", newRelicMap["description"])
+}
+
+func TestCodeDeployNotificationEscaping(t *testing.T) {
+	var cloudwatchEvent events.CloudWatchEvent
+	err := json.Unmarshal([]byte(sampleCodeDeployEvent), &cloudwatchEvent)
+	assert.Nil(t, err)
+
+	codeDeployDetail, _ := helper.ParseCodeDeployDetails(cloudwatchEvent)
+	rollback := &codedeploy.DeploymentInfo{
+		RollbackInfo: &codedeploy.RollbackInfo{
+			RollbackMessage: aws.String(`Alarm "content-api-5xx" fired on path C:\app`),
+		},
+	}
+
+	slackStruct := helper.GenerateCodeDeployNotificationStruct(cloudwatchEvent, "content-api", codeDeployDetail, rollback)
+
+	parsedMessage, err := helper.GeneratePayload(helper.DefaultSlackCodeDeployTemplate, slackStruct, true)
+	assert.Nil(t, err)
+	assert.True(t, json.Valid([]byte(parsedMessage)))
+	assert.Contains(t, parsedMessage, `Alarm \"content-api-5xx\" fired on path C:\\app`)
+}
+
+func TestGetCodeDeployECSServices(t *testing.T) {
+	client := &fake.CodeDeploy{DeploymentGroups: map[string]*codedeploy.DeploymentGroupInfo{
+		"AppECS-content/DgpECS-content-api": {
+			EcsServices: []*codedeploy.ECSService{{ServiceName: aws.String("content-api")}},
+		},
+		"AppECS-content/DgpECS-empty": nil,
+	}}
+
+	services, err := helper.GetCodeDeployECSServices("AppECS-content", "DgpECS-content-api", client)
+	assert.Nil(t, err)
+	assert.Equal(t, "content-api", aws.StringValue(services[0].ServiceName))
+
+	_, err = helper.GetCodeDeployECSServices("AppECS-content", "DgpECS-empty", client)
+	assert.NotNil(t, err)
+
+	_, err = helper.GetCodeDeployECSServices("AppECS-content", "DgpECS-missing", client)
+	assert.NotNil(t, err)
+}
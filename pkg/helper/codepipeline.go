@@ -0,0 +1,399 @@
+package helper
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/codepipeline"
+	"github.com/aws/aws-sdk-go/service/codepipeline/codepipelineiface"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+const (
+	CodePipelineLevelPipeline = "PIPELINE"
+	CodePipelineLevelStage    = "STAGE"
+	CodePipelineLevelAction   = "ACTION"
+)
+
+// DefaultSlackCodePipelineTemplate is used when no
+// SSM_PARAMETER_MESSAGE_SLACK_CODEPIPELINE parameter is configured
+const DefaultSlackCodePipelineTemplate = `{
+   "text":"<varbegin>if eq .State "FAILED"<varend>:x: <varbegin>end<varend><varbegin>.Headline<varend>",
+   "attachments":[
+      {
+         "fallback":"<varbegin>.Headline<varend>",
+         "color":"<varbegin>if eq .State "SUCCEEDED"<varend>#2EB886<varbegin>else if eq .State "FAILED"<varend>#D00000<varbegin>else if eq .State "STARTED" "RESUMED"<varend>#439FE0<varbegin>else<varend>#AAAAAA<varbegin>end<varend>",
+         "fields":[
+            {
+               "title":"Pipeline",
+               "value":"<<varbegin>.ConsoleURL<varend>|<varbegin>.Pipeline<varend>>",
+               "short":true
+            },
+            {
+               "title":"Service",
+               "value":"<varbegin>.ServiceName<varend>",
+               "short":true
+            },<varbegin>if .Stages<varend>
+            {
+               "title":"Stages",
+               "value":"<varbegin>.Stages<varend>",
+               "short":false
+            },<varbegin>end<varend><varbegin>if .FailedAction<varend>
+            {
+               "title":"Failed Action",
+               "value":"<varbegin>.FailedAction<varend>: <varbegin>.FailedActionSummary<varend>",
+               "short":false
+            },<varbegin>end<varend><varbegin>if .Deployment<varend>
+            {
+               "title":"ECS Deployment",
+               "value":"<varbegin>.Deployment<varend>",
+               "short":false
+            },<varbegin>end<varend>
+            {
+               "title":"Execution",
+               "value":"<varbegin>.ExecutionID<varend>",
+               "short":false
+            },
+            {
+               "title":"AWS Account",
+               "value":"<varbegin>.AWSAccount<varend>",
+               "short":true
+            },
+            {
+               "title":"AWS Region",
+               "value":"<varbegin>.AWSRegion<varend>",
+               "short":true
+            },
+            {
+               "title":"Timestamp",
+               "value":"<varbegin>.EventTimestamp<varend>",
+               "short":false
+            }
+         ]
+      }
+   ]
+}`
+
+type CodePipelineActionType struct {
+	Owner    string `json:"owner"`
+	Category string `json:"category"`
+	Provider string `json:"provider"`
+	Version  string `json:"version"`
+}
+
+type CodePipelineExecutionResult struct {
+	ExternalExecutionID      string `json:"external-execution-id"`
+	ExternalExecutionSummary string `json:"external-execution-summary"`
+	ExternalExecutionURL     string `json:"external-execution-url"`
+	ErrorCode                string `json:"error-code"`
+}
+
+// CodePipelineInfo covers the detail of pipeline, stage and action
+// execution events - stage and action are empty where they don't apply
+type CodePipelineInfo struct {
+	Pipeline        string                      `json:"pipeline"`
+	ExecutionID     string                      `json:"execution-id"`
+	State           string                      `json:"state"`
+	Stage           string                      `json:"stage"`
+	Action          string                      `json:"action"`
+	Region          string                      `json:"region"`
+	Type            CodePipelineActionType      `json:"type"`
+	ExecutionResult CodePipelineExecutionResult `json:"execution-result"`
+}
+
+type CodePipelineStageSummary struct {
+	Name   string
+	Status string
+}
+
+// CodePipelineDeployTarget is an ECS service deployed by a pipeline
+// action, either directly or through CodeDeploy blue/green
+type CodePipelineDeployTarget struct {
+	Provider        string
+	ClusterName     string
+	ServiceName     string
+	Application     string
+	DeploymentGroup string
+	DeploymentID    string
+	Status          string
+}
+
+type CodePipelineSummary struct {
+	Stages              []CodePipelineStageSummary
+	FailedAction        string
+	FailedActionSummary string
+	DeployTargets       []CodePipelineDeployTarget
+}
+
+type CodePipelineNotificationFields struct {
+	Pipeline            string
+	ExecutionID         string
+	Level               string
+	State               string
+	Stage               string
+	Action              string
+	Headline            string
+	ServiceName         string
+	Stages              string
+	FailedAction        string
+	FailedActionSummary string
+	Deployment          string
+	ConsoleURL          string
+	AWSReference        string
+	AWSRegion           string
+	AWSAccount          string
+	EventTimestamp      string
+}
+
+func GetCodePipelineLevel(detailType string) string {
+	switch strings.ToLower(detailType) {
+	case "codepipeline pipeline execution state change":
+		return CodePipelineLevelPipeline
+	case "codepipeline stage execution state change":
+		return CodePipelineLevelStage
+	case "codepipeline action execution state change":
+		return CodePipelineLevelAction
+	}
+
+	return ""
+}
+
+func ParseCodePipelineDetails(request events.CloudWatchEvent) (CodePipelineInfo, error) {
+	var codePipelineInfo CodePipelineInfo
+
+	err := json.Unmarshal(request.Detail, &codePipelineInfo)
+
+	if err != nil {
+		return codePipelineInfo, WrapError("Unexpected error unmarshaling CodePipeline details", err)
+	}
+
+	if codePipelineInfo.Pipeline == "" {
+		return codePipelineInfo, WrapError("'pipeline' attribute not found on payload", nil)
+	}
+	if codePipelineInfo.ExecutionID == "" {
+		return codePipelineInfo, WrapError("'execution-id' attribute not found on payload", nil)
+	}
+	if codePipelineInfo.State == "" {
+		return codePipelineInfo, WrapError("'state' attribute not found on payload", nil)
+	}
+
+	return codePipelineInfo, nil
+}
+
+func GetTrackedCodePipelineEvents() []string {
+	// entries are "<level>:<state>", e.g. "ACTION:FAILED"
+	trackedEvents := GetStringEnv("CODEPIPELINE_EVENTS",
+		"PIPELINE:STARTED,PIPELINE:SUCCEEDED,PIPELINE:FAILED,PIPELINE:STOPPED,ACTION:FAILED")
+
+	var result []string
+	for _, eventName := range strings.Split(trackedEvents, ",") {
+		if trimmed := strings.ToUpper(strings.TrimSpace(eventName)); trimmed != "" {
+			result = append(result, trimmed)
+		}
+	}
+
+	return result
+}
+
+func IsTrackedCodePipelineEvent(level, state string) bool {
+	eventName := fmt.Sprintf("%s:%s", level, state)
+
+	for _, trackedEvent := range GetTrackedCodePipelineEvents() {
+		if trackedEvent == eventName {
+			return true
+		}
+	}
+
+	return false
+}
+
+func GetCodePipelineConsoleURL(region, pipeline, executionID string) string {
+	return fmt.Sprintf("https://%s.console.aws.amazon.com/codesuite/codepipeline/pipelines/%s/executions/%s/timeline?region=%s",
+		region, url.PathEscape(pipeline), url.PathEscape(executionID), region)
+}
+
+func ListCodePipelineActionExecutions(pipeline, executionID string,
+	client codepipelineiface.CodePipelineAPI) ([]*codepipeline.ActionExecutionDetail, error) {
+	var actionExecutions []*codepipeline.ActionExecutionDetail
+
+	input := &codepipeline.ListActionExecutionsInput{
+		PipelineName: aws.String(pipeline),
+		Filter: &codepipeline.ActionExecutionFilter{
+			PipelineExecutionId: aws.String(executionID),
+		},
+	}
+
+	err := client.ListActionExecutionsPages(input,
+		func(page *codepipeline.ListActionExecutionsOutput, lastPage bool) bool {
+			actionExecutions = append(actionExecutions, page.ActionExecutionDetails...)
+			return true
+		})
+
+	if err != nil {
+		return nil, fmt.Errorf("error listing action executions for pipeline '%s': %w", pipeline, err)
+	}
+
+	return actionExecutions, nil
+}
+
+func SummarizeCodePipelineExecution(actionExecutions []*codepipeline.ActionExecutionDetail) CodePipelineSummary {
+	// action executions come back newest first, the summary
+	// lists stages in the order the execution ran through them
+	sorted := make([]*codepipeline.ActionExecutionDetail, len(actionExecutions))
+	copy(sorted, actionExecutions)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return aws.TimeValue(sorted[i].StartTime).Before(aws.TimeValue(sorted[j].StartTime))
+	})
+
+	var summary CodePipelineSummary
+	stageIndex := make(map[string]int)
+
+	for _, actionExecution := range sorted {
+		stageName := aws.StringValue(actionExecution.StageName)
+		status := aws.StringValue(actionExecution.Status)
+
+		index, ok := stageIndex[stageName]
+		if !ok {
+			index = len(summary.Stages)
+			stageIndex[stageName] = index
+			summary.Stages = append(summary.Stages, CodePipelineStageSummary{Name: stageName, Status: status})
+		}
+
+		summary.Stages[index].Status = mergeCodePipelineStageStatus(summary.Stages[index].Status, status)
+
+		var executionResult codepipeline.ActionExecutionResult
+		if actionExecution.Output != nil && actionExecution.Output.ExecutionResult != nil {
+			executionResult = *actionExecution.Output.ExecutionResult
+		}
+
+		if status == codepipeline.ActionExecutionStatusFailed && summary.FailedAction == "" {
+			summary.FailedAction = fmt.Sprintf("%s/%s", stageName, aws.StringValue(actionExecution.ActionName))
+			summary.FailedActionSummary = aws.StringValue(executionResult.ExternalExecutionSummary)
+		}
+
+		if target, ok := getCodePipelineDeployTarget(actionExecution.Input); ok {
+			target.DeploymentID = aws.StringValue(executionResult.ExternalExecutionId)
+			target.Status = status
+			summary.DeployTargets = append(summary.DeployTargets, target)
+		}
+	}
+
+	return summary
+}
+
+func mergeCodePipelineStageStatus(current, next string) string {
+	// a stage is as bad as its worst action
+	for _, status := range []string{codepipeline.ActionExecutionStatusFailed, codepipeline.ActionExecutionStatusInProgress,
+		codepipeline.ActionExecutionStatusAbandoned} {
+		if current == status || next == status {
+			return status
+		}
+	}
+
+	return next
+}
+
+func getCodePipelineDeployTarget(input *codepipeline.ActionExecutionInput) (CodePipelineDeployTarget, bool) {
+	if input == nil || input.ActionTypeId == nil {
+		return CodePipelineDeployTarget{}, false
+	}
+
+	configuration := input.ResolvedConfiguration
+	if len(configuration) == 0 {
+		configuration = input.Configuration
+	}
+
+	provider := aws.StringValue(input.ActionTypeId.Provider)
+
+	switch provider {
+	case "ECS":
+		return CodePipelineDeployTarget{
+			Provider:    provider,
+			ClusterName: aws.StringValue(configuration["ClusterName"]),
+			ServiceName: aws.StringValue(configuration["ServiceName"]),
+		}, true
+	case "CodeDeployToECS":
+		return CodePipelineDeployTarget{
+			Provider:        provider,
+			Application:     aws.StringValue(configuration["ApplicationName"]),
+			DeploymentGroup: aws.StringValue(configuration["DeploymentGroupName"]),
+		}, true
+	}
+
+	return CodePipelineDeployTarget{}, false
+}
+
+func FormatCodePipelineStages(stages []CodePipelineStageSummary) string {
+	// the summary is placed inside a JSON string in the Slack
+	// template, so lines are joined with an escaped newline
+	var lines []string
+
+	for _, stage := range stages {
+		icon := ":large_blue_circle:"
+
+		switch stage.Status {
+		case codepipeline.ActionExecutionStatusSucceeded:
+			icon = ":white_check_mark:"
+		case codepipeline.ActionExecutionStatusFailed:
+			icon = ":x:"
+		case codepipeline.ActionExecutionStatusAbandoned:
+			icon = ":heavy_minus_sign:"
+		}
+
+		lines = append(lines, fmt.Sprintf("%s %s: %s", icon, stage.Name, stage.Status))
+	}
+
+	return strings.Join(lines, `\n`)
+}
+
+func GetCodePipelineHeadline(level string, codePipelineInfo CodePipelineInfo) string {
+	switch level {
+	case CodePipelineLevelStage:
+		return fmt.Sprintf("Stage `%s` of pipeline `%s` %s", codePipelineInfo.Stage,
+			codePipelineInfo.Pipeline, codePipelineInfo.State)
+	case CodePipelineLevelAction:
+		return fmt.Sprintf("Action `%s/%s` of pipeline `%s` %s", codePipelineInfo.Stage, codePipelineInfo.Action,
+			codePipelineInfo.Pipeline, codePipelineInfo.State)
+	}
+
+	return fmt.Sprintf("Pipeline `%s` %s", codePipelineInfo.Pipeline, codePipelineInfo.State)
+}
+
+func GenerateCodePipelineNotificationStruct(request events.CloudWatchEvent, codePipelineInfo CodePipelineInfo,
+	serviceName string, summary CodePipelineSummary) CodePipelineNotificationFields {
+	level := GetCodePipelineLevel(request.DetailType)
+
+	failedAction := summary.FailedAction
+	failedActionSummary := summary.FailedActionSummary
+
+	// a failed action event carries its own failure, which is
+	// fresher than whatever the action executions listed
+	if level == CodePipelineLevelAction && codePipelineInfo.State == "FAILED" {
+		failedAction = fmt.Sprintf("%s/%s", codePipelineInfo.Stage, codePipelineInfo.Action)
+		if codePipelineInfo.ExecutionResult.ExternalExecutionSummary != "" {
+			failedActionSummary = codePipelineInfo.ExecutionResult.ExternalExecutionSummary
+		}
+	}
+
+	return CodePipelineNotificationFields{
+		Pipeline:            codePipelineInfo.Pipeline,
+		ExecutionID:         codePipelineInfo.ExecutionID,
+		Level:               level,
+		State:               codePipelineInfo.State,
+		Stage:               codePipelineInfo.Stage,
+		Action:              codePipelineInfo.Action,
+		Headline:            GetCodePipelineHeadline(level, codePipelineInfo),
+		ServiceName:         serviceName,
+		Stages:              FormatCodePipelineStages(summary.Stages),
+		FailedAction:        failedAction,
+		FailedActionSummary: EscapeJSONString(failedActionSummary),
+		ConsoleURL:          GetCodePipelineConsoleURL(request.Region, codePipelineInfo.Pipeline, codePipelineInfo.ExecutionID),
+		AWSReference:        request.ID,
+		AWSRegion:           request.Region,
+		AWSAccount:          request.AccountID,
+		EventTimestamp:      request.Time.UTC().Format("2006-01-02T15:04:05Z"),
+	}
+}
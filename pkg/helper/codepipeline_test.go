@@ -0,0 +1,185 @@
+package helper_test
+
+import (
+	"deployment-notifications/pkg/helper"
+	"encoding/json"
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/codepipeline"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"testing"
+	"time"
+)
+
+const sampleCodePipelineActionEvent = `
+{
+   "version": "0",
+   "id": "CWE-event-id",
+   "detail-type": "CodePipeline Action Execution State Change",
+   "source": "aws.codepipeline",
+   "account": "111122223333",
+   "time": "2020-05-23T12:31:14Z",
+   "region": "us-west-2",
+   "resources": [
+        "arn:aws:codepipeline:us-west-2:111122223333:content-api"
+   ],
+   "detail": {
+        "pipeline": "content-api",
+        "execution-id": "01234567-0123-0123-0123-012345678901",
+        "stage": "Deploy",
+        "action": "DeployECS",
+        "state": "FAILED",
+        "region": "us-west-2",
+        "type": {
+            "owner": "AWS",
+            "category": "Deploy",
+            "provider": "ECS",
+            "version": "1"
+        },
+        "version": 3,
+        "execution-result": {
+            "external-execution-summary": "Service \"content-api\" did not stabilize",
+            "external-execution-id": "ecs-svc/1234567890123456789"
+        }
+   }
+}
+`
+
+func sampleActionExecutions() []*codepipeline.ActionExecutionDetail {
+	startTime := time.Date(2020, 5, 23, 12, 0, 0, 0, time.UTC)
+
+	// newest first, the way ListActionExecutions returns them
+	return []*codepipeline.ActionExecutionDetail{
+		{
+			StageName:  aws.String("Deploy"),
+			ActionName: aws.String("DeployECS"),
+			Status:     aws.String(codepipeline.ActionExecutionStatusFailed),
+			StartTime:  aws.Time(startTime.Add(10 * time.Minute)),
+			Input: &codepipeline.ActionExecutionInput{
+				ActionTypeId: &codepipeline.ActionTypeId{Provider: aws.String("ECS")},
+				Configuration: map[string]*string{
+					"ClusterName": aws.String("production"),
+					"ServiceName": aws.String("content-api"),
+				},
+			},
+			Output: &codepipeline.ActionExecutionOutput{
+				ExecutionResult: &codepipeline.ActionExecutionResult{
+					ExternalExecutionId:      aws.String("ecs-svc/1234567890123456789"),
+					ExternalExecutionSummary: aws.String("Deployment did not stabilize"),
+				},
+			},
+		},
+		{
+			StageName:  aws.String("Build"),
+			ActionName: aws.String("Build"),
+			Status:     aws.String(codepipeline.ActionExecutionStatusSucceeded),
+			StartTime:  aws.Time(startTime.Add(time.Minute)),
+		},
+		{
+			StageName:  aws.String("Source"),
+			ActionName: aws.String("Source"),
+			Status:     aws.String(codepipeline.ActionExecutionStatusSucceeded),
+			StartTime:  aws.Time(startTime),
+		},
+	}
+}
+
+func TestParseCodePipelineDetails(t *testing.T) {
+	var cloudwatchEvent events.CloudWatchEvent
+	err := json.Unmarshal([]byte(sampleCodePipelineActionEvent), &cloudwatchEvent)
+	assert.Nil(t, err)
+
+	assert.Equal(t, helper.CodePipelineLevelAction, helper.GetCodePipelineLevel(cloudwatchEvent.DetailType))
+	assert.Equal(t, helper.CodePipelineLevelPipeline,
+		helper.GetCodePipelineLevel("CodePipeline Pipeline Execution State Change"))
+	assert.Equal(t, helper.CodePipelineLevelStage, helper.GetCodePipelineLevel("CodePipeline Stage Execution State Change"))
+	assert.Equal(t, "", helper.GetCodePipelineLevel("CodePipeline Action Execution Whatever"))
+
+	codePipelineInfo, err := helper.ParseCodePipelineDetails(cloudwatchEvent)
+	assert.Nil(t, err)
+	assert.Equal(t, "content-api", codePipelineInfo.Pipeline)
+	assert.Equal(t, "Deploy", codePipelineInfo.Stage)
+	assert.Equal(t, "DeployECS", codePipelineInfo.Action)
+	assert.Equal(t, "ECS", codePipelineInfo.Type.Provider)
+	assert.Equal(t, "ecs-svc/1234567890123456789", codePipelineInfo.ExecutionResult.ExternalExecutionID)
+
+	cloudwatchEvent.Detail = json.RawMessage(`{"pipeline": "content-api", "state": "STARTED"}`)
+	_, err = helper.ParseCodePipelineDetails(cloudwatchEvent)
+	assert.NotNil(t, err)
+}
+
+func TestTrackedCodePipelineEvents(t *testing.T) {
+	currentEvents := os.Getenv("CODEPIPELINE_EVENTS")
+	defer os.Setenv("CODEPIPELINE_EVENTS", currentEvents)
+
+	os.Unsetenv("CODEPIPELINE_EVENTS")
+	assert.True(t, helper.IsTrackedCodePipelineEvent(helper.CodePipelineLevelPipeline, "SUCCEEDED"))
+	assert.True(t, helper.IsTrackedCodePipelineEvent(helper.CodePipelineLevelAction, "FAILED"))
+	assert.False(t, helper.IsTrackedCodePipelineEvent(helper.CodePipelineLevelStage, "FAILED"))
+	assert.False(t, helper.IsTrackedCodePipelineEvent(helper.CodePipelineLevelAction, "STARTED"))
+
+	os.Setenv("CODEPIPELINE_EVENTS", "stage:failed, PIPELINE:FAILED")
+	assert.True(t, helper.IsTrackedCodePipelineEvent(helper.CodePipelineLevelStage, "FAILED"))
+	assert.False(t, helper.IsTrackedCodePipelineEvent(helper.CodePipelineLevelPipeline, "SUCCEEDED"))
+}
+
+func TestSummarizeCodePipelineExecution(t *testing.T) {
+	summary := helper.SummarizeCodePipelineExecution(sampleActionExecutions())
+
+	assert.Equal(t, []helper.CodePipelineStageSummary{
+		{Name: "Source", Status: "Succeeded"},
+		{Name: "Build", Status: "Succeeded"},
+		{Name: "Deploy", Status: "Failed"},
+	}, summary.Stages)
+	assert.Equal(t, "Deploy/DeployECS", summary.FailedAction)
+	assert.Equal(t, "Deployment did not stabilize", summary.FailedActionSummary)
+	assert.Equal(t, []helper.CodePipelineDeployTarget{
+		{
+			Provider:     "ECS",
+			ClusterName:  "production",
+			ServiceName:  "content-api",
+			DeploymentID: "ecs-svc/1234567890123456789",
+			Status:       "Failed",
+		},
+	}, summary.DeployTargets)
+
+	assert.Equal(t, `:white_check_mark: Source: Succeeded\n:white_check_mark: Build: Succeeded\n:x: Deploy: Failed`,
+		helper.FormatCodePipelineStages(summary.Stages))
+}
+
+func TestCodePipelineNotification(t *testing.T) {
+	var cloudwatchEvent events.CloudWatchEvent
+	err := json.Unmarshal([]byte(sampleCodePipelineActionEvent), &cloudwatchEvent)
+	assert.Nil(t, err)
+
+	codePipelineInfo, _ := helper.ParseCodePipelineDetails(cloudwatchEvent)
+	summary := helper.SummarizeCodePipelineExecution(sampleActionExecutions())
+
+	slackStruct := helper.GenerateCodePipelineNotificationStruct(cloudwatchEvent, codePipelineInfo,
+		"production/content-api", summary)
+	assert.Equal(t, "Action `Deploy/DeployECS` of pipeline `content-api` FAILED", slackStruct.Headline)
+	assert.Equal(t, "Deploy/DeployECS", slackStruct.FailedAction)
+	assert.Equal(t, `Service \"content-api\" did not stabilize`, slackStruct.FailedActionSummary)
+	assert.Equal(t, "https://us-west-2.console.aws.amazon.com/codesuite/codepipeline/pipelines/content-api/"+
+		"executions/01234567-0123-0123-0123-012345678901/timeline?region=us-west-2", slackStruct.ConsoleURL)
+
+	slackStruct.Deployment = "content-api ecs-svc/1234567890123456789: FAILED, 0/2 tasks running"
+
+	parsedMessage, err := helper.GeneratePayload(helper.DefaultSlackCodePipelineTemplate, slackStruct, true)
+	assert.Nil(t, err)
+	assert.True(t, json.Valid([]byte(parsedMessage)))
+	assert.Contains(t, parsedMessage, `"color":"#D00000"`)
+	assert.Contains(t, parsedMessage, `"title":"Failed Action"`)
+	assert.Contains(t, parsedMessage, `"title":"ECS Deployment"`)
+
+	codePipelineInfo.State = "STARTED"
+	slackStruct = helper.GenerateCodePipelineNotificationStruct(cloudwatchEvent, codePipelineInfo,
+		"", helper.CodePipelineSummary{})
+
+	parsedMessage, err = helper.GeneratePayload(helper.DefaultSlackCodePipelineTemplate, slackStruct, true)
+	assert.Nil(t, err)
+	assert.True(t, json.Valid([]byte(parsedMessage)))
+	assert.NotContains(t, parsedMessage, `"title":"Failed Action"`)
+	assert.NotContains(t, parsedMessage, `"title":"Stages"`)
+}
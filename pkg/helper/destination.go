@@ -0,0 +1,161 @@
+package helper
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// the hosts each sink may send to unless ALLOWED_HOSTS_<SINK> is set
+var defaultAllowedHosts = map[string][]string{
+	SinkSlack:    {"hooks.slack.com"},
+	SinkNewRelic: {"api.newrelic.com", "api.*.newrelic.com"},
+}
+
+// GetAllowedHosts returns the destinations of a sink, read from e.g.
+// ALLOWED_HOSTS_SLACK="hooks.slack.com,*.example.com". An entry is a host
+// where '*' stands for one label. Only HTTPS is sent to any of them
+func GetAllowedHosts(sink string) []string {
+	allowedHosts := GetStringEnv(fmt.Sprintf("ALLOWED_HOSTS_%s", strings.ToUpper(sink)), "")
+
+	if strings.TrimSpace(allowedHosts) == "" {
+		return defaultAllowedHosts[sink]
+	}
+
+	var result []string
+	for _, host := range strings.Split(allowedHosts, ",") {
+		if trimmed := strings.ToLower(strings.TrimSpace(host)); trimmed != "" {
+			result = append(result, trimmed)
+		}
+	}
+
+	return result
+}
+
+func matchHost(pattern, host string) bool {
+	patternLabels := strings.Split(pattern, ".")
+	hostLabels := strings.Split(host, ".")
+
+	if len(patternLabels) != len(hostLabels) {
+		return false
+	}
+
+	for i, label := range patternLabels {
+		if label != "*" && label != hostLabels[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// CheckDestination tells whether a sink may send to rawURL. The error
+// names the rule that was broken and only quotes the scheme and host, as
+// the path of a webhook is its secret
+func CheckDestination(sink, rawURL string) error {
+	destination, err := url.Parse(rawURL)
+	if err != nil || destination.Host == "" {
+		return ConfigurationError(fmt.Sprintf("Destination of %s is not a URL", sink), nil)
+	}
+
+	scheme := strings.ToLower(destination.Scheme)
+	host := strings.ToLower(destination.Hostname())
+
+	if scheme != "https" {
+		return ConfigurationError(fmt.Sprintf("Destination '%s://%s' of %s is not HTTPS", scheme, host, sink), nil)
+	}
+
+	for _, allowed := range GetAllowedHosts(sink) {
+		if matchHost(allowed, host) {
+			return nil
+		}
+	}
+
+	return ConfigurationError(fmt.Sprintf("Host '%s' is not an allowed destination of %s, allowed are: %s",
+		host, sink, strings.Join(GetAllowedHosts(sink), ", ")), nil)
+}
+
+// the addresses no sink should be reached at, whatever DNS answers:
+// loopback, private, shared, link-local (e.g. the instance metadata
+// endpoint), unspecified and multicast
+var blockedNetworks = parseNetworks(
+	"0.0.0.0/8", "10.0.0.0/8", "100.64.0.0/10", "127.0.0.0/8", "169.254.0.0/16", "172.16.0.0/12",
+	"192.0.0.0/24", "192.168.0.0/16", "198.18.0.0/15", "224.0.0.0/4", "240.0.0.0/4",
+	"::/128", "::1/128", "fc00::/7", "fe80::/10", "ff00::/8",
+)
+
+func parseNetworks(cidrs ...string) []*net.IPNet {
+	var networks []*net.IPNet
+
+	for _, cidr := range cidrs {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+
+		networks = append(networks, network)
+	}
+
+	return networks
+}
+
+func IsBlockedIP(ip net.IP) bool {
+	// IPv4 addresses mapped into IPv6 are checked as IPv4
+	if ipv4 := ip.To4(); ipv4 != nil {
+		ip = ipv4
+	}
+
+	for _, network := range blockedNetworks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// checkDialAddress runs once the host name has been resolved, so a DNS
+// name pointing at an internal address is caught as well
+func checkDialAddress(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+
+	if ip := net.ParseIP(host); ip == nil || IsBlockedIP(ip) {
+		return ConfigurationError(fmt.Sprintf("Connecting to internal address '%s' is not allowed", host), nil)
+	}
+
+	return nil
+}
+
+// NewHTTPClient returns the client the sinks are sent to by default. It
+// refuses to connect to internal addresses and does not follow redirects,
+// which could lead anywhere. Proxies are not used, the address checked
+// would be the proxy's
+func NewHTTPClient() *http.Client {
+	dialer := &net.Dialer{
+		Timeout:   10 * time.Second,
+		KeepAlive: 30 * time.Second,
+		Control:   checkDialAddress,
+	}
+
+	return &http.Client{
+		Timeout: time.Second * time.Duration(GetDefaultHTTPTimeout()),
+		Transport: &http.Transport{
+			DialContext:           dialer.DialContext,
+			ForceAttemptHTTP2:     true,
+			MaxIdleConns:          10,
+			IdleConnTimeout:       90 * time.Second,
+			TLSHandshakeTimeout:   10 * time.Second,
+			ExpectContinueTimeout: time.Second,
+		},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
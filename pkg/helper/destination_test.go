@@ -0,0 +1,92 @@
+package helper_test
+
+import (
+	"context"
+	"deployment-notifications/pkg/helper"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckDestinationDefaults(t *testing.T) {
+	assert.Nil(t, helper.CheckDestination(helper.SinkSlack, "https://hooks.slack.com/services/T0/B0/X"))
+	assert.Nil(t, helper.CheckDestination(helper.SinkNewRelic,
+		"https://api.newrelic.com/v2/applications/1/deployments.json"))
+	assert.Nil(t, helper.CheckDestination(helper.SinkNewRelic,
+		"https://api.eu.newrelic.com/v2/applications/1/deployments.json"))
+
+	err := helper.CheckDestination(helper.SinkSlack, "http://hooks.slack.com/services/T0/B0/X")
+	assert.Contains(t, err.Error(), "is not HTTPS")
+	assert.Equal(t, helper.ErrorKindConfiguration, helper.GetErrorKind(err))
+
+	err = helper.CheckDestination(helper.SinkSlack, "https://169.254.169.254/latest/meta-data/")
+	assert.Contains(t, err.Error(), "Host '169.254.169.254' is not an allowed destination of slack")
+
+	// '*' stands for exactly one label
+	assert.NotNil(t, helper.CheckDestination(helper.SinkNewRelic, "https://api.newrelic.com.evil.com/"))
+	assert.NotNil(t, helper.CheckDestination(helper.SinkNewRelic, "https://api.a.b.newrelic.com/"))
+	assert.NotNil(t, helper.CheckDestination(helper.SinkSlack, "hooks.slack.com/services/T0/B0/X"))
+}
+
+func TestCheckDestinationRedactsWebhook(t *testing.T) {
+	err := helper.CheckDestination(helper.SinkSlack, "http://hooks.slack.com/services/T0/B0/XXXXXXXXXXXXXXXXXXXXXXXX")
+	assert.NotContains(t, err.Error(), "XXXXXXXXXXXXXXXXXXXXXXXX")
+}
+
+func TestCheckDestinationAllowedHosts(t *testing.T) {
+	os.Setenv("ALLOWED_HOSTS_SLACK", "hooks.example.com, *.chat.example.com,localhost")
+	defer os.Unsetenv("ALLOWED_HOSTS_SLACK")
+
+	assert.Equal(t, []string{"hooks.example.com", "*.chat.example.com", "localhost"},
+		helper.GetAllowedHosts(helper.SinkSlack))
+
+	assert.Nil(t, helper.CheckDestination(helper.SinkSlack, "https://hooks.example.com/x"))
+	assert.Nil(t, helper.CheckDestination(helper.SinkSlack, "https://team.chat.example.com/x"))
+	assert.NotNil(t, helper.CheckDestination(helper.SinkSlack, "https://hooks.slack.com/services/T0/B0/X"))
+
+	// an allowed host is still only reached over HTTPS
+	err := helper.CheckDestination(helper.SinkSlack, "http://hooks.example.com/x")
+	assert.Contains(t, err.Error(), "is not HTTPS")
+	assert.NotNil(t, helper.CheckDestination(helper.SinkSlack, "http://localhost:8080/x"))
+}
+
+func TestCheckDestinationSchemeEntries(t *testing.T) {
+	// entries name hosts, a scheme does not let plain HTTP through
+	os.Setenv("ALLOWED_HOSTS_SLACK", "http://localhost")
+	defer os.Unsetenv("ALLOWED_HOSTS_SLACK")
+
+	assert.NotNil(t, helper.CheckDestination(helper.SinkSlack, "http://localhost/x"))
+	assert.NotNil(t, helper.CheckDestination(helper.SinkSlack, "https://localhost/x"))
+}
+
+func TestIsBlockedIP(t *testing.T) {
+	blocked := []string{"127.0.0.1", "10.1.2.3", "172.31.255.255", "192.168.0.10", "169.254.169.254",
+		"100.64.0.1", "0.0.0.0", "::1", "fd00::1", "fe80::1", "::ffff:169.254.169.254"}
+	for _, address := range blocked {
+		assert.True(t, helper.IsBlockedIP(net.ParseIP(address)), address)
+	}
+
+	allowed := []string{"52.94.236.248", "34.120.0.1", "172.32.0.1", "2600:1f18::1"}
+	for _, address := range allowed {
+		assert.False(t, helper.IsBlockedIP(net.ParseIP(address)), address)
+	}
+}
+
+func TestNewHTTPClientRefusesInternalAddresses(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, server.URL, nil)
+	assert.Nil(t, err)
+
+	_, err = helper.NewHTTPClient().Do(req)
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "Connecting to internal address '127.0.0.1' is not allowed")
+	assert.Equal(t, helper.ErrorKindConfiguration, helper.GetErrorKind(err))
+}
@@ -0,0 +1,175 @@
+package helper
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+type DORAMetrics struct {
+	Group                   string
+	Deployments             int
+	FailedDeployments       int
+	DeploymentsPerDay       float64
+	ChangeFailureRate       float64
+	MeanTimeToRestore       time.Duration
+	RestoredFailures        int
+	UnrestoredFailures      int
+	WindowDays              float64
+	LastDeploymentTimestamp string
+}
+
+func GetDORAGroup(serviceName string, groupBy string, teamMap map[string]string) string {
+	if groupBy != "team" {
+		return serviceName
+	}
+
+	team, ok := teamMap[serviceName]
+	if !ok || team == "" {
+		return "unassigned"
+	}
+
+	return team
+}
+
+func ComputeDORAMetrics(records []DeploymentRecord, windowStart, windowEnd time.Time,
+	groupBy string, teamMap map[string]string) []DORAMetrics {
+	// deployment frequency counts successful deployments per day of the window,
+	// change failure rate is failed over all recorded outcomes, and time to
+	// restore is measured per service from a failure to the next success or
+	// rollback. Rollbacks restore service but are not counted as deployments
+
+	windowDays := windowEnd.Sub(windowStart).Hours() / 24
+	if windowDays <= 0 {
+		windowDays = 1
+	}
+
+	serviceRecords := make(map[string][]DeploymentRecord)
+	for _, record := range records {
+		serviceRecords[record.ServiceName] = append(serviceRecords[record.ServiceName], record)
+	}
+
+	groupMetrics := make(map[string]*DORAMetrics)
+	groupRestoreTotal := make(map[string]time.Duration)
+
+	for serviceName, serviceHistory := range serviceRecords {
+		group := GetDORAGroup(serviceName, groupBy, teamMap)
+
+		metrics, ok := groupMetrics[group]
+		if !ok {
+			metrics = &DORAMetrics{Group: group, WindowDays: windowDays}
+			groupMetrics[group] = metrics
+		}
+
+		sort.Slice(serviceHistory, func(i, j int) bool {
+			return serviceHistory[i].Time().Before(serviceHistory[j].Time())
+		})
+
+		var failedSince time.Time
+
+		for _, record := range serviceHistory {
+			if record.Timestamp > metrics.LastDeploymentTimestamp {
+				metrics.LastDeploymentTimestamp = record.Timestamp
+			}
+
+			if record.Outcome == DeploymentOutcomeSuccess || record.Outcome == DeploymentOutcomeRollback {
+				if record.Outcome == DeploymentOutcomeSuccess {
+					metrics.Deployments++
+				} else if failedSince.IsZero() {
+					// the failure that caused the rollback was not recorded
+					metrics.FailedDeployments++
+				}
+
+				if !failedSince.IsZero() {
+					groupRestoreTotal[group] += record.Time().Sub(failedSince)
+					metrics.RestoredFailures++
+					failedSince = time.Time{}
+				}
+				continue
+			}
+
+			metrics.FailedDeployments++
+			if failedSince.IsZero() {
+				failedSince = record.Time()
+			}
+		}
+
+		if !failedSince.IsZero() {
+			metrics.UnrestoredFailures++
+		}
+	}
+
+	result := make([]DORAMetrics, 0, len(groupMetrics))
+
+	for group, metrics := range groupMetrics {
+		metrics.DeploymentsPerDay = float64(metrics.Deployments) / windowDays
+
+		total := metrics.Deployments + metrics.FailedDeployments
+		if total > 0 {
+			metrics.ChangeFailureRate = float64(metrics.FailedDeployments) / float64(total) * 100
+		}
+
+		if metrics.RestoredFailures > 0 {
+			metrics.MeanTimeToRestore = groupRestoreTotal[group] / time.Duration(metrics.RestoredFailures)
+		}
+
+		result = append(result, *metrics)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Group < result[j].Group
+	})
+
+	return result
+}
+
+func FormatDORADuration(duration time.Duration) string {
+	if duration == 0 {
+		return "n/a"
+	}
+
+	return duration.Round(time.Minute).String()
+}
+
+func GenerateDORADigestPayload(metrics []DORAMetrics, windowStart, windowEnd time.Time) (string, error) {
+	var digest strings.Builder
+
+	digest.WriteString(fmt.Sprintf("*DORA metrics digest* (%s to %s)\n",
+		windowStart.UTC().Format("2006-01-02"), windowEnd.UTC().Format("2006-01-02")))
+
+	if len(metrics) == 0 {
+		digest.WriteString("No deployments were recorded in this window.")
+	}
+
+	for _, groupMetrics := range metrics {
+		digest.WriteString(fmt.Sprintf("\n*%s*: %d deployments (%.2f/day), change failure rate %.1f%%, "+
+			"mean time to restore %s",
+			groupMetrics.Group, groupMetrics.Deployments, groupMetrics.DeploymentsPerDay,
+			groupMetrics.ChangeFailureRate, FormatDORADuration(groupMetrics.MeanTimeToRestore)))
+
+		if groupMetrics.UnrestoredFailures > 0 {
+			digest.WriteString(fmt.Sprintf(", %d failure(s) not yet restored", groupMetrics.UnrestoredFailures))
+		}
+	}
+
+	payload, err := json.Marshal(map[string]string{"text": digest.String()})
+
+	if err != nil {
+		return "", WrapError("Error marshaling DORA digest payload", err)
+	}
+
+	return string(payload), nil
+}
+
+func GetDeploymentOutcome(eventName string) (string, bool) {
+	switch eventName {
+	case "SERVICE_DEPLOYMENT_COMPLETED":
+		return DeploymentOutcomeSuccess, true
+	case "SERVICE_DEPLOYMENT_FAILED":
+		return DeploymentOutcomeFailure, true
+	}
+
+	return "", false
+}
@@ -0,0 +1,138 @@
+package helper_test
+
+import (
+	"deployment-notifications/pkg/helper"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func doraRecord(serviceName, outcome, timestamp string) helper.DeploymentRecord {
+	parsedTime, _ := time.Parse(time.RFC3339, timestamp)
+	return helper.NewDeploymentRecord(serviceName, "ecs-svc/"+timestamp, outcome, parsedTime,
+		"event-id", "111122223333", "us-west-2")
+}
+
+func TestDeploymentOutcome(t *testing.T) {
+	outcome, ok := helper.GetDeploymentOutcome("SERVICE_DEPLOYMENT_COMPLETED")
+	assert.True(t, ok)
+	assert.Equal(t, helper.DeploymentOutcomeSuccess, outcome)
+
+	outcome, ok = helper.GetDeploymentOutcome("SERVICE_DEPLOYMENT_FAILED")
+	assert.True(t, ok)
+	assert.Equal(t, helper.DeploymentOutcomeFailure, outcome)
+
+	_, ok = helper.GetDeploymentOutcome("SERVICE_DEPLOYMENT_IN_PROGRESS")
+	assert.False(t, ok)
+}
+
+func TestComputeDORAMetricsPerService(t *testing.T) {
+	windowStart, _ := time.Parse(time.RFC3339, "2020-05-16T00:00:00Z")
+	windowEnd, _ := time.Parse(time.RFC3339, "2020-05-23T00:00:00Z")
+
+	records := []helper.DeploymentRecord{
+		doraRecord("content-api", helper.DeploymentOutcomeSuccess, "2020-05-17T10:00:00Z"),
+		doraRecord("content-api", helper.DeploymentOutcomeSuccess, "2020-05-20T12:00:00Z"),
+		doraRecord("content-api", helper.DeploymentOutcomeFailure, "2020-05-18T10:00:00Z"),
+		doraRecord("content-api", helper.DeploymentOutcomeSuccess, "2020-05-18T11:30:00Z"),
+		doraRecord("auth-api", helper.DeploymentOutcomeFailure, "2020-05-22T10:00:00Z"),
+	}
+
+	metrics := helper.ComputeDORAMetrics(records, windowStart, windowEnd, "service", nil)
+
+	assert.Equal(t, 2, len(metrics))
+
+	assert.Equal(t, "auth-api", metrics[0].Group)
+	assert.Equal(t, 0, metrics[0].Deployments)
+	assert.Equal(t, 1, metrics[0].FailedDeployments)
+	assert.Equal(t, float64(100), metrics[0].ChangeFailureRate)
+	assert.Equal(t, 1, metrics[0].UnrestoredFailures)
+	assert.Equal(t, time.Duration(0), metrics[0].MeanTimeToRestore)
+
+	assert.Equal(t, "content-api", metrics[1].Group)
+	assert.Equal(t, 3, metrics[1].Deployments)
+	assert.Equal(t, 1, metrics[1].FailedDeployments)
+	assert.InDelta(t, 3.0/7.0, metrics[1].DeploymentsPerDay, 0.0001)
+	assert.Equal(t, float64(25), metrics[1].ChangeFailureRate)
+	assert.Equal(t, 90*time.Minute, metrics[1].MeanTimeToRestore)
+	assert.Equal(t, "2020-05-20T12:00:00Z", metrics[1].LastDeploymentTimestamp)
+}
+
+func TestComputeDORAMetricsPerTeam(t *testing.T) {
+	windowStart, _ := time.Parse(time.RFC3339, "2020-05-16T00:00:00Z")
+	windowEnd, _ := time.Parse(time.RFC3339, "2020-05-23T00:00:00Z")
+
+	records := []helper.DeploymentRecord{
+		doraRecord("content-api", helper.DeploymentOutcomeSuccess, "2020-05-17T10:00:00Z"),
+		doraRecord("auth-api", helper.DeploymentOutcomeSuccess, "2020-05-18T10:00:00Z"),
+		doraRecord("billing-api", helper.DeploymentOutcomeSuccess, "2020-05-19T10:00:00Z"),
+	}
+
+	teamMap := map[string]string{
+		"content-api": "platform",
+		"auth-api":    "platform",
+	}
+
+	metrics := helper.ComputeDORAMetrics(records, windowStart, windowEnd, "team", teamMap)
+
+	assert.Equal(t, 2, len(metrics))
+	assert.Equal(t, "platform", metrics[0].Group)
+	assert.Equal(t, 2, metrics[0].Deployments)
+	assert.Equal(t, "unassigned", metrics[1].Group)
+	assert.Equal(t, 1, metrics[1].Deployments)
+}
+
+func TestDORADigestPayload(t *testing.T) {
+	windowStart, _ := time.Parse(time.RFC3339, "2020-05-16T00:00:00Z")
+	windowEnd, _ := time.Parse(time.RFC3339, "2020-05-23T00:00:00Z")
+
+	metrics := []helper.DORAMetrics{
+		{
+			Group:              "content-api",
+			Deployments:        3,
+			FailedDeployments:  1,
+			DeploymentsPerDay:  3.0 / 7.0,
+			ChangeFailureRate:  25,
+			MeanTimeToRestore:  90 * time.Minute,
+			UnrestoredFailures: 1,
+		},
+	}
+
+	payload, err := helper.GenerateDORADigestPayload(metrics, windowStart, windowEnd)
+	assert.Nil(t, err)
+
+	decoded := make(map[string]string)
+	assert.Nil(t, json.Unmarshal([]byte(payload), &decoded))
+	assert.Equal(t, "*DORA metrics digest* (2020-05-16 to 2020-05-23)\n"+
+		"\n*content-api*: 3 deployments (0.43/day), change failure rate 25.0%, mean time to restore 1h30m0s"+
+		", 1 failure(s) not yet restored", decoded["text"])
+
+	payload, err = helper.GenerateDORADigestPayload(nil, windowStart, windowEnd)
+	assert.Nil(t, err)
+	assert.Contains(t, payload, "No deployments were recorded in this window.")
+}
+
+func TestComputeDORAMetricsRollback(t *testing.T) {
+	windowStart, _ := time.Parse(time.RFC3339, "2020-05-16T00:00:00Z")
+	windowEnd, _ := time.Parse(time.RFC3339, "2020-05-23T00:00:00Z")
+
+	records := []helper.DeploymentRecord{
+		doraRecord("content-api", helper.DeploymentOutcomeFailure, "2020-05-18T10:00:00Z"),
+		doraRecord("content-api", helper.DeploymentOutcomeRollback, "2020-05-18T10:20:00Z"),
+		doraRecord("auth-api", helper.DeploymentOutcomeRollback, "2020-05-19T10:00:00Z"),
+	}
+
+	metrics := helper.ComputeDORAMetrics(records, windowStart, windowEnd, "service", nil)
+
+	assert.Equal(t, "auth-api", metrics[0].Group)
+	assert.Equal(t, 0, metrics[0].Deployments)
+	assert.Equal(t, 1, metrics[0].FailedDeployments)
+	assert.Equal(t, 0, metrics[0].UnrestoredFailures)
+
+	assert.Equal(t, "content-api", metrics[1].Group)
+	assert.Equal(t, 0, metrics[1].Deployments)
+	assert.Equal(t, 1, metrics[1].FailedDeployments)
+	assert.Equal(t, 1, metrics[1].RestoredFailures)
+	assert.Equal(t, 20*time.Minute, metrics[1].MeanTimeToRestore)
+}
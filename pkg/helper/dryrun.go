@@ -0,0 +1,109 @@
+package helper
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+const (
+	SinkNewRelic = "newrelic"
+	SinkSlack    = "slack"
+)
+
+const (
+	StoreDynamoDB   = "dynamodb"
+	StoreCloudWatch = "cloudwatch"
+)
+
+// DryRunDelivery is a notification a sink would have sent
+type DryRunDelivery struct {
+	Sink    string          `json:"sink"`
+	Target  string          `json:"target"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+	Valid   bool            `json:"valid"`
+	Error   string          `json:"error,omitempty"`
+}
+
+// DryRunWrite is an item a store would have written
+type DryRunWrite struct {
+	Store  string          `json:"store"`
+	Target string          `json:"target"`
+	Item   json.RawMessage `json:"item,omitempty"`
+}
+
+func GetDryRunSinks() []string {
+	// DRY_RUN is either "true" for every sink, or a comma
+	// separated list of the sinks to dry run, e.g. "slack"
+	dryRun := strings.ToLower(strings.TrimSpace(GetStringEnv("DRY_RUN", "false")))
+
+	switch dryRun {
+	case "false", "0", "":
+		return nil
+	case "true", "1", "all":
+		return []string{SinkNewRelic, SinkSlack}
+	}
+
+	var result []string
+	for _, sink := range strings.Split(dryRun, ",") {
+		if trimmed := strings.TrimSpace(sink); trimmed != "" {
+			result = append(result, trimmed)
+		}
+	}
+
+	return result
+}
+
+func IsDryRun(sink string) bool {
+	for _, dryRunSink := range GetDryRunSinks() {
+		if dryRunSink == sink {
+			return true
+		}
+	}
+
+	return false
+}
+
+func IsAnyDryRun() bool {
+	return len(GetDryRunSinks()) > 0
+}
+
+func IsDryRunWrite() bool {
+	// records written while trying out events would be counted in the
+	// DORA metrics and crash loops, so any dry run skips the stores
+	return IsAnyDryRun()
+}
+
+func ValidateNewRelicDeployment(payload map[string]string, appID, apiKey string) error {
+	// the same checks the deployments API applies, short of the key being valid
+	switch {
+	case appID == "":
+		return WrapError("New Relic application ID is empty", nil)
+	case strings.Trim(appID, "0123456789") != "":
+		return WrapError(fmt.Sprintf("New Relic application ID '%s' is not numeric", appID), nil)
+	case apiKey == "":
+		return WrapError("New Relic API key is empty", nil)
+	case payload["revision"] == "":
+		return WrapError("New Relic deployment revision is empty", nil)
+	}
+
+	return nil
+}
+
+func ValidateSlackPayload(payload string) error {
+	// a webhook accepts any JSON object with something to display
+	var message map[string]json.RawMessage
+
+	err := json.Unmarshal([]byte(payload), &message)
+	if err != nil {
+		return WrapError("Slack payload is not a JSON object", err)
+	}
+
+	for _, field := range []string{"text", "blocks", "attachments"} {
+		if _, ok := message[field]; ok {
+			return nil
+		}
+	}
+
+	return WrapError("Slack payload has none of 'text', 'blocks' or 'attachments'", nil)
+}
@@ -0,0 +1,49 @@
+package helper_test
+
+import (
+	"deployment-notifications/pkg/helper"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"testing"
+)
+
+func TestGetDryRunSinks(t *testing.T) {
+	assert.Nil(t, helper.GetDryRunSinks())
+	assert.False(t, helper.IsAnyDryRun())
+
+	os.Setenv("DRY_RUN", "true")
+	defer os.Unsetenv("DRY_RUN")
+	assert.Equal(t, []string{helper.SinkNewRelic, helper.SinkSlack}, helper.GetDryRunSinks())
+	assert.True(t, helper.IsDryRun(helper.SinkNewRelic))
+	assert.True(t, helper.IsDryRun(helper.SinkSlack))
+
+	os.Setenv("DRY_RUN", " Slack ")
+	assert.Equal(t, []string{helper.SinkSlack}, helper.GetDryRunSinks())
+	assert.False(t, helper.IsDryRun(helper.SinkNewRelic))
+	assert.True(t, helper.IsDryRun(helper.SinkSlack))
+	assert.True(t, helper.IsAnyDryRun())
+	// a dry run of any sink leaves the stores alone
+	assert.True(t, helper.IsDryRunWrite())
+
+	os.Setenv("DRY_RUN", "false")
+	assert.False(t, helper.IsAnyDryRun())
+	assert.False(t, helper.IsDryRunWrite())
+}
+
+func TestValidateNewRelicDeployment(t *testing.T) {
+	payload := map[string]string{"revision": "ecs-svc/123"}
+
+	assert.Nil(t, helper.ValidateNewRelicDeployment(payload, "123456", "NRAK-KEY"))
+	assert.NotNil(t, helper.ValidateNewRelicDeployment(payload, "", "NRAK-KEY"))
+	assert.NotNil(t, helper.ValidateNewRelicDeployment(payload, "my-app", "NRAK-KEY"))
+	assert.NotNil(t, helper.ValidateNewRelicDeployment(payload, "123456", ""))
+	assert.NotNil(t, helper.ValidateNewRelicDeployment(map[string]string{}, "123456", "NRAK-KEY"))
+}
+
+func TestValidateSlackPayload(t *testing.T) {
+	assert.Nil(t, helper.ValidateSlackPayload(`{"text": "deployed"}`))
+	assert.Nil(t, helper.ValidateSlackPayload(`{"attachments": []}`))
+	assert.NotNil(t, helper.ValidateSlackPayload(`{"text": "deployed",}`))
+	assert.NotNil(t, helper.ValidateSlackPayload(`["deployed"]`))
+	assert.NotNil(t, helper.ValidateSlackPayload(`{"username": "deploy-bot"}`))
+}
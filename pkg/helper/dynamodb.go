@@ -0,0 +1,230 @@
+package helper
+
+import (
+	"errors"
+	"fmt"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+	"time"
+)
+
+const (
+	DeploymentOutcomeSuccess  = "success"
+	DeploymentOutcomeFailure  = "failure"
+	DeploymentOutcomeRollback = "rollback"
+)
+
+// DeploymentRecord is a single processed deployment outcome as stored
+// in the DORA table. The table is keyed on ServiceName (hash) and
+// RecordKey (range), where RecordKey is "<timestamp>#<deploymentId>"
+// so that records for a service sort chronologically
+type DeploymentRecord struct {
+	ServiceName  string `dynamodbav:"ServiceName"`
+	RecordKey    string `dynamodbav:"RecordKey"`
+	DeploymentID string `dynamodbav:"DeploymentID"`
+	Outcome      string `dynamodbav:"Outcome"`
+	Timestamp    string `dynamodbav:"Timestamp"`
+	EventID      string `dynamodbav:"EventID"`
+	AWSAccount   string `dynamodbav:"AWSAccount"`
+	AWSRegion    string `dynamodbav:"AWSRegion"`
+}
+
+func NewDeploymentRecord(serviceName, deploymentID, outcome string, timestamp time.Time,
+	eventID, account, region string) DeploymentRecord {
+	formattedTimestamp := timestamp.UTC().Format(time.RFC3339)
+
+	return DeploymentRecord{
+		ServiceName:  serviceName,
+		RecordKey:    fmt.Sprintf("%s#%s", formattedTimestamp, deploymentID),
+		DeploymentID: deploymentID,
+		Outcome:      outcome,
+		Timestamp:    formattedTimestamp,
+		EventID:      eventID,
+		AWSAccount:   account,
+		AWSRegion:    region,
+	}
+}
+
+func (record DeploymentRecord) Time() time.Time {
+	parsedTime, _ := time.Parse(time.RFC3339, record.Timestamp)
+	return parsedTime
+}
+
+func PutDeploymentRecord(tableName string, record DeploymentRecord, client dynamodbiface.DynamoDBAPI) error {
+	item, err := dynamodbattribute.MarshalMap(record)
+	if err != nil {
+		return WrapError("Error marshaling deployment record", err)
+	}
+
+	_, err = client.PutItem(&dynamodb.PutItemInput{
+		TableName: aws.String(tableName),
+		Item:      item,
+	})
+
+	if err != nil {
+		return fmt.Errorf("error writing deployment record to table '%s': %w", tableName, err)
+	}
+
+	return nil
+}
+
+// TaskStopRecord is a stopped task counted towards crash loop detection.
+// The table is keyed on ServiceName (hash) and StopKey (range), where
+// StopKey is "<stoppedAt>#<taskArn>" so repeated events for the same task
+// are only counted once. ExpiresAt can be enabled as the table TTL
+type TaskStopRecord struct {
+	ServiceName string `dynamodbav:"ServiceName"`
+	StopKey     string `dynamodbav:"StopKey"`
+	TaskARN     string `dynamodbav:"TaskARN"`
+	StoppedAt   string `dynamodbav:"StoppedAt"`
+	ExpiresAt   int64  `dynamodbav:"ExpiresAt"`
+}
+
+func NewTaskStopRecord(serviceName, taskARN string, stoppedAt time.Time, retention time.Duration) TaskStopRecord {
+	formattedStoppedAt := stoppedAt.UTC().Format(time.RFC3339)
+
+	return TaskStopRecord{
+		ServiceName: serviceName,
+		StopKey:     fmt.Sprintf("%s#%s", formattedStoppedAt, taskARN),
+		TaskARN:     taskARN,
+		StoppedAt:   formattedStoppedAt,
+		ExpiresAt:   stoppedAt.Add(retention).Unix(),
+	}
+}
+
+func PutTaskStopRecord(tableName string, record TaskStopRecord, client dynamodbiface.DynamoDBAPI) error {
+	item, err := dynamodbattribute.MarshalMap(record)
+	if err != nil {
+		return WrapError("Error marshaling task stop record", err)
+	}
+
+	_, err = client.PutItem(&dynamodb.PutItemInput{
+		TableName: aws.String(tableName),
+		Item:      item,
+	})
+
+	if err != nil {
+		return fmt.Errorf("error writing task stop record to table '%s': %w", tableName, err)
+	}
+
+	return nil
+}
+
+func CountTaskStops(tableName, serviceName string, since time.Time, client dynamodbiface.DynamoDBAPI) (int, error) {
+	count := 0
+
+	input := &dynamodb.QueryInput{
+		TableName:              aws.String(tableName),
+		KeyConditionExpression: aws.String("ServiceName = :service AND StopKey >= :since"),
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":service": {S: aws.String(serviceName)},
+			":since":   {S: aws.String(since.UTC().Format(time.RFC3339))},
+		},
+		Select: aws.String(dynamodb.SelectCount),
+	}
+
+	err := client.QueryPages(input, func(page *dynamodb.QueryOutput, lastPage bool) bool {
+		count += int(aws.Int64Value(page.Count))
+		return true
+	})
+
+	if err != nil {
+		return 0, fmt.Errorf("error counting task stops in table '%s': %w", tableName, err)
+	}
+
+	return count, nil
+}
+
+// CrashLoopAlertRecord marks a crash loop as alerted. It is stored in the
+// crash loop table under "<serviceName>#alerted", apart from the stops
+// counted for the service, with AlertKey the start of the window the
+// alert was sent in. ExpiresAt can be enabled as the table TTL
+type CrashLoopAlertRecord struct {
+	ServiceName string `dynamodbav:"ServiceName"`
+	AlertKey    string `dynamodbav:"StopKey"`
+	AlertedAt   string `dynamodbav:"AlertedAt"`
+	ExpiresAt   int64  `dynamodbav:"ExpiresAt"`
+}
+
+func NewCrashLoopAlertRecord(serviceName string, alertedAt time.Time, window time.Duration) CrashLoopAlertRecord {
+	// windows are aligned to multiples of their length, so every
+	// stop in the same window names the same marker
+	windowStart := alertedAt.UTC().Truncate(window)
+
+	return CrashLoopAlertRecord{
+		ServiceName: fmt.Sprintf("%s#alerted", serviceName),
+		AlertKey:    windowStart.Format(time.RFC3339),
+		AlertedAt:   alertedAt.UTC().Format(time.RFC3339),
+		ExpiresAt:   windowStart.Add(2 * window).Unix(),
+	}
+}
+
+// PutCrashLoopAlertRecord writes the marker unless it exists already and
+// tells whether it did, so only the first stop past the threshold in a
+// window alerts, even when concurrent events cross it together
+func PutCrashLoopAlertRecord(tableName string, record CrashLoopAlertRecord,
+	client dynamodbiface.DynamoDBAPI) (bool, error) {
+	item, err := dynamodbattribute.MarshalMap(record)
+	if err != nil {
+		return false, WrapError("Error marshaling crash loop alert record", err)
+	}
+
+	_, err = client.PutItem(&dynamodb.PutItemInput{
+		TableName:           aws.String(tableName),
+		Item:                item,
+		ConditionExpression: aws.String("attribute_not_exists(StopKey)"),
+	})
+
+	var awsError awserr.Error
+	if errors.As(err, &awsError) && awsError.Code() == dynamodb.ErrCodeConditionalCheckFailedException {
+		return false, nil
+	}
+
+	if err != nil {
+		return false, fmt.Errorf("error writing crash loop alert record to table '%s': %w", tableName, err)
+	}
+
+	return true, nil
+}
+
+func ScanDeploymentRecords(tableName string, windowStart, windowEnd time.Time,
+	client dynamodbiface.DynamoDBAPI) ([]DeploymentRecord, error) {
+	// the digest runs once per schedule over every service, so a
+	// filtered scan is cheaper to operate than a per-service index
+	var records []DeploymentRecord
+	var unmarshalErr error
+
+	input := &dynamodb.ScanInput{
+		TableName:                aws.String(tableName),
+		FilterExpression:         aws.String("#ts BETWEEN :start AND :end"),
+		ExpressionAttributeNames: map[string]*string{"#ts": aws.String("Timestamp")},
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":start": {S: aws.String(windowStart.UTC().Format(time.RFC3339))},
+			":end":   {S: aws.String(windowEnd.UTC().Format(time.RFC3339))},
+		},
+	}
+
+	err := client.ScanPages(input, func(page *dynamodb.ScanOutput, lastPage bool) bool {
+		var pageRecords []DeploymentRecord
+		unmarshalErr = dynamodbattribute.UnmarshalListOfMaps(page.Items, &pageRecords)
+		if unmarshalErr != nil {
+			return false
+		}
+
+		records = append(records, pageRecords...)
+		return true
+	})
+
+	if err != nil {
+		return records, fmt.Errorf("error scanning deployment records from table '%s': %w", tableName, err)
+	}
+
+	if unmarshalErr != nil {
+		return records, WrapError("Error unmarshaling deployment records", unmarshalErr)
+	}
+
+	return records, nil
+}
@@ -0,0 +1,72 @@
+package helper_test
+
+import (
+	"deployment-notifications/pkg/fake"
+	"deployment-notifications/pkg/helper"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestNewDeploymentRecord(t *testing.T) {
+	deploymentTime, _ := time.Parse(time.RFC3339, "2020-05-23T13:11:11+02:00")
+
+	record := helper.NewDeploymentRecord("shure-content-api", "ecs-svc/123", helper.DeploymentOutcomeSuccess,
+		deploymentTime, "ddca6449-b258-46c0-8653-e0e3a6EXAMPLE", "111122223333", "us-west-2")
+
+	assert.Equal(t, "shure-content-api", record.ServiceName)
+	assert.Equal(t, "2020-05-23T11:11:11Z#ecs-svc/123", record.RecordKey)
+	assert.Equal(t, "2020-05-23T11:11:11Z", record.Timestamp)
+	assert.Equal(t, helper.DeploymentOutcomeSuccess, record.Outcome)
+	assert.Equal(t, "ddca6449-b258-46c0-8653-e0e3a6EXAMPLE", record.EventID)
+	assert.True(t, deploymentTime.Equal(record.Time()))
+}
+
+func TestNewTaskStopRecord(t *testing.T) {
+	stoppedAt, _ := time.Parse(time.RFC3339, "2020-05-23T12:35:10Z")
+
+	record := helper.NewTaskStopRecord("shure-content-api", "arn:aws:ecs:us-west-2:111122223333:task/default/b99d",
+		stoppedAt, 20*time.Minute)
+
+	assert.Equal(t, "shure-content-api", record.ServiceName)
+	assert.Equal(t, "2020-05-23T12:35:10Z#arn:aws:ecs:us-west-2:111122223333:task/default/b99d", record.StopKey)
+	assert.Equal(t, "2020-05-23T12:35:10Z", record.StoppedAt)
+	assert.Equal(t, stoppedAt.Add(20*time.Minute).Unix(), record.ExpiresAt)
+}
+
+func TestNewCrashLoopAlertRecord(t *testing.T) {
+	alertedAt, _ := time.Parse(time.RFC3339, "2020-05-23T12:37:10Z")
+
+	record := helper.NewCrashLoopAlertRecord("shure-content-api", alertedAt, 10*time.Minute)
+
+	assert.Equal(t, "shure-content-api#alerted", record.ServiceName)
+	assert.Equal(t, "2020-05-23T12:30:00Z", record.AlertKey)
+	assert.Equal(t, "2020-05-23T12:37:10Z", record.AlertedAt)
+	assert.Equal(t, alertedAt.Truncate(10*time.Minute).Add(20*time.Minute).Unix(), record.ExpiresAt)
+}
+
+func TestPutCrashLoopAlertRecord(t *testing.T) {
+	client := &fake.DynamoDB{Keys: map[string][]string{"crash-loops": {"ServiceName", "StopKey"}}}
+	alertedAt, _ := time.Parse(time.RFC3339, "2020-05-23T12:31:00Z")
+
+	alerted, err := helper.PutCrashLoopAlertRecord("crash-loops",
+		helper.NewCrashLoopAlertRecord("shure-content-api", alertedAt, 10*time.Minute), client)
+	assert.Nil(t, err)
+	assert.True(t, alerted)
+
+	alerted, err = helper.PutCrashLoopAlertRecord("crash-loops",
+		helper.NewCrashLoopAlertRecord("shure-content-api", alertedAt.Add(5*time.Minute), 10*time.Minute), client)
+	assert.Nil(t, err)
+	assert.False(t, alerted)
+
+	// the marker is kept apart from the stops counted for the service
+	count, err := helper.CountTaskStops("crash-loops", "shure-content-api", alertedAt.Add(-time.Hour), client)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, count)
+
+	client.Err = errors.New("throttled")
+	_, err = helper.PutCrashLoopAlertRecord("crash-loops",
+		helper.NewCrashLoopAlertRecord("shure-content-api", alertedAt, 10*time.Minute), client)
+	assert.NotNil(t, err)
+}
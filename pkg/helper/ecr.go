@@ -0,0 +1,375 @@
+package helper
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ecr"
+	"github.com/aws/aws-sdk-go/service/ecr/ecriface"
+	"github.com/aws/aws-sdk-go/service/ecs"
+	"github.com/aws/aws-sdk-go/service/ecs/ecsiface"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// DefaultSlackECRTemplate is used for image push and scan events when no
+// SSM_PARAMETER_MESSAGE_SLACK_ECR parameter is configured
+const DefaultSlackECRTemplate = `{
+   "text":"<varbegin>if eq .EventName "SCAN"<varend>:warning: Scan of a new <backquote><varbegin>.ServiceName<varend><backquote> image found <varbegin>.CriticalCount<varend> critical finding(s)<varbegin>else<varend>:package: New <backquote><varbegin>.ServiceName<varend><backquote> image pushed to <backquote><varbegin>.RepositoryName<varend><backquote><varbegin>end<varend>",
+   "attachments":[
+      {
+         "fallback":"<varbegin>.EventName<varend> of <backquote><varbegin>.RepositoryName<varend><backquote> image <varbegin>.ImageDigest<varend>",
+         "color":"<varbegin>if eq .EventName "SCAN"<varend>#D00000<varbegin>else<varend>#439FE0<varbegin>end<varend>",
+         "fields":[
+            {
+               "title":"Repository",
+               "value":"<varbegin>.RepositoryName<varend>",
+               "short":true
+            },
+            {
+               "title":"Tags",
+               "value":"<varbegin>.ImageTags<varend>",
+               "short":true
+            },
+            {
+               "title":"Digest",
+               "value":"<varbegin>.ImageDigest<varend>",
+               "short":false
+            },<varbegin>if .SeveritySummary<varend>
+            {
+               "title":"Findings",
+               "value":"<varbegin>.SeveritySummary<varend>",
+               "short":false
+            },<varbegin>end<varend>
+            {
+               "title":"Timestamp",
+               "value":"<varbegin>.EventTimestamp<varend>",
+               "short":false
+            }
+         ]
+      }
+   ]
+}`
+
+// DefaultSlackECRDeployWarningTemplate is used when a deployment rolls out
+// images with critical findings and no SSM_PARAMETER_MESSAGE_SLACK_ECR_DEPLOY
+// parameter is configured
+const DefaultSlackECRDeployWarningTemplate = `{
+   "text":"<!here> :warning: Deployment of <backquote><varbegin>.ServiceName<varend><backquote> rolled out images with outstanding critical findings",
+   "attachments":[
+      {
+         "fallback":"Deployment of <backquote><varbegin>.ServiceName<varend><backquote> rolled out images with critical findings",
+         "color":"#D00000",
+         "fields":[
+            {
+               "title":"Service",
+               "value":"<varbegin>.ServiceName<varend>",
+               "short":true
+            },
+            {
+               "title":"Deployment",
+               "value":"<varbegin>.DeploymentID<varend>",
+               "short":true
+            },
+            {
+               "title":"Images",
+               "value":"<varbegin>.Images<varend>",
+               "short":false
+            }
+         ]
+      }
+   ]
+}`
+
+const ecrCriticalSeverity = "CRITICAL"
+
+var ecrImagePattern = regexp.MustCompile(`^(\d+)\.dkr\.ecr\.[a-z0-9-]+\.amazonaws\.com(?:\.cn)?/([^:@]+)(?::([^@]+))?(?:@(.+))?$`)
+
+type ECRImageActionInfo struct {
+	Result         string `json:"result"`
+	RepositoryName string `json:"repository-name"`
+	ImageDigest    string `json:"image-digest"`
+	ActionType     string `json:"action-type"`
+	ImageTag       string `json:"image-tag"`
+}
+
+type ECRImageScanInfo struct {
+	ScanStatus            string         `json:"scan-status"`
+	RepositoryName        string         `json:"repository-name"`
+	ImageDigest           string         `json:"image-digest"`
+	ImageTags             []string       `json:"image-tags"`
+	FindingSeverityCounts map[string]int `json:"finding-severity-counts"`
+}
+
+// ECRImage is an image reference split into its ECR parts,
+// the tag or the digest may be empty
+type ECRImage struct {
+	RegistryID     string
+	RepositoryName string
+	Tag            string
+	Digest         string
+}
+
+// DeployedImage is an image a container of a deployment is running
+type DeployedImage struct {
+	ContainerName string
+	Image         string
+	Digest        string
+	CriticalCount int
+}
+
+type ECRNotificationFields struct {
+	ServiceName     string
+	RepositoryName  string
+	EventName       string
+	ImageDigest     string
+	ImageTags       string
+	CriticalCount   int
+	SeveritySummary string
+	AWSReference    string
+	AWSRegion       string
+	AWSAccount      string
+	EventTimestamp  string
+}
+
+type ECRDeployWarningFields struct {
+	ServiceName  string
+	DeploymentID string
+	Images       string
+	AWSReference string
+	AWSRegion    string
+	AWSAccount   string
+}
+
+func IsECRImageScanEvent(detailType string) bool {
+	return strings.ToLower(detailType) == "ecr image scan"
+}
+
+func GetECRRepositoryName(repository string) string {
+	// enhanced scanning reports the repository ARN rather than its name
+	if split := strings.Split(repository, ":repository/"); len(split) == 2 {
+		return split[1]
+	}
+
+	return repository
+}
+
+func ParseECRImageActionDetails(request events.CloudWatchEvent) (ECRImageActionInfo, error) {
+	var imageActionInfo ECRImageActionInfo
+
+	err := json.Unmarshal(request.Detail, &imageActionInfo)
+
+	if err != nil {
+		return imageActionInfo, WrapError("Unexpected error unmarshaling ECR image action details", err)
+	}
+
+	if imageActionInfo.RepositoryName == "" {
+		return imageActionInfo, WrapError("'repository-name' attribute not found on payload", nil)
+	}
+	if imageActionInfo.ActionType == "" {
+		return imageActionInfo, WrapError("'action-type' attribute not found on payload", nil)
+	}
+
+	return imageActionInfo, nil
+}
+
+func ParseECRImageScanDetails(request events.CloudWatchEvent) (ECRImageScanInfo, error) {
+	var imageScanInfo ECRImageScanInfo
+
+	err := json.Unmarshal(request.Detail, &imageScanInfo)
+
+	if err != nil {
+		return imageScanInfo, WrapError("Unexpected error unmarshaling ECR image scan details", err)
+	}
+
+	if imageScanInfo.RepositoryName == "" {
+		return imageScanInfo, WrapError("'repository-name' attribute not found on payload", nil)
+	}
+	if imageScanInfo.ImageDigest == "" {
+		return imageScanInfo, WrapError("'image-digest' attribute not found on payload", nil)
+	}
+
+	imageScanInfo.RepositoryName = GetECRRepositoryName(imageScanInfo.RepositoryName)
+
+	return imageScanInfo, nil
+}
+
+func IsECRScanComplete(scanStatus string) bool {
+	// basic scans report COMPLETE, enhanced scans e.g. INITIAL_SCAN_COMPLETE
+	return strings.HasSuffix(strings.ToUpper(scanStatus), "COMPLETE")
+}
+
+func FormatSeverityCounts(severityCounts map[string]int) string {
+	// most severe first, the way the ECR console lists them
+	severityOrder := map[string]int{"CRITICAL": 0, "HIGH": 1, "MEDIUM": 2, "LOW": 3, "INFORMATIONAL": 4, "UNDEFINED": 5}
+
+	var severities []string
+	for severity := range severityCounts {
+		if severity != "TOTAL" {
+			severities = append(severities, severity)
+		}
+	}
+
+	sort.Slice(severities, func(i, j int) bool {
+		orderI, okI := severityOrder[severities[i]]
+		orderJ, okJ := severityOrder[severities[j]]
+
+		if okI != okJ {
+			return okI
+		}
+		if orderI != orderJ {
+			return orderI < orderJ
+		}
+		return severities[i] < severities[j]
+	})
+
+	var counts []string
+	for _, severity := range severities {
+		counts = append(counts, fmt.Sprintf("%s: %d", severity, severityCounts[severity]))
+	}
+
+	return strings.Join(counts, ", ")
+}
+
+func ParseECRImage(image string) (ECRImage, bool) {
+	// <registry>.dkr.ecr.<region>.amazonaws.com/<repository>[:<tag>][@<digest>]
+	match := ecrImagePattern.FindStringSubmatch(image)
+
+	if match == nil {
+		return ECRImage{}, false
+	}
+
+	return ECRImage{
+		RegistryID:     match[1],
+		RepositoryName: match[2],
+		Tag:            match[3],
+		Digest:         match[4],
+	}, true
+}
+
+func GetECRCriticalFindings(ecrImage ECRImage, client ecriface.ECRAPI) (int, error) {
+	imageID := &ecr.ImageIdentifier{}
+	if ecrImage.Digest != "" {
+		imageID.ImageDigest = aws.String(ecrImage.Digest)
+	} else {
+		imageID.ImageTag = aws.String(ecrImage.Tag)
+	}
+
+	output, err := client.DescribeImageScanFindings(&ecr.DescribeImageScanFindingsInput{
+		RegistryId:     aws.String(ecrImage.RegistryID),
+		RepositoryName: aws.String(ecrImage.RepositoryName),
+		ImageId:        imageID,
+		MaxResults:     aws.Int64(1),
+	})
+
+	if err != nil {
+		return 0, fmt.Errorf("error describing scan findings for '%s': %w", ecrImage.RepositoryName, err)
+	}
+
+	if output.ImageScanFindings == nil {
+		return 0, nil
+	}
+
+	return int(aws.Int64Value(output.ImageScanFindings.FindingSeverityCounts[ecrCriticalSeverity])), nil
+}
+
+func ListDeploymentImages(clusterName, serviceName, deploymentID string,
+	client ecsiface.ECSAPI) ([]DeployedImage, error) {
+	// service tasks are started by their deployment, so the
+	// deployment id selects the tasks it rolled out
+	listOutput, err := client.ListTasks(&ecs.ListTasksInput{
+		Cluster:   aws.String(clusterName),
+		StartedBy: aws.String(deploymentID),
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("error listing tasks of deployment '%s': %w", deploymentID, err)
+	}
+
+	if len(listOutput.TaskArns) == 0 {
+		return nil, nil
+	}
+
+	describeOutput, err := client.DescribeTasks(&ecs.DescribeTasksInput{
+		Cluster: aws.String(clusterName),
+		Tasks:   listOutput.TaskArns,
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("error describing tasks of deployment '%s': %w", deploymentID, err)
+	}
+
+	return GetDeployedImages(describeOutput.Tasks), nil
+}
+
+func GetDeployedImages(tasks []*ecs.Task) []DeployedImage {
+	// all tasks of a deployment run the same images, so
+	// each container image and digest is listed once
+	var images []DeployedImage
+	seen := make(map[string]bool)
+
+	for _, task := range tasks {
+		for _, container := range task.Containers {
+			key := fmt.Sprintf("%s@%s", aws.StringValue(container.Image), aws.StringValue(container.ImageDigest))
+
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+
+			images = append(images, DeployedImage{
+				ContainerName: aws.StringValue(container.Name),
+				Image:         aws.StringValue(container.Image),
+				Digest:        aws.StringValue(container.ImageDigest),
+			})
+		}
+	}
+
+	return images
+}
+
+func FormatDeployedImages(images []DeployedImage) string {
+	// placed inside a JSON string in the Slack template
+	var lines []string
+
+	for _, image := range images {
+		lines = append(lines, fmt.Sprintf("%s: %s@%s (%d critical)", image.ContainerName, image.Image,
+			image.Digest, image.CriticalCount))
+	}
+
+	return strings.Join(lines, `\n`)
+}
+
+func GenerateECRImageActionNotificationStruct(request events.CloudWatchEvent, serviceName string,
+	imageActionInfo ECRImageActionInfo) ECRNotificationFields {
+	return ECRNotificationFields{
+		ServiceName:    serviceName,
+		RepositoryName: imageActionInfo.RepositoryName,
+		EventName:      imageActionInfo.ActionType,
+		ImageDigest:    imageActionInfo.ImageDigest,
+		ImageTags:      imageActionInfo.ImageTag,
+		AWSReference:   request.ID,
+		AWSRegion:      request.Region,
+		AWSAccount:     request.AccountID,
+		EventTimestamp: request.Time.UTC().Format("2006-01-02T15:04:05Z"),
+	}
+}
+
+func GenerateECRImageScanNotificationStruct(request events.CloudWatchEvent, serviceName string,
+	imageScanInfo ECRImageScanInfo) ECRNotificationFields {
+	return ECRNotificationFields{
+		ServiceName:     serviceName,
+		RepositoryName:  imageScanInfo.RepositoryName,
+		EventName:       "SCAN",
+		ImageDigest:     imageScanInfo.ImageDigest,
+		ImageTags:       strings.Join(imageScanInfo.ImageTags, ", "),
+		CriticalCount:   imageScanInfo.FindingSeverityCounts[ecrCriticalSeverity],
+		SeveritySummary: FormatSeverityCounts(imageScanInfo.FindingSeverityCounts),
+		AWSReference:    request.ID,
+		AWSRegion:       request.Region,
+		AWSAccount:      request.AccountID,
+		EventTimestamp:  request.Time.UTC().Format("2006-01-02T15:04:05Z"),
+	}
+}
@@ -0,0 +1,163 @@
+package helper_test
+
+import (
+	"deployment-notifications/pkg/helper"
+	"encoding/json"
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ecs"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+const sampleECRImageScanEvent = `
+{
+   "version": "0",
+   "id": "85fc3613-e913-7fc4-a80c-a3753e4aa9ae",
+   "detail-type": "ECR Image Scan",
+   "source": "aws.ecr",
+   "account": "111122223333",
+   "time": "2020-05-23T12:31:14Z",
+   "region": "us-west-2",
+   "resources": [
+        "arn:aws:ecr:us-west-2:111122223333:repository/content-api"
+   ],
+   "detail": {
+        "scan-status": "COMPLETE",
+        "repository-name": "content-api",
+        "finding-severity-counts": {
+            "CRITICAL": 2,
+            "HIGH": 5,
+            "MEDIUM": 9
+        },
+        "image-digest": "sha256:7f5b2640fe6fb4f46592dfd3410c4a79dac4f89e4782432e0378abcd1234abcd",
+        "image-tags": ["2020.05", "latest"]
+   }
+}
+`
+
+const sampleECRImageActionDetail = `
+{
+    "result": "SUCCESS",
+    "repository-name": "content-api",
+    "image-digest": "sha256:7f5b2640fe6fb4f46592dfd3410c4a79dac4f89e4782432e0378abcd1234abcd",
+    "action-type": "PUSH",
+    "image-tag": "2020.05"
+}
+`
+
+func TestParseECRDetails(t *testing.T) {
+	var cloudwatchEvent events.CloudWatchEvent
+	err := json.Unmarshal([]byte(sampleECRImageScanEvent), &cloudwatchEvent)
+	assert.Nil(t, err)
+
+	assert.True(t, helper.IsECRImageScanEvent(cloudwatchEvent.DetailType))
+	imageScanInfo, err := helper.ParseECRImageScanDetails(cloudwatchEvent)
+	assert.Nil(t, err)
+	assert.Equal(t, "content-api", imageScanInfo.RepositoryName)
+	assert.Equal(t, 2, imageScanInfo.FindingSeverityCounts["CRITICAL"])
+	assert.True(t, helper.IsECRScanComplete(imageScanInfo.ScanStatus))
+
+	cloudwatchEvent.Detail = json.RawMessage(`{"scan-status": "INITIAL_SCAN_COMPLETE", ` +
+		`"repository-name": "arn:aws:ecr:us-west-2:111122223333:repository/content-api", "image-digest": "sha256:1"}`)
+	imageScanInfo, err = helper.ParseECRImageScanDetails(cloudwatchEvent)
+	assert.Nil(t, err)
+	assert.Equal(t, "content-api", imageScanInfo.RepositoryName)
+	assert.True(t, helper.IsECRScanComplete(imageScanInfo.ScanStatus))
+	assert.False(t, helper.IsECRScanComplete("FAILED"))
+
+	cloudwatchEvent.DetailType = "ECR Image Action"
+	cloudwatchEvent.Detail = json.RawMessage(sampleECRImageActionDetail)
+	assert.False(t, helper.IsECRImageScanEvent(cloudwatchEvent.DetailType))
+	imageActionInfo, err := helper.ParseECRImageActionDetails(cloudwatchEvent)
+	assert.Nil(t, err)
+	assert.Equal(t, "PUSH", imageActionInfo.ActionType)
+	assert.Equal(t, "2020.05", imageActionInfo.ImageTag)
+
+	cloudwatchEvent.Detail = json.RawMessage(`{"result": "SUCCESS", "action-type": "PUSH"}`)
+	_, err = helper.ParseECRImageActionDetails(cloudwatchEvent)
+	assert.NotNil(t, err)
+}
+
+func TestFormatSeverityCounts(t *testing.T) {
+	assert.Equal(t, "CRITICAL: 2, HIGH: 5, MEDIUM: 9, LOW: 1, OTHER: 3", helper.FormatSeverityCounts(
+		map[string]int{"LOW": 1, "MEDIUM": 9, "CRITICAL": 2, "TOTAL": 20, "HIGH": 5, "OTHER": 3}))
+	assert.Equal(t, "", helper.FormatSeverityCounts(nil))
+}
+
+func TestParseECRImage(t *testing.T) {
+	ecrImage, ok := helper.ParseECRImage("111122223333.dkr.ecr.us-west-2.amazonaws.com/team/content-api:2020.05")
+	assert.True(t, ok)
+	assert.Equal(t, helper.ECRImage{RegistryID: "111122223333", RepositoryName: "team/content-api", Tag: "2020.05"}, ecrImage)
+
+	ecrImage, ok = helper.ParseECRImage("111122223333.dkr.ecr.us-west-2.amazonaws.com/content-api@sha256:abcd")
+	assert.True(t, ok)
+	assert.Equal(t, helper.ECRImage{RegistryID: "111122223333", RepositoryName: "content-api", Digest: "sha256:abcd"}, ecrImage)
+
+	_, ok = helper.ParseECRImage("docker.io/library/nginx:latest")
+	assert.False(t, ok)
+}
+
+func TestDeployedImages(t *testing.T) {
+	container := func(name, image, digest string) *ecs.Container {
+		return &ecs.Container{Name: aws.String(name), Image: aws.String(image), ImageDigest: aws.String(digest)}
+	}
+
+	tasks := []*ecs.Task{
+		{Containers: []*ecs.Container{
+			container("web", "111122223333.dkr.ecr.us-west-2.amazonaws.com/content-api:2020.05", "sha256:abcd"),
+			container("proxy", "nginx:latest", "sha256:ffff"),
+		}},
+		{Containers: []*ecs.Container{
+			container("web", "111122223333.dkr.ecr.us-west-2.amazonaws.com/content-api:2020.05", "sha256:abcd"),
+		}},
+	}
+
+	images := helper.GetDeployedImages(tasks)
+	assert.Equal(t, 2, len(images))
+	assert.Equal(t, "web", images[0].ContainerName)
+	assert.Equal(t, "sha256:abcd", images[0].Digest)
+
+	images[0].CriticalCount = 2
+	assert.Equal(t, `web: 111122223333.dkr.ecr.us-west-2.amazonaws.com/content-api:2020.05@sha256:abcd (2 critical)\n`+
+		`proxy: nginx:latest@sha256:ffff (0 critical)`, helper.FormatDeployedImages(images))
+}
+
+func TestECRNotification(t *testing.T) {
+	var cloudwatchEvent events.CloudWatchEvent
+	err := json.Unmarshal([]byte(sampleECRImageScanEvent), &cloudwatchEvent)
+	assert.Nil(t, err)
+
+	imageScanInfo, _ := helper.ParseECRImageScanDetails(cloudwatchEvent)
+	slackStruct := helper.GenerateECRImageScanNotificationStruct(cloudwatchEvent, "production/content-api", imageScanInfo)
+	assert.Equal(t, "SCAN", slackStruct.EventName)
+	assert.Equal(t, 2, slackStruct.CriticalCount)
+	assert.Equal(t, "2020.05, latest", slackStruct.ImageTags)
+
+	parsedMessage, err := helper.GeneratePayload(helper.DefaultSlackECRTemplate, slackStruct, true)
+	assert.Nil(t, err)
+	assert.True(t, json.Valid([]byte(parsedMessage)))
+	assert.Contains(t, parsedMessage, "Scan of a new `production/content-api` image found 2 critical finding(s)")
+	assert.Contains(t, parsedMessage, "CRITICAL: 2, HIGH: 5, MEDIUM: 9")
+
+	cloudwatchEvent.DetailType = "ECR Image Action"
+	cloudwatchEvent.Detail = json.RawMessage(sampleECRImageActionDetail)
+	imageActionInfo, _ := helper.ParseECRImageActionDetails(cloudwatchEvent)
+	slackStruct = helper.GenerateECRImageActionNotificationStruct(cloudwatchEvent, "production/content-api", imageActionInfo)
+
+	parsedMessage, err = helper.GeneratePayload(helper.DefaultSlackECRTemplate, slackStruct, true)
+	assert.Nil(t, err)
+	assert.True(t, json.Valid([]byte(parsedMessage)))
+	assert.Contains(t, parsedMessage, "New `production/content-api` image pushed to `content-api`")
+	assert.NotContains(t, parsedMessage, `"title":"Findings"`)
+
+	warning := helper.ECRDeployWarningFields{
+		ServiceName:  "production/content-api",
+		DeploymentID: "ecs-svc/1234567890123456789",
+		Images:       `web: content-api@sha256:abcd (2 critical)\nsidecar: agent@sha256:ffff (1 critical)`,
+	}
+
+	parsedMessage, err = helper.GeneratePayload(helper.DefaultSlackECRDeployWarningTemplate, warning, true)
+	assert.Nil(t, err)
+	assert.True(t, json.Valid([]byte(parsedMessage)))
+}
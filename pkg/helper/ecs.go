@@ -0,0 +1,107 @@
+package helper
+
+import (
+	"fmt"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ecs"
+	"github.com/aws/aws-sdk-go/service/ecs/ecsiface"
+	"strconv"
+	"strings"
+)
+
+func GetClusterAndServiceFromARN(arnString string) (string, string, error) {
+	// new style ARNs carry the cluster: service/<cluster>/<service>
+	// old style ARNs (service/<service>) fall back to ECS_CLUSTER_NAME
+	serviceName, err := GetServiceNameFromARN(arnString)
+
+	if err != nil {
+		return "", "", err
+	}
+
+	nameSplit := strings.SplitN(serviceName, "/", 2)
+
+	if len(nameSplit) == 2 {
+		return nameSplit[0], nameSplit[1], nil
+	}
+
+	return GetStringEnv("ECS_CLUSTER_NAME", "default"), serviceName, nil
+}
+
+func ParseTaskDefinitionRevision(taskDefinitionARN string) (string, int, error) {
+	// arn:aws:ecs:<region>:<account>:task-definition/<family>:<revision>
+	familyRevision := taskDefinitionARN
+
+	if arnSplit := strings.Split(taskDefinitionARN, "task-definition/"); len(arnSplit) == 2 {
+		familyRevision = arnSplit[1]
+	}
+
+	separator := strings.LastIndex(familyRevision, ":")
+
+	if separator < 1 {
+		return "", 0, WrapError(fmt.Sprintf("Task definition '%s' did not have expected '<family>:<revision>' format",
+			taskDefinitionARN), nil)
+	}
+
+	revision, err := strconv.Atoi(familyRevision[separator+1:])
+
+	if err != nil {
+		return "", 0, WrapError(fmt.Sprintf("Task definition '%s' revision is not a number", taskDefinitionARN), err)
+	}
+
+	return familyRevision[:separator], revision, nil
+}
+
+func GetTaskDefinitionName(taskDefinitionARN string) string {
+	family, revision, err := ParseTaskDefinitionRevision(taskDefinitionARN)
+
+	if err != nil {
+		return taskDefinitionARN
+	}
+
+	return fmt.Sprintf("%s:%d", family, revision)
+}
+
+func DescribeECSService(clusterName, serviceName string, client ecsiface.ECSAPI) (*ecs.Service, error) {
+	output, err := client.DescribeServices(&ecs.DescribeServicesInput{
+		Cluster:  aws.String(clusterName),
+		Services: []*string{aws.String(serviceName)},
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("error describing ECS service '%s' in cluster '%s': %w", serviceName, clusterName, err)
+	}
+
+	if len(output.Services) == 0 {
+		return nil, WrapError(fmt.Sprintf("ECS service '%s' not found in cluster '%s'", serviceName, clusterName), nil)
+	}
+
+	return output.Services[0], nil
+}
+
+func FindECSDeployment(deployments []*ecs.Deployment, deploymentID string) *ecs.Deployment {
+	// looks up a deployment by id, falling back to the primary
+	// deployment when the id is unknown or no longer listed
+	var primary *ecs.Deployment
+
+	for _, deployment := range deployments {
+		if deploymentID != "" && aws.StringValue(deployment.Id) == deploymentID {
+			return deployment
+		}
+
+		if aws.StringValue(deployment.Status) == "PRIMARY" {
+			primary = deployment
+		}
+	}
+
+	return primary
+}
+
+func FormatECSDeployment(serviceName string, deployment *ecs.Deployment) string {
+	rolloutState := aws.StringValue(deployment.RolloutState)
+	if rolloutState == "" {
+		rolloutState = aws.StringValue(deployment.Status)
+	}
+
+	return fmt.Sprintf("%s %s: %s, %d/%d tasks running", serviceName, aws.StringValue(deployment.Id),
+		rolloutState, aws.Int64Value(deployment.RunningCount), aws.Int64Value(deployment.DesiredCount))
+}
@@ -0,0 +1,83 @@
+package helper_test
+
+import (
+	"deployment-notifications/pkg/helper"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ecs"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"testing"
+)
+
+func TestClusterAndServiceFromARN(t *testing.T) {
+	clusterName, serviceName, err := helper.GetClusterAndServiceFromARN(
+		"arn:aws:ecs:us-west-2:111122223333:service/production/shure-content-api")
+	assert.Nil(t, err)
+	assert.Equal(t, "production", clusterName)
+	assert.Equal(t, "shure-content-api", serviceName)
+
+	clusterName, serviceName, err = helper.GetClusterAndServiceFromARN(
+		"arn:aws:ecs:us-west-2:111122223333:service/shure-content-api")
+	assert.Nil(t, err)
+	assert.Equal(t, "default", clusterName)
+	assert.Equal(t, "shure-content-api", serviceName)
+
+	os.Setenv("ECS_CLUSTER_NAME", "staging")
+	defer os.Unsetenv("ECS_CLUSTER_NAME")
+
+	clusterName, _, _ = helper.GetClusterAndServiceFromARN(
+		"arn:aws:ecs:us-west-2:111122223333:service/shure-content-api")
+	assert.Equal(t, "staging", clusterName)
+
+	_, _, err = helper.GetClusterAndServiceFromARN("arn:aws:ecs:us-west-2:111122223333:services/shure-content-api")
+	assert.NotNil(t, err)
+}
+
+func TestParseTaskDefinitionRevision(t *testing.T) {
+	family, revision, err := helper.ParseTaskDefinitionRevision(
+		"arn:aws:ecs:us-west-2:111122223333:task-definition/shure-content-api:42")
+	assert.Nil(t, err)
+	assert.Equal(t, "shure-content-api", family)
+	assert.Equal(t, 42, revision)
+
+	family, revision, err = helper.ParseTaskDefinitionRevision("shure-content-api:7")
+	assert.Nil(t, err)
+	assert.Equal(t, "shure-content-api", family)
+	assert.Equal(t, 7, revision)
+
+	_, _, err = helper.ParseTaskDefinitionRevision("shure-content-api")
+	assert.NotNil(t, err)
+
+	_, _, err = helper.ParseTaskDefinitionRevision("shure-content-api:latest")
+	assert.NotNil(t, err)
+
+	assert.Equal(t, "shure-content-api:42",
+		helper.GetTaskDefinitionName("arn:aws:ecs:us-west-2:111122223333:task-definition/shure-content-api:42"))
+	assert.Equal(t, "not-a-task-definition", helper.GetTaskDefinitionName("not-a-task-definition"))
+}
+
+func TestFindECSDeployment(t *testing.T) {
+	deployments := []*ecs.Deployment{
+		{
+			Id:           aws.String("ecs-svc/2222222222222222222"),
+			Status:       aws.String("PRIMARY"),
+			RolloutState: aws.String("IN_PROGRESS"),
+			RunningCount: aws.Int64(1),
+			DesiredCount: aws.Int64(2),
+		},
+		{
+			Id:     aws.String("ecs-svc/1111111111111111111"),
+			Status: aws.String("ACTIVE"),
+		},
+	}
+
+	deployment := helper.FindECSDeployment(deployments, "ecs-svc/1111111111111111111")
+	assert.Equal(t, "ecs-svc/1111111111111111111", aws.StringValue(deployment.Id))
+
+	deployment = helper.FindECSDeployment(deployments, "ecs-svc/9999999999999999999")
+	assert.Equal(t, "ecs-svc/2222222222222222222", aws.StringValue(deployment.Id))
+	assert.Equal(t, "content-api ecs-svc/2222222222222222222: IN_PROGRESS, 1/2 tasks running",
+		helper.FormatECSDeployment("content-api", deployment))
+
+	assert.Nil(t, helper.FindECSDeployment(deployments[1:], ""))
+}
@@ -0,0 +1,133 @@
+package helper
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/secretsmanager"
+	"github.com/aws/aws-sdk-go/service/ssm"
+)
+
+// ErrorKind tells failures that a retry can fix from those it cannot
+type ErrorKind string
+
+const (
+	// ErrorKindConfiguration is a missing or invalid mapping, template
+	// or setting, which stays wrong until someone changes it
+	ErrorKindConfiguration ErrorKind = "configuration"
+	// ErrorKindFiltered is an event that is not tracked or not supported
+	ErrorKindFiltered ErrorKind = "filtered"
+	// ErrorKindUnmapped is an event about a service with no mapping
+	ErrorKindUnmapped ErrorKind = "unmapped"
+	// ErrorKindInvalidEvent is a payload or event that cannot be read
+	// or lacks what it must carry, e.g. the resources of an ECS event
+	ErrorKindInvalidEvent ErrorKind = "invalid-event"
+	// ErrorKindTransientSink is a sink that could not be reached, was
+	// throttled or failed on its side
+	ErrorKindTransientSink ErrorKind = "transient-sink"
+	// ErrorKindPermanentSink is a sink that rejected the notification
+	ErrorKindPermanentSink ErrorKind = "permanent-sink"
+)
+
+// NotificationError is an error of a known kind
+type NotificationError struct {
+	Kind ErrorKind
+	Err  error
+}
+
+func (e *NotificationError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *NotificationError) Unwrap() error {
+	return e.Err
+}
+
+func newNotificationError(kind ErrorKind, errorMessage string, err error) error {
+	// an empty message keeps the error as it is
+	if errorMessage != "" || err == nil {
+		err = WrapError(errorMessage, err)
+	}
+
+	return &NotificationError{Kind: kind, Err: err}
+}
+
+func ConfigurationError(errorMessage string, err error) error {
+	return newNotificationError(ErrorKindConfiguration, errorMessage, err)
+}
+
+func FilteredError(errorMessage string, err error) error {
+	return newNotificationError(ErrorKindFiltered, errorMessage, err)
+}
+
+func UnmappedError(errorMessage string, err error) error {
+	return newNotificationError(ErrorKindUnmapped, errorMessage, err)
+}
+
+func InvalidEventError(errorMessage string, err error) error {
+	return newNotificationError(ErrorKindInvalidEvent, errorMessage, err)
+}
+
+func TransientSinkError(errorMessage string, err error) error {
+	return newNotificationError(ErrorKindTransientSink, errorMessage, err)
+}
+
+func PermanentSinkError(errorMessage string, err error) error {
+	return newNotificationError(ErrorKindPermanentSink, errorMessage, err)
+}
+
+// SinkStatusError is the error of a sink answering with a failure status.
+// Throttling and server errors may pass, any other status will not
+func SinkStatusError(errorMessage string, status int) error {
+	if status == http.StatusTooManyRequests || status >= 500 {
+		return TransientSinkError(errorMessage, nil)
+	}
+
+	return PermanentSinkError(errorMessage, nil)
+}
+
+// GetErrorKind returns the kind of err, or "" for errors of no known kind
+func GetErrorKind(err error) ErrorKind {
+	var notificationError *NotificationError
+
+	if errors.As(err, &notificationError) {
+		return notificationError.Kind
+	}
+
+	return ""
+}
+
+// IsRetryable tells whether handling the event again may succeed. Errors
+// of no known kind, e.g. AWS API failures, are retried to be safe
+func IsRetryable(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	switch GetErrorKind(err) {
+	case ErrorKindConfiguration, ErrorKindFiltered, ErrorKindUnmapped, ErrorKindInvalidEvent, ErrorKindPermanentSink:
+		return false
+	}
+
+	return true
+}
+
+// the AWS error codes of a parameter or secret that is missing or
+// may not be read, rather than one that could not be read right now
+var configurationAWSErrorCodes = map[string]bool{
+	ssm.ErrCodeParameterNotFound:                    true,
+	ssm.ErrCodeParameterVersionNotFound:             true,
+	ssm.ErrCodeInvalidKeyId:                         true,
+	secretsmanager.ErrCodeResourceNotFoundException: true,
+	secretsmanager.ErrCodeDecryptionFailure:         true,
+	secretsmanager.ErrCodeInvalidRequestException:   true,
+	"AccessDeniedException":                         true,
+	"ValidationException":                           true,
+}
+
+func IsConfigurationAWSError(err error) bool {
+	var awsError awserr.Error
+
+	return errors.As(err, &awsError) && configurationAWSErrorCodes[awsError.Code()]
+}
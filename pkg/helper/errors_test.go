@@ -0,0 +1,68 @@
+package helper_test
+
+import (
+	"deployment-notifications/pkg/helper"
+	"errors"
+	"fmt"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestErrorKinds(t *testing.T) {
+	cause := errors.New("cause")
+
+	tests := []struct {
+		err       error
+		kind      helper.ErrorKind
+		retryable bool
+	}{
+		{helper.ConfigurationError("Default Slack Webhook not defined", nil), helper.ErrorKindConfiguration, false},
+		{helper.FilteredError("", cause), helper.ErrorKindFiltered, false},
+		{helper.UnmappedError("ECS Service Not Configured", nil), helper.ErrorKindUnmapped, false},
+		{helper.InvalidEventError("Error unmarshaling SQS event", cause), helper.ErrorKindInvalidEvent, false},
+		{helper.TransientSinkError("Error posting to Slack", cause), helper.ErrorKindTransientSink, true},
+		{helper.PermanentSinkError("Error creating request", cause), helper.ErrorKindPermanentSink, false},
+		{helper.WrapError("Error describing ECS service", cause), "", true},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.kind, helper.GetErrorKind(test.err), test.err.Error())
+		assert.Equal(t, test.retryable, helper.IsRetryable(test.err), test.err.Error())
+	}
+
+	assert.False(t, helper.IsRetryable(nil))
+}
+
+func TestErrorKindsWrap(t *testing.T) {
+	cause := errors.New("cause")
+
+	err := helper.FilteredError("", cause)
+	assert.Equal(t, "cause", err.Error())
+	assert.True(t, errors.Is(err, cause))
+
+	err = helper.UnmappedError("Lambda Function Not Configured", nil)
+	assert.Equal(t, "Lambda Function Not Configured", err.Error())
+
+	// the kind survives further wrapping
+	wrapped := fmt.Errorf("handling event: %w", helper.ConfigurationError("", cause))
+	assert.Equal(t, helper.ErrorKindConfiguration, helper.GetErrorKind(wrapped))
+	assert.False(t, helper.IsRetryable(wrapped))
+}
+
+func TestSinkStatusError(t *testing.T) {
+	assert.Equal(t, helper.ErrorKindTransientSink, helper.GetErrorKind(helper.SinkStatusError("throttled", 429)))
+	assert.Equal(t, helper.ErrorKindTransientSink, helper.GetErrorKind(helper.SinkStatusError("unavailable", 503)))
+	assert.Equal(t, helper.ErrorKindPermanentSink, helper.GetErrorKind(helper.SinkStatusError("no_service", 404)))
+	assert.Equal(t, helper.ErrorKindPermanentSink, helper.GetErrorKind(helper.SinkStatusError("invalid_token", 403)))
+}
+
+func TestIsConfigurationAWSError(t *testing.T) {
+	assert.True(t, helper.IsConfigurationAWSError(awserr.New(ssm.ErrCodeParameterNotFound, "not found", nil)))
+	assert.True(t, helper.IsConfigurationAWSError(
+		helper.WrapError("Error reading secret", awserr.New("AccessDeniedException", "denied", nil))))
+	assert.False(t, helper.IsConfigurationAWSError(awserr.New("ThrottlingException", "rate exceeded", nil)))
+	assert.False(t, helper.IsConfigurationAWSError(errors.New("connection reset")))
+	assert.False(t, helper.IsConfigurationAWSError(nil))
+}
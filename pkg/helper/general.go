@@ -1,6 +1,9 @@
 package helper
 
-import "strconv"
+import (
+	"strconv"
+	"time"
+)
 
 func GetDefaultHTTPTimeout() int {
 	// putting this as a configurable parameter
@@ -15,7 +18,104 @@ func GetDefaultHTTPTimeout() int {
 	return defaultTimeout
 }
 
+func GetSecretCacheTTL() time.Duration {
+	// how long secrets are reused before being read again, 0 reads
+	// them for every event
+	return time.Duration(GetIntEnv("SECRET_CACHE_TTL", 300)) * time.Second
+}
+
 func GetDeploymentUser() string {
 	user := GetStringEnv("DEPLOYMENT_USER", "services@graphcms.com")
 	return user
 }
+
+func GetDORATableName() string {
+	// DORA persistence is optional. An empty table name disables
+	// recording and the scheduled digest
+	return GetStringEnv("DORA_TABLE_NAME", "")
+}
+
+func GetDORAWindowDays() int {
+	windowDays, err := strconv.Atoi(GetStringEnv("DORA_WINDOW_DAYS", "7"))
+
+	if err != nil || windowDays < 1 {
+		windowDays = 7
+	}
+
+	return windowDays
+}
+
+func GetDORAGroupBy() string {
+	// either "service" or "team"
+	if GetStringEnv("DORA_GROUP_BY", "service") == "team" {
+		return "team"
+	}
+
+	return "service"
+}
+
+func GetCrashLoopTableName() string {
+	// crash loop detection is disabled without a table to count stops in
+	return GetStringEnv("CRASH_LOOP_TABLE_NAME", "")
+}
+
+func GetCrashLoopThreshold() int {
+	threshold := GetIntEnv("CRASH_LOOP_THRESHOLD", 3)
+	if threshold < 1 {
+		threshold = 3
+	}
+
+	return threshold
+}
+
+func GetCrashLoopWindowMinutes() int {
+	// the sliding window stopped tasks are counted in
+	windowMinutes := GetIntEnv("CRASH_LOOP_WINDOW_MINUTES", 10)
+	if windowMinutes < 1 {
+		windowMinutes = 10
+	}
+
+	return windowMinutes
+}
+
+func GetCrashLoopDeploymentWindowMinutes() int {
+	// how long after a deployment starts stops are considered crash loops
+	windowMinutes := GetIntEnv("CRASH_LOOP_DEPLOYMENT_WINDOW_MINUTES", 60)
+	if windowMinutes < 1 {
+		windowMinutes = 60
+	}
+
+	return windowMinutes
+}
+
+func GetDORACloudWatchNamespace() string {
+	// metrics are only emitted when a namespace is configured
+	return GetStringEnv("DORA_CLOUDWATCH_NAMESPACE", "")
+}
+
+func GetECRDeployScanCheck() bool {
+	// completed deployments are checked for images with critical findings
+	// unless ECR_DEPLOY_SCAN_CHECK is set to "false"
+	return GetStringEnv("ECR_DEPLOY_SCAN_CHECK", "true") != "false"
+}
+
+func GetSinkMaxAttempts() int {
+	// how often a delivery that may pass is tried before giving up
+	maxAttempts := GetIntEnv("SINK_MAX_ATTEMPTS", 3)
+	if maxAttempts < 1 {
+		maxAttempts = 3
+	}
+
+	return maxAttempts
+}
+
+func GetSinkRetryDelay() time.Duration {
+	// the wait before the first retry of a delivery, doubled for each
+	// retry after it
+	retryDelay := GetIntEnv("SINK_RETRY_DELAY_MS", 500)
+	if retryDelay < 0 {
+		retryDelay = 500
+	}
+
+	return time.Duration(retryDelay) * time.Millisecond
+}
@@ -5,6 +5,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"os"
 	"testing"
+	"time"
 )
 
 func TestHTTPTimeout(t *testing.T) {
@@ -26,3 +27,56 @@ func TestDefaultUser(t *testing.T) {
 	defaultUser = helper.GetDeploymentUser()
 	assert.Equal(t, "whoami@graphcms.com", defaultUser)
 }
+
+func TestDORASettings(t *testing.T) {
+	assert.Equal(t, "", helper.GetDORATableName())
+	assert.Equal(t, 7, helper.GetDORAWindowDays())
+	assert.Equal(t, "service", helper.GetDORAGroupBy())
+
+	os.Setenv("DORA_WINDOW_DAYS", "14")
+	os.Setenv("DORA_GROUP_BY", "team")
+	defer os.Unsetenv("DORA_WINDOW_DAYS")
+	defer os.Unsetenv("DORA_GROUP_BY")
+
+	assert.Equal(t, 14, helper.GetDORAWindowDays())
+	assert.Equal(t, "team", helper.GetDORAGroupBy())
+
+	os.Setenv("DORA_WINDOW_DAYS", "none")
+	assert.Equal(t, 7, helper.GetDORAWindowDays())
+}
+
+func TestCrashLoopSettings(t *testing.T) {
+	assert.Equal(t, "", helper.GetCrashLoopTableName())
+	assert.Equal(t, 3, helper.GetCrashLoopThreshold())
+	assert.Equal(t, 10, helper.GetCrashLoopWindowMinutes())
+	assert.Equal(t, 60, helper.GetCrashLoopDeploymentWindowMinutes())
+
+	os.Setenv("CRASH_LOOP_THRESHOLD", "5")
+	os.Setenv("CRASH_LOOP_WINDOW_MINUTES", "0")
+	defer os.Unsetenv("CRASH_LOOP_THRESHOLD")
+	defer os.Unsetenv("CRASH_LOOP_WINDOW_MINUTES")
+
+	assert.Equal(t, 5, helper.GetCrashLoopThreshold())
+	assert.Equal(t, 10, helper.GetCrashLoopWindowMinutes())
+}
+
+func TestSinkRetrySettings(t *testing.T) {
+	assert.Equal(t, 3, helper.GetSinkMaxAttempts())
+	assert.Equal(t, 500*time.Millisecond, helper.GetSinkRetryDelay())
+
+	os.Setenv("SINK_MAX_ATTEMPTS", "0")
+	os.Setenv("SINK_RETRY_DELAY_MS", "0")
+	defer os.Unsetenv("SINK_MAX_ATTEMPTS")
+	defer os.Unsetenv("SINK_RETRY_DELAY_MS")
+
+	assert.Equal(t, 3, helper.GetSinkMaxAttempts())
+	assert.Equal(t, time.Duration(0), helper.GetSinkRetryDelay())
+}
+
+func TestECRDeployScanCheck(t *testing.T) {
+	assert.True(t, helper.GetECRDeployScanCheck())
+
+	os.Setenv("ECR_DEPLOY_SCAN_CHECK", "false")
+	defer os.Unsetenv("ECR_DEPLOY_SCAN_CHECK")
+	assert.False(t, helper.GetECRDeployScanCheck())
+}
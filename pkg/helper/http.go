@@ -0,0 +1,89 @@
+package helper
+
+import (
+	"context"
+	"deployment-notifications/pkg/logger"
+	"deployment-notifications/pkg/tracing"
+	"fmt"
+	"net/http"
+	"sync"
+)
+
+// HTTPDoer sends the requests of every sink. It is satisfied by
+// *http.Client and can be replaced, e.g. to print payloads locally
+type HTTPDoer interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+type httpDoerKey struct{}
+
+// WithHTTPDoer returns a context whose sink requests are sent by doer
+func WithHTTPDoer(ctx context.Context, doer HTTPDoer) context.Context {
+	return context.WithValue(ctx, httpDoerKey{}, doer)
+}
+
+var (
+	defaultHTTPClientOnce sync.Once
+	defaultHTTPClient     *http.Client
+)
+
+// GetHTTPDoer returns the doer set on ctx, or the default client. The
+// default client is shared, so connections to the sinks are reused
+func GetHTTPDoer(ctx context.Context) HTTPDoer {
+	if doer, ok := ctx.Value(httpDoerKey{}).(HTTPDoer); ok && doer != nil {
+		return doer
+	}
+
+	defaultHTTPClientOnce.Do(func() {
+		defaultHTTPClient = NewHTTPClient()
+	})
+
+	return defaultHTTPClient
+}
+
+// IsAuthorizationStatus tells whether a sink rejected the credentials
+// of a request, which may have been rotated
+func IsAuthorizationStatus(status int) bool {
+	return status == http.StatusUnauthorized || status == http.StatusForbidden
+}
+
+// transportError is the error of a request that got no answer, which
+// may pass unless connecting was refused, e.g. to an internal address
+func transportError(errorMessage string, err error) error {
+	if GetErrorKind(err) == ErrorKindConfiguration {
+		return WrapError(errorMessage, err)
+	}
+
+	return TransientSinkError(errorMessage, err)
+}
+
+func doRequest(req *http.Request) (*http.Response, error) {
+	// sends req in a client span. The trace is not passed on, the
+	// sinks are not ours and have no use for our trace ids
+	ctx, span := tracing.Start(req.Context(), fmt.Sprintf("%s %s", req.Method, req.URL.Host), tracing.KindClient)
+	defer span.End()
+
+	span.SetAttribute("http.request.method", req.Method)
+	span.SetAttribute("server.address", req.URL.Host)
+	span.SetAttribute("url.full", req.URL.String())
+
+	if sink := logger.FieldsFrom(ctx)[logger.FieldSink]; sink != "" {
+		span.SetAttribute("sink", sink)
+	}
+
+	req = req.WithContext(ctx)
+
+	resp, err := GetHTTPDoer(ctx).Do(req)
+	if err != nil {
+		span.RecordError(err)
+		return resp, err
+	}
+
+	span.SetAttribute("http.response.status_code", resp.StatusCode)
+
+	if resp.StatusCode >= 400 {
+		span.RecordError(fmt.Errorf("status %d", resp.StatusCode))
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,278 @@
+package helper
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/lambda"
+	"github.com/aws/aws-sdk-go/service/lambda/lambdaiface"
+	"strings"
+)
+
+// DefaultSlackLambdaTemplate is used when no
+// SSM_PARAMETER_MESSAGE_SLACK_LAMBDA parameter is configured
+const DefaultSlackLambdaTemplate = `{
+   "text":"Lambda function <backquote><varbegin>.FunctionName<varend><backquote> deployed (<varbegin>.EventName<varend>)",
+   "attachments":[
+      {
+         "fallback":"Lambda function <backquote><varbegin>.FunctionName<varend><backquote> deployed (<varbegin>.EventName<varend>)",
+         "color":"#2EB886",
+         "fields":[
+            {
+               "title":"Function",
+               "value":"<varbegin>.FunctionName<varend>",
+               "short":true
+            },
+            {
+               "title":"Version",
+               "value":"<varbegin>.Version<varend>",
+               "short":true
+            },<varbegin>if .Alias<varend>
+            {
+               "title":"Alias",
+               "value":"<varbegin>.Alias<varend>",
+               "short":true
+            },<varbegin>end<varend>
+            {
+               "title":"Code SHA256",
+               "value":"<varbegin>.CodeSHA256<varend>",
+               "short":false
+            },
+            {
+               "title":"Deployed By",
+               "value":"<varbegin>.DeployedBy<varend>",
+               "short":false
+            },
+            {
+               "title":"AWS Account",
+               "value":"<varbegin>.AWSAccount<varend>",
+               "short":true
+            },
+            {
+               "title":"AWS Region",
+               "value":"<varbegin>.AWSRegion<varend>",
+               "short":true
+            },
+            {
+               "title":"Timestamp",
+               "value":"<varbegin>.EventTimestamp<varend>",
+               "short":false
+            }
+         ]
+      }
+   ]
+}`
+
+type lambdaCloudTrailDetail struct {
+	EventSource  string `json:"eventSource"`
+	EventName    string `json:"eventName"`
+	EventTime    string `json:"eventTime"`
+	EventID      string `json:"eventID"`
+	ErrorCode    string `json:"errorCode"`
+	UserIdentity struct {
+		ARN string `json:"arn"`
+	} `json:"userIdentity"`
+	RequestParameters struct {
+		FunctionName    string `json:"functionName"`
+		Name            string `json:"name"`
+		FunctionVersion string `json:"functionVersion"`
+	} `json:"requestParameters"`
+	ResponseElements struct {
+		FunctionName    string `json:"functionName"`
+		FunctionARN     string `json:"functionArn"`
+		Version         string `json:"version"`
+		CodeSHA256      string `json:"codeSha256"`
+		Description     string `json:"description"`
+		Name            string `json:"name"`
+		FunctionVersion string `json:"functionVersion"`
+	} `json:"responseElements"`
+}
+
+// LambdaDeployInfo is a Lambda deployment taken from a CloudTrail
+// UpdateFunctionCode, UpdateAlias or PublishVersion API call
+type LambdaDeployInfo struct {
+	EventName    string
+	FunctionName string
+	Version      string
+	Alias        string
+	CodeSHA256   string
+	Description  string
+	DeployedBy   string
+	EventTime    string
+}
+
+type LambdaNotificationFields struct {
+	FunctionName   string
+	EventName      string
+	Version        string
+	Alias          string
+	CodeSHA256     string
+	Description    string
+	DeployedBy     string
+	AWSReference   string
+	AWSRegion      string
+	AWSAccount     string
+	EventTimestamp string
+}
+
+func GetLambdaDeployEventName(cloudTrailEventName string) string {
+	// CloudTrail suffixes the API version, e.g. UpdateFunctionCode20150331v2
+	for _, eventName := range []string{"UpdateFunctionCode", "UpdateAlias", "PublishVersion"} {
+		if strings.HasPrefix(cloudTrailEventName, eventName) {
+			return eventName
+		}
+	}
+
+	return cloudTrailEventName
+}
+
+func GetLambdaFunctionName(functionName string) string {
+	// accepts a name, a partial ARN (<account>:function:<name>)
+	// or a full ARN, each optionally qualified with a version
+	if split := strings.Split(functionName, "function:"); len(split) == 2 {
+		functionName = split[1]
+	}
+
+	return strings.SplitN(functionName, ":", 2)[0]
+}
+
+func ParseLambdaDeployDetails(request events.CloudWatchEvent) (LambdaDeployInfo, error) {
+	var cloudTrailDetail lambdaCloudTrailDetail
+
+	err := json.Unmarshal(request.Detail, &cloudTrailDetail)
+
+	if err != nil {
+		return LambdaDeployInfo{}, WrapError("Unexpected error unmarshaling CloudTrail details", err)
+	}
+
+	if cloudTrailDetail.EventSource != "lambda.amazonaws.com" {
+		return LambdaDeployInfo{}, WrapError(fmt.Sprintf("CloudTrail event source '%s' is not Lambda",
+			cloudTrailDetail.EventSource), nil)
+	}
+
+	if cloudTrailDetail.ErrorCode != "" {
+		return LambdaDeployInfo{}, WrapError(fmt.Sprintf("Lambda API call '%s' failed with '%s'",
+			cloudTrailDetail.EventName, cloudTrailDetail.ErrorCode), nil)
+	}
+
+	functionName := cloudTrailDetail.ResponseElements.FunctionName
+	if functionName == "" {
+		functionName = cloudTrailDetail.RequestParameters.FunctionName
+	}
+
+	if functionName == "" {
+		return LambdaDeployInfo{}, WrapError("'functionName' attribute not found on payload", nil)
+	}
+
+	lambdaDeployInfo := LambdaDeployInfo{
+		EventName:    GetLambdaDeployEventName(cloudTrailDetail.EventName),
+		FunctionName: GetLambdaFunctionName(functionName),
+		Version:      cloudTrailDetail.ResponseElements.Version,
+		CodeSHA256:   cloudTrailDetail.ResponseElements.CodeSHA256,
+		Description:  cloudTrailDetail.ResponseElements.Description,
+		DeployedBy:   cloudTrailDetail.UserIdentity.ARN,
+		EventTime:    cloudTrailDetail.EventTime,
+	}
+
+	if lambdaDeployInfo.EventName == "UpdateAlias" {
+		lambdaDeployInfo.Alias = cloudTrailDetail.ResponseElements.Name
+		lambdaDeployInfo.Version = cloudTrailDetail.ResponseElements.FunctionVersion
+
+		if lambdaDeployInfo.Alias == "" {
+			lambdaDeployInfo.Alias = cloudTrailDetail.RequestParameters.Name
+		}
+		if lambdaDeployInfo.Version == "" {
+			lambdaDeployInfo.Version = cloudTrailDetail.RequestParameters.FunctionVersion
+		}
+	}
+
+	return lambdaDeployInfo, nil
+}
+
+func GetTrackedLambdaDeployEvents() []string {
+	trackedEvents := GetStringEnv("LAMBDA_DEPLOY_EVENTS", "UpdateFunctionCode,UpdateAlias,PublishVersion")
+
+	var result []string
+	for _, eventName := range strings.Split(trackedEvents, ",") {
+		if trimmed := strings.TrimSpace(eventName); trimmed != "" {
+			result = append(result, trimmed)
+		}
+	}
+
+	return result
+}
+
+func IsTrackedLambdaDeployEvent(eventName string) bool {
+	for _, trackedEvent := range GetTrackedLambdaDeployEvents() {
+		if trackedEvent == eventName {
+			return true
+		}
+	}
+
+	return false
+}
+
+func GetLambdaMappingKeys(lambdaDeployInfo LambdaDeployInfo) []string {
+	// keys tried, in order, against the New Relic and Slack mappings
+	if lambdaDeployInfo.Alias != "" {
+		return []string{fmt.Sprintf("%s:%s", lambdaDeployInfo.FunctionName, lambdaDeployInfo.Alias),
+			lambdaDeployInfo.FunctionName}
+	}
+
+	return []string{lambdaDeployInfo.FunctionName}
+}
+
+func GetLambdaCodeSHA(functionName, version string, client lambdaiface.LambdaAPI) (string, error) {
+	// alias updates don't carry the code SHA of the version they point to
+	output, err := client.GetFunctionConfiguration(&lambda.GetFunctionConfigurationInput{
+		FunctionName: aws.String(functionName),
+		Qualifier:    aws.String(version),
+	})
+
+	if err != nil {
+		return "", fmt.Errorf("error getting configuration of Lambda function '%s:%s': %w", functionName, version, err)
+	}
+
+	return aws.StringValue(output.CodeSha256), nil
+}
+
+func GenerateLambdaNotificationStruct(request events.CloudWatchEvent,
+	lambdaDeployInfo LambdaDeployInfo) LambdaNotificationFields {
+	eventTimestamp := lambdaDeployInfo.EventTime
+	if eventTimestamp == "" {
+		eventTimestamp = request.Time.UTC().Format("2006-01-02T15:04:05Z")
+	}
+
+	return LambdaNotificationFields{
+		FunctionName:   lambdaDeployInfo.FunctionName,
+		EventName:      lambdaDeployInfo.EventName,
+		Version:        lambdaDeployInfo.Version,
+		Alias:          lambdaDeployInfo.Alias,
+		CodeSHA256:     lambdaDeployInfo.CodeSHA256,
+		Description:    EscapeJSONString(lambdaDeployInfo.Description),
+		DeployedBy:     lambdaDeployInfo.DeployedBy,
+		AWSReference:   request.ID,
+		AWSRegion:      request.Region,
+		AWSAccount:     request.AccountID,
+		EventTimestamp: eventTimestamp,
+	}
+}
+
+func GetLambdaNewRelicPayload(request events.CloudWatchEvent, notificationFields LambdaNotificationFields) map[string]string {
+	result := make(map[string]string)
+
+	revision := notificationFields.Version
+	if notificationFields.Alias != "" {
+		revision = fmt.Sprintf("%s (%s)", notificationFields.Version, notificationFields.Alias)
+	}
+
+	result["revision"] = revision
+	result["timestamp"] = notificationFields.EventTimestamp
+	result["user"] = GetDeploymentUser()
+	result["description"] = fmt.Sprintf("Lambda %s %s, AWS Account: %s, Region: %s, Deployment ID: %s",
+		notificationFields.EventName, notificationFields.FunctionName, request.AccountID, request.Region, request.ID)
+	result["changelog"] = fmt.Sprintf("Code SHA256: %s", notificationFields.CodeSHA256)
+
+	return result
+}
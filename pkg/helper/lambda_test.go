@@ -0,0 +1,160 @@
+package helper_test
+
+import (
+	"deployment-notifications/pkg/helper"
+	"encoding/json"
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"testing"
+)
+
+const sampleLambdaPublishVersionEvent = `
+{
+   "version": "0",
+   "id": "2f7d1f0a-1b2c-4d5e-8f90-123456789abc",
+   "detail-type": "AWS API Call via CloudTrail",
+   "source": "aws.lambda",
+   "account": "111122223333",
+   "time": "2020-05-23T12:31:14Z",
+   "region": "us-west-2",
+   "resources": [],
+   "detail": {
+        "eventVersion": "1.08",
+        "userIdentity": {
+            "type": "AssumedRole",
+            "arn": "arn:aws:sts::111122223333:assumed-role/deployer/ci"
+        },
+        "eventTime": "2020-05-23T12:31:13Z",
+        "eventSource": "lambda.amazonaws.com",
+        "eventName": "PublishVersion20150331",
+        "awsRegion": "us-west-2",
+        "requestParameters": {
+            "functionName": "content-webhooks"
+        },
+        "responseElements": {
+            "functionName": "content-webhooks",
+            "functionArn": "arn:aws:lambda:us-west-2:111122223333:function:content-webhooks:7",
+            "version": "7",
+            "codeSha256": "Rj9AbDCs1+p6Tq4ZwS0V0VbGGRjDkBk9wIAhOoj0P/Y=",
+            "description": "Release \"2020.05\""
+        },
+        "eventID": "8a7f9e3c-0000-4000-8000-000000000000"
+   }
+}
+`
+
+const sampleLambdaUpdateAliasDetail = `
+{
+    "userIdentity": {"arn": "arn:aws:sts::111122223333:assumed-role/deployer/ci"},
+    "eventTime": "2020-05-23T12:35:00Z",
+    "eventSource": "lambda.amazonaws.com",
+    "eventName": "UpdateAlias20150331",
+    "requestParameters": {
+        "functionName": "arn:aws:lambda:us-west-2:111122223333:function:content-webhooks",
+        "name": "live",
+        "functionVersion": "7"
+    },
+    "responseElements": {
+        "aliasArn": "arn:aws:lambda:us-west-2:111122223333:function:content-webhooks:live",
+        "name": "live",
+        "functionVersion": "7"
+    }
+}
+`
+
+func TestLambdaFunctionName(t *testing.T) {
+	assert.Equal(t, "content-webhooks", helper.GetLambdaFunctionName("content-webhooks"))
+	assert.Equal(t, "content-webhooks", helper.GetLambdaFunctionName("content-webhooks:live"))
+	assert.Equal(t, "content-webhooks", helper.GetLambdaFunctionName("111122223333:function:content-webhooks"))
+	assert.Equal(t, "content-webhooks",
+		helper.GetLambdaFunctionName("arn:aws:lambda:us-west-2:111122223333:function:content-webhooks:7"))
+
+	assert.Equal(t, "UpdateFunctionCode", helper.GetLambdaDeployEventName("UpdateFunctionCode20150331v2"))
+	assert.Equal(t, "UpdateAlias", helper.GetLambdaDeployEventName("UpdateAlias20150331"))
+	assert.Equal(t, "Invoke", helper.GetLambdaDeployEventName("Invoke"))
+}
+
+func TestParseLambdaDeployDetails(t *testing.T) {
+	var cloudwatchEvent events.CloudWatchEvent
+	err := json.Unmarshal([]byte(sampleLambdaPublishVersionEvent), &cloudwatchEvent)
+	assert.Nil(t, err)
+
+	lambdaDeployInfo, err := helper.ParseLambdaDeployDetails(cloudwatchEvent)
+	assert.Nil(t, err)
+	assert.Equal(t, "PublishVersion", lambdaDeployInfo.EventName)
+	assert.Equal(t, "content-webhooks", lambdaDeployInfo.FunctionName)
+	assert.Equal(t, "7", lambdaDeployInfo.Version)
+	assert.Equal(t, "", lambdaDeployInfo.Alias)
+	assert.Equal(t, "Rj9AbDCs1+p6Tq4ZwS0V0VbGGRjDkBk9wIAhOoj0P/Y=", lambdaDeployInfo.CodeSHA256)
+	assert.Equal(t, "arn:aws:sts::111122223333:assumed-role/deployer/ci", lambdaDeployInfo.DeployedBy)
+	assert.Equal(t, []string{"content-webhooks"}, helper.GetLambdaMappingKeys(lambdaDeployInfo))
+
+	cloudwatchEvent.Detail = json.RawMessage(sampleLambdaUpdateAliasDetail)
+	lambdaDeployInfo, err = helper.ParseLambdaDeployDetails(cloudwatchEvent)
+	assert.Nil(t, err)
+	assert.Equal(t, "UpdateAlias", lambdaDeployInfo.EventName)
+	assert.Equal(t, "content-webhooks", lambdaDeployInfo.FunctionName)
+	assert.Equal(t, "7", lambdaDeployInfo.Version)
+	assert.Equal(t, "live", lambdaDeployInfo.Alias)
+	assert.Equal(t, []string{"content-webhooks:live", "content-webhooks"}, helper.GetLambdaMappingKeys(lambdaDeployInfo))
+
+	cloudwatchEvent.Detail = json.RawMessage(`{"eventSource": "lambda.amazonaws.com", ` +
+		`"eventName": "UpdateFunctionCode20150331v2", "errorCode": "AccessDenied", ` +
+		`"requestParameters": {"functionName": "content-webhooks"}}`)
+	_, err = helper.ParseLambdaDeployDetails(cloudwatchEvent)
+	assert.NotNil(t, err)
+
+	cloudwatchEvent.Detail = json.RawMessage(`{"eventSource": "s3.amazonaws.com", "eventName": "PutObject"}`)
+	_, err = helper.ParseLambdaDeployDetails(cloudwatchEvent)
+	assert.NotNil(t, err)
+}
+
+func TestTrackedLambdaDeployEvents(t *testing.T) {
+	currentEvents := os.Getenv("LAMBDA_DEPLOY_EVENTS")
+	defer os.Setenv("LAMBDA_DEPLOY_EVENTS", currentEvents)
+
+	os.Unsetenv("LAMBDA_DEPLOY_EVENTS")
+	assert.True(t, helper.IsTrackedLambdaDeployEvent("UpdateFunctionCode"))
+	assert.True(t, helper.IsTrackedLambdaDeployEvent("UpdateAlias"))
+	assert.True(t, helper.IsTrackedLambdaDeployEvent("PublishVersion"))
+	assert.False(t, helper.IsTrackedLambdaDeployEvent("UpdateFunctionConfiguration"))
+
+	os.Setenv("LAMBDA_DEPLOY_EVENTS", "UpdateAlias")
+	assert.False(t, helper.IsTrackedLambdaDeployEvent("PublishVersion"))
+}
+
+func TestLambdaNotification(t *testing.T) {
+	var cloudwatchEvent events.CloudWatchEvent
+	err := json.Unmarshal([]byte(sampleLambdaPublishVersionEvent), &cloudwatchEvent)
+	assert.Nil(t, err)
+
+	currentDeploymentUser := os.Getenv("DEPLOYMENT_USER")
+	os.Unsetenv("DEPLOYMENT_USER")
+	defer os.Setenv("DEPLOYMENT_USER", currentDeploymentUser)
+
+	lambdaDeployInfo, _ := helper.ParseLambdaDeployDetails(cloudwatchEvent)
+	slackStruct := helper.GenerateLambdaNotificationStruct(cloudwatchEvent, lambdaDeployInfo)
+	assert.Equal(t, "2020-05-23T12:31:13Z", slackStruct.EventTimestamp)
+	assert.Equal(t, `Release \"2020.05\"`, slackStruct.Description)
+
+	parsedMessage, err := helper.GeneratePayload(helper.DefaultSlackLambdaTemplate, slackStruct, true)
+	assert.Nil(t, err)
+	assert.True(t, json.Valid([]byte(parsedMessage)))
+	assert.Contains(t, parsedMessage, "Lambda function `content-webhooks` deployed (PublishVersion)")
+	assert.NotContains(t, parsedMessage, `"title":"Alias"`)
+
+	newRelicMap := helper.GetLambdaNewRelicPayload(cloudwatchEvent, slackStruct)
+	assert.Equal(t, "7", newRelicMap["revision"])
+	assert.Equal(t, "services@graphcms.com", newRelicMap["user"])
+	assert.Equal(t, "Code SHA256: Rj9AbDCs1+p6Tq4ZwS0V0VbGGRjDkBk9wIAhOoj0P/Y=", newRelicMap["changelog"])
+	assert.Equal(t, "Lambda PublishVersion content-webhooks, AWS Account: 111122223333, Region: us-west-2, "+
+		"Deployment ID: 2f7d1f0a-1b2c-4d5e-8f90-123456789abc", newRelicMap["description"])
+
+	slackStruct.Alias = "live"
+	parsedMessage, err = helper.GeneratePayload(helper.DefaultSlackLambdaTemplate, slackStruct, true)
+	assert.Nil(t, err)
+	assert.True(t, json.Valid([]byte(parsedMessage)))
+	assert.Contains(t, parsedMessage, `"title":"Alias"`)
+	assert.Equal(t, "7 (live)", helper.GetLambdaNewRelicPayload(cloudwatchEvent, slackStruct)["revision"])
+}
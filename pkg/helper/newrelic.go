@@ -2,18 +2,25 @@ package helper
 
 import (
 	"bytes"
+	"context"
+	"deployment-notifications/pkg/logger"
 	"encoding/json"
 	"fmt"
 	"github.com/aws/aws-lambda-go/events"
 	"io/ioutil"
-	"log"
 	"net/http"
-	"time"
+	"strings"
 )
 
-func GetNewRelicDeploymentURL(baseDomain, appID string) string {
-	return fmt.Sprintf("https://%s/v2/applications/%s/deployments.json",
-		baseDomain, appID)
+// GetNewRelicBaseURL is the root of the REST API of a New Relic region,
+// e.g. "api.eu.newrelic.com"
+func GetNewRelicBaseURL(baseDomain string) string {
+	return fmt.Sprintf("https://%s", baseDomain)
+}
+
+func GetNewRelicDeploymentURL(baseURL, appID string) string {
+	return fmt.Sprintf("%s/v2/applications/%s/deployments.json",
+		strings.TrimSuffix(baseURL, "/"), appID)
 }
 
 func GetNewRelicPayload(request events.CloudWatchEvent) map[string]string {
@@ -30,46 +37,53 @@ func GetNewRelicPayload(request events.CloudWatchEvent) map[string]string {
 	return result
 }
 
-func PostNewRelicDeployment(payload map[string]string,
-	baseDomain, appID, apiKey string) (int, error) {
+func GetNewRelicDeploymentBody(payload map[string]string) ([]byte, error) {
+	// adds the "deployment" meta-key the API expects
+	finalPayload := make(map[string]map[string]string)
+	finalPayload["deployment"] = payload
+
+	return json.Marshal(finalPayload)
+}
+
+func PostNewRelicDeployment(ctx context.Context, payload map[string]string,
+	baseURL, appID, apiKey string) (int, error) {
 	// posts deployment payload to the New Relic application deployment
 	// section. It adds the "deployment" meta-key
 	// Do not include that in the input payload
 
-	deploymentURL := GetNewRelicDeploymentURL(baseDomain, appID)
+	deploymentURL := GetNewRelicDeploymentURL(baseURL, appID)
 
-	finalPayload := make(map[string]map[string]string)
-	finalPayload["deployment"] = payload
-	finalPayloadBytes, err := json.Marshal(finalPayload)
+	if err := CheckDestination(SinkNewRelic, deploymentURL); err != nil {
+		return 999, err
+	}
+
+	finalPayloadBytes, err := GetNewRelicDeploymentBody(payload)
 
 	if err != nil {
-		return 999, WrapError("Error marshaling New Relic deployment payload into bytes", err)
+		return 999, PermanentSinkError("Error marshaling New Relic deployment payload into bytes", err)
 	}
 
-	req, err := http.NewRequest("POST", deploymentURL, bytes.NewBuffer(finalPayloadBytes))
+	req, err := http.NewRequestWithContext(ctx, "POST", deploymentURL, bytes.NewBuffer(finalPayloadBytes))
 
 	if err != nil {
-		return 999, WrapError("Error formatting new request for New Relic deployment", err)
+		return 999, PermanentSinkError("Error formatting new request for New Relic deployment", err)
 	}
 
 	req.Header.Set("Api-Key", apiKey)
 	req.Header.Set("Content-Type", "application/json")
 
-	client := &http.Client{}
-	client.Timeout = time.Second * time.Duration(GetDefaultHTTPTimeout())
-
-	resp, err := client.Do(req)
+	resp, err := doRequest(req)
 	if err != nil {
-		return resp.StatusCode, WrapError("Error making final New Relic request", err)
+		return 500, transportError("Error making final New Relic request", err)
 	}
 	defer resp.Body.Close()
 
-	log.Printf("Response Status: %d", resp.StatusCode)
 	respBody, _ := ioutil.ReadAll(resp.Body)
-	log.Printf("Response Body: %s", string(respBody))
+	logger.Infof(ctx, "New Relic response status: %d", resp.StatusCode)
+	logger.Debugf(ctx, "New Relic response body: %s", string(respBody))
 
 	if resp.StatusCode != 201 {
-		return resp.StatusCode, WrapError("New Relic final submission failed", nil)
+		return resp.StatusCode, SinkStatusError("New Relic final submission failed", resp.StatusCode)
 	}
 	return resp.StatusCode, nil
 }
@@ -10,7 +10,7 @@ import (
 )
 
 func TestGetNewRelicDeploymentURL(t *testing.T) {
-	deploymentURL := helper.GetNewRelicDeploymentURL("onenewrelic.com", "12345")
+	deploymentURL := helper.GetNewRelicDeploymentURL(helper.GetNewRelicBaseURL("onenewrelic.com"), "12345")
 
 	assert.Equal(t, "https://onenewrelic.com/v2/applications/12345/deployments.json", deploymentURL)
 }
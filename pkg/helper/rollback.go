@@ -0,0 +1,188 @@
+package helper
+
+import (
+	"fmt"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ecs"
+	"regexp"
+	"strings"
+)
+
+// DefaultSlackRollbackTemplate is used for rollbacks when no
+// SSM_PARAMETER_MESSAGE_SLACK_ROLLBACK parameter is configured
+const DefaultSlackRollbackTemplate = `{
+   "text":"<!here> :rotating_light: ECS deployment circuit breaker rolled back <backquote><varbegin>.ServiceName<varend><backquote>",
+   "attachments":[
+      {
+         "fallback":"ROLLBACK of <backquote><varbegin>.ServiceName<varend><backquote>",
+         "color":"#D00000",
+         "fields":[
+            {
+               "title":"Service",
+               "value":"<varbegin>.ServiceName<varend>",
+               "short":false
+            },
+            {
+               "title":"Failed Revision",
+               "value":"<varbegin>.FailedRevision<varend>",
+               "short":true
+            },
+            {
+               "title":"Rolled Back To",
+               "value":"<varbegin>.RollbackTarget<varend>",
+               "short":true
+            },
+            {
+               "title":"Deployment",
+               "value":"<varbegin>.DeploymentRevision<varend>",
+               "short":false
+            },
+            {
+               "title":"AWS Account",
+               "value":"<varbegin>.AWSAccount<varend>",
+               "short":true
+            },
+            {
+               "title":"AWS Region",
+               "value":"<varbegin>.AWSRegion<varend>",
+               "short":true
+            },
+            {
+               "title":"Description",
+               "value":"<varbegin>.DeploymentDescription<varend>",
+               "short":false
+            },
+            {
+               "title":"Timestamp",
+               "value":"<varbegin>.DeploymentTimestamp<varend>",
+               "short":false
+            }
+         ]
+      }
+   ]
+}`
+
+var rollbackTargetPattern = regexp.MustCompile(`deploymentId\s+(ecs-svc/[0-9A-Za-z]+)`)
+
+type RollbackInfo struct {
+	IsRollback     bool
+	FailedRevision string
+	RollbackTarget string
+}
+
+func IsRollbackReason(reason string) bool {
+	lowerReason := strings.ToLower(reason)
+
+	return strings.Contains(lowerReason, "circuit breaker") &&
+		(strings.Contains(lowerReason, "rolling back") || strings.Contains(lowerReason, "rollback") ||
+			strings.Contains(lowerReason, "rolled back"))
+}
+
+// IsRollbackEvent tells whether the event is the one a circuit breaker
+// rollback is notified on. The breaker announces a rollback on both the
+// in-progress event of the rollback and the failed event of the
+// deployment, only the terminal failed event is taken
+func IsRollbackEvent(eventDetails EventInfo) bool {
+	return eventDetails.EventName == "SERVICE_DEPLOYMENT_FAILED" && IsRollbackReason(eventDetails.Reason)
+}
+
+func DetectRollback(eventDetails EventInfo, deployments []*ecs.Deployment) RollbackInfo {
+	// a rollback is either announced by the circuit breaker in the event
+	// reason, or visible on the service as the event's deployment running
+	// an older revision of the task definition than a failed deployment
+	var rollbackInfo RollbackInfo
+
+	if IsRollbackReason(eventDetails.Reason) {
+		rollbackInfo.IsRollback = true
+
+		if match := rollbackTargetPattern.FindStringSubmatch(eventDetails.Reason); match != nil {
+			rollbackInfo.RollbackTarget = match[1]
+		}
+	}
+
+	if IsRollbackEvent(eventDetails) {
+		return describeFailedDeployment(eventDetails, deployments, rollbackInfo)
+	}
+
+	var currentDeployment *ecs.Deployment
+
+	for _, deployment := range deployments {
+		if aws.StringValue(deployment.Id) == eventDetails.DeploymentID {
+			currentDeployment = deployment
+		}
+	}
+
+	if currentDeployment == nil {
+		return rollbackInfo
+	}
+
+	currentFamily, currentRevision, err := ParseTaskDefinitionRevision(aws.StringValue(currentDeployment.TaskDefinition))
+
+	if err != nil {
+		return rollbackInfo
+	}
+
+	rollbackInfo.RollbackTarget = fmt.Sprintf("%s:%d", currentFamily, currentRevision)
+
+	for _, deployment := range deployments {
+		if deployment == currentDeployment {
+			continue
+		}
+
+		family, revision, err := ParseTaskDefinitionRevision(aws.StringValue(deployment.TaskDefinition))
+
+		if err != nil || family != currentFamily || revision <= currentRevision {
+			continue
+		}
+
+		if aws.StringValue(deployment.RolloutState) == ecs.DeploymentRolloutStateFailed || rollbackInfo.IsRollback {
+			rollbackInfo.IsRollback = true
+			rollbackInfo.FailedRevision = fmt.Sprintf("%s:%d", family, revision)
+		}
+	}
+
+	if !rollbackInfo.IsRollback {
+		// a newer revision alone is not a rollback, so
+		// do not report a target for a regular deployment
+		return RollbackInfo{}
+	}
+
+	return rollbackInfo
+}
+
+// describeFailedDeployment fills in the revisions of a rollback announced
+// on the failed event, which is about the failed deployment rather than
+// the one rolled back to
+func describeFailedDeployment(eventDetails EventInfo, deployments []*ecs.Deployment,
+	rollbackInfo RollbackInfo) RollbackInfo {
+	targetDeploymentID := rollbackInfo.RollbackTarget
+
+	for _, deployment := range deployments {
+		family, revision, err := ParseTaskDefinitionRevision(aws.StringValue(deployment.TaskDefinition))
+
+		if err != nil {
+			continue
+		}
+
+		switch aws.StringValue(deployment.Id) {
+		case eventDetails.DeploymentID:
+			rollbackInfo.FailedRevision = fmt.Sprintf("%s:%d", family, revision)
+		case targetDeploymentID:
+			rollbackInfo.RollbackTarget = fmt.Sprintf("%s:%d", family, revision)
+		}
+	}
+
+	return rollbackInfo
+}
+
+func MarkNewRelicPayloadRollback(payload map[string]string, rollbackInfo RollbackInfo) map[string]string {
+	if !rollbackInfo.IsRollback {
+		return payload
+	}
+
+	payload["description"] = fmt.Sprintf("ROLLBACK: %s", payload["description"])
+	payload["changelog"] = fmt.Sprintf("Circuit breaker rolled back from '%s' to '%s'. %s",
+		rollbackInfo.FailedRevision, rollbackInfo.RollbackTarget, payload["changelog"])
+
+	return payload
+}
@@ -0,0 +1,152 @@
+package helper_test
+
+import (
+	"deployment-notifications/pkg/helper"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ecs"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestIsRollbackReason(t *testing.T) {
+	assert.True(t, helper.IsRollbackReason("ECS deployment circuit breaker: rolling back to deploymentId ecs-svc/123."))
+	assert.False(t, helper.IsRollbackReason("ECS deployment circuit breaker: task failed to start."))
+	assert.False(t, helper.IsRollbackReason("ECS deployment ecs-svc/123 completed."))
+}
+
+func TestIsRollbackEvent(t *testing.T) {
+	reason := "ECS deployment circuit breaker: rolling back to deploymentId ecs-svc/123."
+
+	assert.True(t, helper.IsRollbackEvent(helper.EventInfo{EventName: "SERVICE_DEPLOYMENT_FAILED", Reason: reason}))
+	assert.False(t, helper.IsRollbackEvent(helper.EventInfo{EventName: "SERVICE_DEPLOYMENT_IN_PROGRESS", Reason: reason}))
+	assert.False(t, helper.IsRollbackEvent(helper.EventInfo{EventName: "SERVICE_DEPLOYMENT_FAILED",
+		Reason: "ECS deployment circuit breaker: task failed to start."}))
+}
+
+func TestDetectRollbackFromFailedEvent(t *testing.T) {
+	eventDetails := helper.EventInfo{
+		EventName:    "SERVICE_DEPLOYMENT_FAILED",
+		DeploymentID: "ecs-svc/456",
+		Reason:       "ECS deployment circuit breaker: rolling back to deploymentId ecs-svc/123.",
+	}
+
+	deployments := []*ecs.Deployment{
+		{
+			Id:             aws.String("ecs-svc/456"),
+			TaskDefinition: aws.String("arn:aws:ecs:us-west-2:111122223333:task-definition/content-api:12"),
+			RolloutState:   aws.String(ecs.DeploymentRolloutStateFailed),
+		},
+		{
+			Id:             aws.String("ecs-svc/123"),
+			TaskDefinition: aws.String("arn:aws:ecs:us-west-2:111122223333:task-definition/content-api:11"),
+			RolloutState:   aws.String(ecs.DeploymentRolloutStateInProgress),
+		},
+	}
+
+	rollbackInfo := helper.DetectRollback(eventDetails, deployments)
+
+	assert.True(t, rollbackInfo.IsRollback)
+	assert.Equal(t, "content-api:12", rollbackInfo.FailedRevision)
+	assert.Equal(t, "content-api:11", rollbackInfo.RollbackTarget)
+}
+
+func TestDetectRollbackFromReason(t *testing.T) {
+	eventDetails := helper.EventInfo{
+		EventName:    "SERVICE_DEPLOYMENT_IN_PROGRESS",
+		DeploymentID: "ecs-svc/456",
+		Reason:       "ECS deployment circuit breaker: rolling back to deploymentId ecs-svc/123.",
+	}
+
+	rollbackInfo := helper.DetectRollback(eventDetails, nil)
+
+	assert.True(t, rollbackInfo.IsRollback)
+	assert.Equal(t, "ecs-svc/123", rollbackInfo.RollbackTarget)
+	assert.Equal(t, "", rollbackInfo.FailedRevision)
+}
+
+func TestDetectRollbackFromRevisions(t *testing.T) {
+	eventDetails := helper.EventInfo{
+		EventName:    "SERVICE_DEPLOYMENT_COMPLETED",
+		DeploymentID: "ecs-svc/456",
+		Reason:       "ECS deployment ecs-svc/456 completed.",
+	}
+
+	deployments := []*ecs.Deployment{
+		{
+			Id:             aws.String("ecs-svc/456"),
+			TaskDefinition: aws.String("arn:aws:ecs:us-west-2:111122223333:task-definition/content-api:11"),
+			RolloutState:   aws.String(ecs.DeploymentRolloutStateCompleted),
+		},
+		{
+			Id:             aws.String("ecs-svc/789"),
+			TaskDefinition: aws.String("arn:aws:ecs:us-west-2:111122223333:task-definition/content-api:12"),
+			RolloutState:   aws.String(ecs.DeploymentRolloutStateFailed),
+		},
+	}
+
+	rollbackInfo := helper.DetectRollback(eventDetails, deployments)
+
+	assert.True(t, rollbackInfo.IsRollback)
+	assert.Equal(t, "content-api:12", rollbackInfo.FailedRevision)
+	assert.Equal(t, "content-api:11", rollbackInfo.RollbackTarget)
+}
+
+func TestDetectRollbackRegularDeployment(t *testing.T) {
+	eventDetails := helper.EventInfo{
+		EventName:    "SERVICE_DEPLOYMENT_COMPLETED",
+		DeploymentID: "ecs-svc/789",
+		Reason:       "ECS deployment ecs-svc/789 completed.",
+	}
+
+	deployments := []*ecs.Deployment{
+		{
+			Id:             aws.String("ecs-svc/789"),
+			TaskDefinition: aws.String("arn:aws:ecs:us-west-2:111122223333:task-definition/content-api:12"),
+			RolloutState:   aws.String(ecs.DeploymentRolloutStateCompleted),
+		},
+		{
+			Id:             aws.String("ecs-svc/456"),
+			TaskDefinition: aws.String("arn:aws:ecs:us-west-2:111122223333:task-definition/content-api:11"),
+			RolloutState:   aws.String(ecs.DeploymentRolloutStateCompleted),
+		},
+	}
+
+	rollbackInfo := helper.DetectRollback(eventDetails, deployments)
+
+	assert.Equal(t, helper.RollbackInfo{}, rollbackInfo)
+}
+
+func TestMarkNewRelicPayloadRollback(t *testing.T) {
+	payload := map[string]string{
+		"description": "AWS Account: 111122223333",
+		"changelog":   "ECS deployment ecs-svc/456 completed.",
+	}
+
+	unchanged := helper.MarkNewRelicPayloadRollback(map[string]string{"description": "same"}, helper.RollbackInfo{})
+	assert.Equal(t, "same", unchanged["description"])
+
+	payload = helper.MarkNewRelicPayloadRollback(payload, helper.RollbackInfo{
+		IsRollback:     true,
+		FailedRevision: "content-api:12",
+		RollbackTarget: "content-api:11",
+	})
+
+	assert.Equal(t, "ROLLBACK: AWS Account: 111122223333", payload["description"])
+	assert.Equal(t, "Circuit breaker rolled back from 'content-api:12' to 'content-api:11'. "+
+		"ECS deployment ecs-svc/456 completed.", payload["changelog"])
+}
+
+func TestSlackRollbackTemplate(t *testing.T) {
+	slackStruct := helper.SlackNotificationFields{
+		ServiceName:    "content-api",
+		IsRollback:     true,
+		FailedRevision: "content-api:12",
+		RollbackTarget: "content-api:11",
+	}
+
+	parsedMessage, err := helper.GeneratePayload(helper.DefaultSlackRollbackTemplate, slackStruct, true)
+	assert.Nil(t, err)
+	assert.Contains(t, parsedMessage, "rolled back `content-api`")
+	assert.Contains(t, parsedMessage, `"value":"content-api:12"`)
+	assert.Contains(t, parsedMessage, `"value":"content-api:11"`)
+}
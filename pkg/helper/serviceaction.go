@@ -0,0 +1,204 @@
+package helper
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/aws/aws-lambda-go/events"
+	"strings"
+)
+
+// DefaultServiceActionTemplates are used for any service action event that
+// has no template in the SSM_PARAMETER_MESSAGE_SLACK_SERVICE_ACTION parameter
+var DefaultServiceActionTemplates = map[string]string{
+	"SERVICE_STEADY_STATE": `{
+   "text":":white_check_mark: <backquote><varbegin>.ServiceName<varend><backquote> reached a steady state in <backquote><varbegin>.ClusterName<varend><backquote>"
+}`,
+	"SERVICE_TASK_PLACEMENT_FAILURE": `{
+   "text":"<!here> :x: ECS could not place tasks for <backquote><varbegin>.ServiceName<varend><backquote>",
+   "attachments":[
+      {
+         "fallback":"Task placement failure for <backquote><varbegin>.ServiceName<varend><backquote>",
+         "color":"#D00000",
+         "fields":[
+            {
+               "title":"Service",
+               "value":"<varbegin>.ServiceName<varend>",
+               "short":true
+            },
+            {
+               "title":"Cluster",
+               "value":"<varbegin>.ClusterName<varend>",
+               "short":true
+            },
+            {
+               "title":"Reasons",
+               "value":"<varbegin>.Reasons<varend>",
+               "short":false
+            },
+            {
+               "title":"Last Deployment",
+               "value":"<varbegin>.DeploymentID<varend> <varbegin>.DeploymentStartedAt<varend>",
+               "short":false
+            },
+            {
+               "title":"AWS Account",
+               "value":"<varbegin>.AWSAccount<varend>",
+               "short":true
+            },
+            {
+               "title":"AWS Region",
+               "value":"<varbegin>.AWSRegion<varend>",
+               "short":true
+            },
+            {
+               "title":"Timestamp",
+               "value":"<varbegin>.EventTimestamp<varend>",
+               "short":false
+            }
+         ]
+      }
+   ]
+}`,
+	"SERVICE_DISCOVERY_INSTANCE_UNHEALTHY": `{
+   "text":"<!here> :warning: Service discovery reports unhealthy instances for <backquote><varbegin>.ServiceName<varend><backquote>",
+   "attachments":[
+      {
+         "fallback":"Unhealthy service discovery instance for <backquote><varbegin>.ServiceName<varend><backquote>",
+         "color":"#FFA500",
+         "fields":[
+            {
+               "title":"Service",
+               "value":"<varbegin>.ServiceName<varend>",
+               "short":true
+            },
+            {
+               "title":"Cluster",
+               "value":"<varbegin>.ClusterName<varend>",
+               "short":true
+            },
+            {
+               "title":"Last Deployment",
+               "value":"<varbegin>.DeploymentID<varend> <varbegin>.DeploymentStartedAt<varend>",
+               "short":false
+            },
+            {
+               "title":"Timestamp",
+               "value":"<varbegin>.EventTimestamp<varend>",
+               "short":false
+            }
+         ]
+      }
+   ]
+}`,
+}
+
+type ServiceActionInfo struct {
+	EventType            string   `json:"eventType"`
+	EventName            string   `json:"eventName"`
+	ClusterARN           string   `json:"clusterArn"`
+	CreatedAt            string   `json:"createdAt"`
+	Reasons              []string `json:"reasons"`
+	CapacityProviderARNs []string `json:"capacityProviderArns"`
+	ServiceRegistryARNs  []string `json:"serviceRegistryArns"`
+}
+
+type ServiceActionNotificationFields struct {
+	ServiceName         string
+	ClusterName         string
+	EventName           string
+	EventType           string
+	Reasons             string
+	DeploymentID        string
+	DeploymentStartedAt string
+	AWSReference        string
+	AWSRegion           string
+	AWSAccount          string
+	EventTimestamp      string
+}
+
+func ParseServiceActionDetails(request events.CloudWatchEvent) (ServiceActionInfo, error) {
+	var serviceActionInfo ServiceActionInfo
+
+	err := json.Unmarshal(request.Detail, &serviceActionInfo)
+
+	if err != nil {
+		return serviceActionInfo, WrapError("Unexpected error unmarshaling service action details", err)
+	}
+
+	if serviceActionInfo.EventName == "" {
+		return serviceActionInfo, WrapError("'eventName' attribute not found on payload", nil)
+	}
+
+	if len(request.Resources) == 0 {
+		return serviceActionInfo, WrapError("No service ARN found in event resources", nil)
+	}
+
+	return serviceActionInfo, nil
+}
+
+func GetTrackedServiceActionEvents() []string {
+	trackedEvents := GetStringEnv("ECS_SERVICE_ACTION_EVENTS",
+		"SERVICE_STEADY_STATE,SERVICE_TASK_PLACEMENT_FAILURE,SERVICE_DISCOVERY_INSTANCE_UNHEALTHY")
+
+	var result []string
+	for _, eventName := range strings.Split(trackedEvents, ",") {
+		if trimmed := strings.TrimSpace(eventName); trimmed != "" {
+			result = append(result, trimmed)
+		}
+	}
+
+	return result
+}
+
+func IsTrackedServiceAction(eventName string) bool {
+	for _, trackedEvent := range GetTrackedServiceActionEvents() {
+		if trackedEvent == eventName {
+			return true
+		}
+	}
+
+	return false
+}
+
+func SelectServiceActionTemplate(templates map[string]string, serviceName, eventName string) (string, error) {
+	// most specific wins: "<service>:<event>", then "<event>",
+	// then the configured "default", then the built-in template
+	for _, key := range []string{fmt.Sprintf("%s:%s", serviceName, eventName), eventName, "default"} {
+		if template, ok := templates[key]; ok {
+			return template, nil
+		}
+	}
+
+	if template, ok := DefaultServiceActionTemplates[eventName]; ok {
+		return template, nil
+	}
+
+	return "", ConfigurationError(fmt.Sprintf("No Slack template found for service action '%s'", eventName), nil)
+}
+
+func GenerateServiceActionNotificationStruct(request events.CloudWatchEvent,
+	serviceActionInfo ServiceActionInfo) ServiceActionNotificationFields {
+	ecsServiceName, _ := GetServiceNameFromARN(request.Resources[0])
+
+	clusterName := serviceActionInfo.ClusterARN
+	if clusterSplit := strings.Split(clusterName, "cluster/"); len(clusterSplit) == 2 {
+		clusterName = clusterSplit[1]
+	}
+
+	eventTimestamp := serviceActionInfo.CreatedAt
+	if eventTimestamp == "" {
+		eventTimestamp = request.Time.UTC().Format("2006-01-02T15:04:05Z")
+	}
+
+	return ServiceActionNotificationFields{
+		ServiceName:    ecsServiceName,
+		ClusterName:    clusterName,
+		EventName:      serviceActionInfo.EventName,
+		EventType:      serviceActionInfo.EventType,
+		Reasons:        EscapeJSONString(strings.Join(serviceActionInfo.Reasons, ", ")),
+		AWSReference:   request.ID,
+		AWSRegion:      request.Region,
+		AWSAccount:     request.AccountID,
+		EventTimestamp: eventTimestamp,
+	}
+}
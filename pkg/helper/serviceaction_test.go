@@ -0,0 +1,133 @@
+package helper_test
+
+import (
+	"deployment-notifications/pkg/helper"
+	"encoding/json"
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"testing"
+)
+
+const samplePlacementFailureEvent = `
+{
+   "version": "0",
+   "id": "ddca6449-b258-46c0-8653-e0e3a6EXAMPLE",
+   "detail-type": "ECS Service Action",
+   "source": "aws.ecs",
+   "account": "111122223333",
+   "time": "2020-05-23T12:31:14Z",
+   "region": "us-west-2",
+   "resources": [
+        "arn:aws:ecs:us-west-2:111122223333:service/default/shure-content-api"
+   ],
+   "detail": {
+        "eventType": "ERROR",
+        "eventName": "SERVICE_TASK_PLACEMENT_FAILURE",
+        "clusterArn": "arn:aws:ecs:us-west-2:111122223333:cluster/default",
+        "capacityProviderArns": [
+            "arn:aws:ecs:us-west-2:111122223333:capacity-provider/ASG-tutorial-capacity-provider"
+        ],
+        "reasons": [
+            "RESOURCE:MEMORY",
+            "RESOURCE:CPU"
+        ],
+        "createdAt": "2020-05-23T12:31:14.123Z"
+   }
+}
+`
+
+func TestParseServiceActionDetails(t *testing.T) {
+	var cloudwatchEvent events.CloudWatchEvent
+	err := json.Unmarshal([]byte(samplePlacementFailureEvent), &cloudwatchEvent)
+	assert.Nil(t, err)
+
+	serviceActionInfo, err := helper.ParseServiceActionDetails(cloudwatchEvent)
+	assert.Nil(t, err)
+	assert.Equal(t, "ERROR", serviceActionInfo.EventType)
+	assert.Equal(t, "SERVICE_TASK_PLACEMENT_FAILURE", serviceActionInfo.EventName)
+	assert.Equal(t, []string{"RESOURCE:MEMORY", "RESOURCE:CPU"}, serviceActionInfo.Reasons)
+
+	cloudwatchEvent.Detail = json.RawMessage(`{"eventType": "INFO"}`)
+	_, err = helper.ParseServiceActionDetails(cloudwatchEvent)
+	assert.NotNil(t, err)
+}
+
+func TestServiceActionNotificationStruct(t *testing.T) {
+	var cloudwatchEvent events.CloudWatchEvent
+	err := json.Unmarshal([]byte(samplePlacementFailureEvent), &cloudwatchEvent)
+	assert.Nil(t, err)
+
+	serviceActionInfo, _ := helper.ParseServiceActionDetails(cloudwatchEvent)
+	slackStruct := helper.GenerateServiceActionNotificationStruct(cloudwatchEvent, serviceActionInfo)
+
+	assert.Equal(t, "default/shure-content-api", slackStruct.ServiceName)
+	assert.Equal(t, "default", slackStruct.ClusterName)
+	assert.Equal(t, "SERVICE_TASK_PLACEMENT_FAILURE", slackStruct.EventName)
+	assert.Equal(t, "RESOURCE:MEMORY, RESOURCE:CPU", slackStruct.Reasons)
+	assert.Equal(t, "2020-05-23T12:31:14.123Z", slackStruct.EventTimestamp)
+	assert.Equal(t, "111122223333", slackStruct.AWSAccount)
+
+	parsedMessage, err := helper.GeneratePayload(helper.DefaultServiceActionTemplates[slackStruct.EventName],
+		slackStruct, true)
+	assert.Nil(t, err)
+	assert.True(t, json.Valid([]byte(parsedMessage)))
+	assert.Contains(t, parsedMessage, "could not place tasks for `default/shure-content-api`")
+	assert.Contains(t, parsedMessage, "RESOURCE:MEMORY, RESOURCE:CPU")
+}
+
+func TestServiceActionNotificationEscaping(t *testing.T) {
+	var cloudwatchEvent events.CloudWatchEvent
+	err := json.Unmarshal([]byte(samplePlacementFailureEvent), &cloudwatchEvent)
+	assert.Nil(t, err)
+
+	serviceActionInfo, _ := helper.ParseServiceActionDetails(cloudwatchEvent)
+	serviceActionInfo.Reasons = []string{`no container instance met "memory" requirement`, `path C:\tasks`}
+	slackStruct := helper.GenerateServiceActionNotificationStruct(cloudwatchEvent, serviceActionInfo)
+
+	parsedMessage, err := helper.GeneratePayload(helper.DefaultServiceActionTemplates[slackStruct.EventName],
+		slackStruct, true)
+	assert.Nil(t, err)
+	assert.True(t, json.Valid([]byte(parsedMessage)))
+	assert.Contains(t, parsedMessage, `no container instance met \"memory\" requirement, path C:\\tasks`)
+}
+
+func TestTrackedServiceActions(t *testing.T) {
+	assert.True(t, helper.IsTrackedServiceAction("SERVICE_STEADY_STATE"))
+	assert.True(t, helper.IsTrackedServiceAction("SERVICE_TASK_PLACEMENT_FAILURE"))
+	assert.True(t, helper.IsTrackedServiceAction("SERVICE_DISCOVERY_INSTANCE_UNHEALTHY"))
+	assert.False(t, helper.IsTrackedServiceAction("SERVICE_TASK_START_IMPAIRED"))
+
+	os.Setenv("ECS_SERVICE_ACTION_EVENTS", "SERVICE_TASK_PLACEMENT_FAILURE, SERVICE_TASK_START_IMPAIRED")
+	defer os.Unsetenv("ECS_SERVICE_ACTION_EVENTS")
+
+	assert.False(t, helper.IsTrackedServiceAction("SERVICE_STEADY_STATE"))
+	assert.True(t, helper.IsTrackedServiceAction("SERVICE_TASK_START_IMPAIRED"))
+}
+
+func TestSelectServiceActionTemplate(t *testing.T) {
+	templates := map[string]string{
+		"content-api:SERVICE_STEADY_STATE": "service specific",
+		"SERVICE_STEADY_STATE":             "event specific",
+	}
+
+	template, err := helper.SelectServiceActionTemplate(templates, "content-api", "SERVICE_STEADY_STATE")
+	assert.Nil(t, err)
+	assert.Equal(t, "service specific", template)
+
+	template, err = helper.SelectServiceActionTemplate(templates, "auth-api", "SERVICE_STEADY_STATE")
+	assert.Nil(t, err)
+	assert.Equal(t, "event specific", template)
+
+	template, err = helper.SelectServiceActionTemplate(templates, "auth-api", "SERVICE_TASK_PLACEMENT_FAILURE")
+	assert.Nil(t, err)
+	assert.Equal(t, helper.DefaultServiceActionTemplates["SERVICE_TASK_PLACEMENT_FAILURE"], template)
+
+	templates["default"] = "catch all"
+	template, err = helper.SelectServiceActionTemplate(templates, "auth-api", "SERVICE_TASK_PLACEMENT_FAILURE")
+	assert.Nil(t, err)
+	assert.Equal(t, "catch all", template)
+
+	_, err = helper.SelectServiceActionTemplate(nil, "auth-api", "SERVICE_TASK_START_IMPAIRED")
+	assert.NotNil(t, err)
+}
@@ -2,15 +2,16 @@ package helper
 
 import (
 	"bytes"
+	"context"
+	"deployment-notifications/pkg/logger"
+	"deployment-notifications/pkg/redact"
 	"encoding/json"
 	"fmt"
 	"github.com/aws/aws-lambda-go/events"
 	"io/ioutil"
-	"log"
 	"net/http"
 	"strings"
 	"text/template"
-	"time"
 )
 
 type SlackNotificationFields struct {
@@ -21,6 +22,9 @@ type SlackNotificationFields struct {
 	AWSAccount            string
 	DeploymentTimestamp   string
 	DeploymentDescription string
+	IsRollback            bool
+	FailedRevision        string
+	RollbackTarget        string
 }
 
 func DecodeSlackMapping(parameterString string) (map[string][]string, error) {
@@ -32,13 +36,33 @@ func DecodeSlackMapping(parameterString string) (map[string][]string, error) {
 	err := json.Unmarshal([]byte(parameterString), &resultMap)
 
 	if err != nil {
-		return resultMap, WrapError(fmt.Sprintf("Could not decode slack parameter string\n%s", parameterString),
-			nil)
+		return resultMap, WrapError(fmt.Sprintf("Could not decode slack parameter string\n%s",
+			redact.String(parameterString)), err)
 	}
 
 	return resultMap, nil
 }
 
+// Slack mapping entries can reference the webhook URL instead of holding
+// it, e.g. "secretsmanager:prod/slack#platform" or "ssm:/prod/slack/platform",
+// so the mapping holds no credentials. They are read when delivering
+const (
+	WebhookStoreSecretsManager = "secretsmanager"
+	WebhookStoreSSM            = "ssm"
+)
+
+// ParseWebhookReference returns the store and name a mapping entry
+// references, or an empty store for a webhook URL
+func ParseWebhookReference(webhook string) (string, string) {
+	for _, store := range []string{WebhookStoreSecretsManager, WebhookStoreSSM} {
+		if strings.HasPrefix(webhook, store+":") {
+			return store, strings.TrimPrefix(webhook, store+":")
+		}
+	}
+
+	return "", webhook
+}
+
 func GetDefaultWebhook(valueMap map[string][]string) string {
 	defaultServiceMap := LocateValueMultiple("default-service", valueMap)
 
@@ -64,7 +88,10 @@ func GenerateSlackNotificationStruct(request events.CloudWatchEvent) SlackNotifi
 	}
 }
 
-func GeneratePayload(templateMessage string, templateValues SlackNotificationFields, parseQuoteTags bool) (string, error) {
+func GeneratePayload(templateMessage string, templateValues interface{}, parseQuoteTags bool) (string, error) {
+	// templateValues is any of the notification field structs, e.g.
+	// SlackNotificationFields or ServiceActionNotificationFields
+
 	parsedMessage := templateMessage
 
 	parsedMessage = strings.ReplaceAll(templateMessage, "<varbegin>", "{{")
@@ -94,37 +121,48 @@ func GeneratePayload(templateMessage string, templateValues SlackNotificationFie
 	return finalOut, nil
 }
 
-func PostSlackMessage(messageTemplate string, templateValues SlackNotificationFields,
+func PostSlackMessage(ctx context.Context, messageTemplate string, templateValues interface{},
 	webhookURL string) (int, error) {
 
 	parsedMessage, err := GeneratePayload(messageTemplate, templateValues, true)
 
-	fmt.Println(parsedMessage)
-
 	if err != nil {
-		return 500, WrapError("Error parsing slack message template", err)
+		return 500, PermanentSinkError("Error parsing slack message template", err)
 	}
 
-	req, err := http.NewRequest("POST", webhookURL, bytes.NewBuffer([]byte(parsedMessage)))
+	logger.Debugf(ctx, "Slack payload: %s", parsedMessage)
+
+	return PostSlackPayload(ctx, parsedMessage, webhookURL)
+}
+
+func PostSlackPayload(ctx context.Context, payload string, webhookURL string) (int, error) {
+	// posts an already rendered payload to the webhook as is
+
+	if err := CheckDestination(SinkSlack, webhookURL); err != nil {
+		return 999, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", webhookURL, bytes.NewBuffer([]byte(payload)))
 
 	if err != nil {
-		return 500, WrapError("Error formatting new request for New Relic deployment", err)
+		return 500, PermanentSinkError("Error formatting new request for Slack message", err)
 	}
 
 	req.Header.Set("Content-Type", "application/json")
 
-	client := &http.Client{}
-	client.Timeout = time.Second * time.Duration(GetDefaultHTTPTimeout())
-
-	resp, err := client.Do(req)
+	resp, err := doRequest(req)
 	if err != nil {
-		return resp.StatusCode, WrapError("Error making final Slack request", err)
+		return 500, transportError("Error making final Slack request", err)
 	}
 	defer resp.Body.Close()
 
-	log.Printf("Response Status: %d", resp.StatusCode)
 	respBody, _ := ioutil.ReadAll(resp.Body)
-	log.Printf("Response Body: %s", string(respBody))
+	logger.Infof(ctx, "Slack response status: %d", resp.StatusCode)
+	logger.Debugf(ctx, "Slack response body: %s", string(respBody))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, SinkStatusError(fmt.Sprintf("Slack submission failed: %.200s", respBody), resp.StatusCode)
+	}
 
 	return resp.StatusCode, nil
 }
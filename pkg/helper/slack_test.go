@@ -32,6 +32,15 @@ func TestSlackMappingDecode(t *testing.T) {
 	assert.NotNil(t, err)
 }
 
+func TestSlackMappingDecodeRedactsWebhooks(t *testing.T) {
+	mapTemplate := `{"my-service": ["https://hooks.slack.com/services/T0000/B0000/XXXXXXXXXXXXXXXX"],}`
+
+	_, err := helper.DecodeSlackMapping(mapTemplate)
+	assert.NotNil(t, err)
+	assert.NotContains(t, err.Error(), "XXXXXXXXXXXXXXXX")
+	assert.Contains(t, err.Error(), "https://hooks.slack.com/services/T0000/B0000/<redacted>")
+}
+
 func TestSlackDefaultWebhook(t *testing.T) {
 	mapTemplate := `
 {
@@ -350,3 +359,17 @@ func TestSlackPayloadNoDequote(t *testing.T) {
 
 	assert.Equal(t, expectedOutput, parsedMessage)
 }
+
+func TestParseWebhookReference(t *testing.T) {
+	store, name := helper.ParseWebhookReference("secretsmanager:prod/slack#platform")
+	assert.Equal(t, helper.WebhookStoreSecretsManager, store)
+	assert.Equal(t, "prod/slack#platform", name)
+
+	store, name = helper.ParseWebhookReference("ssm:/prod/slack/platform")
+	assert.Equal(t, helper.WebhookStoreSSM, store)
+	assert.Equal(t, "/prod/slack/platform", name)
+
+	store, name = helper.ParseWebhookReference("https://hooks.slack.com/services/T0/B0/X")
+	assert.Equal(t, "", store)
+	assert.Equal(t, "https://hooks.slack.com/services/T0/B0/X", name)
+}
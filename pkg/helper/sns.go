@@ -0,0 +1,48 @@
+package helper
+
+import (
+	"encoding/json"
+)
+
+type snsNotification struct {
+	Type     string `json:"Type"`
+	TopicArn string `json:"TopicArn"`
+	Message  string `json:"Message"`
+}
+
+func IsSNSEvent(payload []byte) bool {
+	var snsEvent struct {
+		Records []struct {
+			EventSource string `json:"EventSource"`
+		} `json:"Records"`
+	}
+
+	if err := json.Unmarshal(payload, &snsEvent); err != nil || len(snsEvent.Records) == 0 {
+		return false
+	}
+
+	for _, record := range snsEvent.Records {
+		if record.EventSource != "aws:sns" {
+			return false
+		}
+	}
+
+	return true
+}
+
+func UnwrapSNSNotification(body string) string {
+	// SNS subscriptions without raw message delivery wrap the event
+	// in a notification envelope carrying it as the "Message" string,
+	// anything else, including raw deliveries, is returned unchanged
+	var notification snsNotification
+
+	if err := json.Unmarshal([]byte(body), &notification); err != nil {
+		return body
+	}
+
+	if notification.Type != "Notification" || notification.TopicArn == "" || notification.Message == "" {
+		return body
+	}
+
+	return notification.Message
+}
@@ -0,0 +1,69 @@
+package helper_test
+
+import (
+	"deployment-notifications/pkg/helper"
+	"encoding/json"
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+const sampleSNSNotification = `{
+  "Type" : "Notification",
+  "MessageId" : "95df01b4-ee98-5cb9-9903-4c221d41eb5e",
+  "TopicArn" : "arn:aws:sns:us-west-2:444455556666:ecs-deployments",
+  "Message" : "{\"version\":\"0\",\"id\":\"ddca6449-b258-46c0-8653-e0e3a6EXAMPLE\",\"detail-type\":\"ECS Deployment State Change\",\"source\":\"aws.ecs\",\"account\":\"111122223333\",\"time\":\"2020-05-23T12:31:14Z\",\"region\":\"us-west-2\",\"resources\":[\"arn:aws:ecs:us-west-2:111122223333:service/shure-content-api\"],\"detail\":{\"eventType\":\"INFO\",\"eventName\":\"SERVICE_DEPLOYMENT_COMPLETED\",\"deploymentId\":\"ecs-svc/123\",\"updatedAt\":\"2020-05-23T11:11:11Z\",\"reason\":\"ECS deployment deploymentId completed.\"}}",
+  "Timestamp" : "2020-05-23T12:31:15.000Z",
+  "SignatureVersion" : "1",
+  "Signature" : "EXAMPLE",
+  "SigningCertURL" : "https://sns.us-west-2.amazonaws.com/SimpleNotificationService-EXAMPLE.pem",
+  "UnsubscribeURL" : "https://sns.us-west-2.amazonaws.com/?Action=Unsubscribe&SubscriptionArn=EXAMPLE"
+}`
+
+func TestUnwrapSNSNotification(t *testing.T) {
+	var notification map[string]string
+	err := json.Unmarshal([]byte(sampleSNSNotification), &notification)
+	assert.Nil(t, err)
+
+	assert.Equal(t, notification["Message"], helper.UnwrapSNSNotification(sampleSNSNotification))
+
+	// raw message delivery and anything that isn't a notification are left as is
+	assert.Equal(t, notification["Message"], helper.UnwrapSNSNotification(notification["Message"]))
+	assert.Equal(t, "not json", helper.UnwrapSNSNotification("not json"))
+	assert.Equal(t, `{"Type": "SubscriptionConfirmation", "TopicArn": "arn", "Message": "confirm"}`,
+		helper.UnwrapSNSNotification(`{"Type": "SubscriptionConfirmation", "TopicArn": "arn", "Message": "confirm"}`))
+}
+
+func TestParseSNSWrappedEvent(t *testing.T) {
+	// non-raw SNS subscriptions to an SQS queue put the envelope in the body
+	request, err := helper.ParseEventBridgeEnvelope(sampleSNSNotification)
+	assert.Nil(t, err)
+	assert.Equal(t, "aws.ecs", request.Source)
+	assert.Equal(t, "ECS Deployment State Change", request.DetailType)
+	assert.Equal(t, "111122223333", request.AccountID)
+
+	eventDetails, err := helper.ParseEventDetails(request)
+	assert.Nil(t, err)
+	assert.Equal(t, "SERVICE_DEPLOYMENT_COMPLETED", eventDetails.EventName)
+}
+
+func TestIsSNSEvent(t *testing.T) {
+	snsEvent := events.SNSEvent{
+		Records: []events.SNSEventRecord{
+			{
+				EventSource: "aws:sns",
+				SNS: events.SNSEntity{
+					Type:     "Notification",
+					TopicArn: "arn:aws:sns:us-west-2:444455556666:ecs-deployments",
+					Message:  "{}",
+				},
+			},
+		},
+	}
+
+	payload, err := json.Marshal(snsEvent)
+	assert.Nil(t, err)
+	assert.True(t, helper.IsSNSEvent(payload))
+	assert.False(t, helper.IsSNSEvent([]byte(sampleSQSEvent)))
+	assert.False(t, helper.IsSNSEvent([]byte(sampleSNSNotification)))
+}
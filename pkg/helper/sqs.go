@@ -0,0 +1,68 @@
+package helper
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/aws/aws-lambda-go/events"
+	"strconv"
+)
+
+// SQSEventResponse reports the records of a batch that failed, so that
+// only those are returned to the queue. It mirrors the type of the same
+// name in newer aws-lambda-go releases and needs ReportBatchItemFailures
+// enabled on the event source mapping
+type SQSEventResponse struct {
+	BatchItemFailures []SQSBatchItemFailure `json:"batchItemFailures"`
+}
+
+type SQSBatchItemFailure struct {
+	ItemIdentifier string `json:"itemIdentifier"`
+}
+
+func IsSQSEvent(payload []byte) bool {
+	var sqsEvent struct {
+		Records []struct {
+			EventSource string `json:"eventSource"`
+		} `json:"Records"`
+	}
+
+	if err := json.Unmarshal(payload, &sqsEvent); err != nil || len(sqsEvent.Records) == 0 {
+		return false
+	}
+
+	for _, record := range sqsEvent.Records {
+		if record.EventSource != "aws:sqs" {
+			return false
+		}
+	}
+
+	return true
+}
+
+func ParseEventBridgeEnvelope(body string) (events.CloudWatchEvent, error) {
+	// EventBridge delivers the whole event as the message body, either
+	// as is or wrapped in an SNS notification when forwarded through SNS
+	var request events.CloudWatchEvent
+
+	err := json.Unmarshal([]byte(UnwrapSNSNotification(body)), &request)
+
+	if err != nil {
+		return request, WrapError("Message body is not an EventBridge event", err)
+	}
+
+	if request.Source == "" || request.DetailType == "" {
+		return request, WrapError(fmt.Sprintf("Message body has no 'source' or 'detail-type': %.100s", body), nil)
+	}
+
+	return request, nil
+}
+
+func GetSQSRetryCount(record events.SQSMessage) int {
+	// the first receive is not a retry, a missing count is taken as one
+	receiveCount, err := strconv.Atoi(record.Attributes["ApproximateReceiveCount"])
+	if err != nil || receiveCount < 1 {
+		return 0
+	}
+
+	return receiveCount - 1
+}
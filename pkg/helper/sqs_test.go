@@ -0,0 +1,90 @@
+package helper_test
+
+import (
+	"deployment-notifications/pkg/helper"
+	"encoding/json"
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+const sampleSQSEvent = `
+{
+   "Records": [
+        {
+            "messageId": "059f36b4-87a3-44ab-83d2-661975830a7d",
+            "receiptHandle": "AQEBwJnKyrHigUMZj6rYigCgxlaS3SLy0a...",
+            "body": "{\"version\":\"0\",\"id\":\"ddca6449-b258-46c0-8653-e0e3a6EXAMPLE\",\"detail-type\":\"ECS Deployment State Change\",\"source\":\"aws.ecs\",\"account\":\"111122223333\",\"time\":\"2020-05-23T12:31:14Z\",\"region\":\"us-west-2\",\"resources\":[\"arn:aws:ecs:us-west-2:111122223333:service/shure-content-api\"],\"detail\":{\"eventType\":\"INFO\",\"eventName\":\"SERVICE_DEPLOYMENT_COMPLETED\",\"deploymentId\":\"ecs-svc/123\",\"updatedAt\":\"2020-05-23T11:11:11Z\",\"reason\":\"ECS deployment deploymentId completed.\"}}",
+            "attributes": {
+                "ApproximateReceiveCount": "1",
+                "SentTimestamp": "1590236000000"
+            },
+            "messageAttributes": {},
+            "md5OfBody": "e4e68fb7bd0e697a0ae8f1bb342846b3",
+            "eventSource": "aws:sqs",
+            "eventSourceARN": "arn:aws:sqs:us-west-2:111122223333:deployment-notifications",
+            "awsRegion": "us-west-2"
+        },
+        {
+            "messageId": "2e1424d4-f796-459a-8184-9c92662be6da",
+            "receiptHandle": "AQEBzWwaftRI0KuVm4tP+/7q1rGgNqicHq...",
+            "body": "not an event",
+            "attributes": {},
+            "messageAttributes": {},
+            "eventSource": "aws:sqs",
+            "eventSourceARN": "arn:aws:sqs:us-west-2:111122223333:deployment-notifications",
+            "awsRegion": "us-west-2"
+        }
+   ]
+}
+`
+
+func TestIsSQSEvent(t *testing.T) {
+	assert.True(t, helper.IsSQSEvent([]byte(sampleSQSEvent)))
+	assert.False(t, helper.IsSQSEvent([]byte(`{"Records": []}`)))
+	assert.False(t, helper.IsSQSEvent([]byte(`{"Records": [{"EventSource": "aws:sns"}]}`)))
+	assert.False(t, helper.IsSQSEvent([]byte(`{"source": "aws.ecs", "detail-type": "ECS Deployment State Change"}`)))
+	assert.False(t, helper.IsSQSEvent([]byte(`not json`)))
+}
+
+func TestParseEventBridgeEnvelope(t *testing.T) {
+	var sqsEvent events.SQSEvent
+	err := json.Unmarshal([]byte(sampleSQSEvent), &sqsEvent)
+	assert.Nil(t, err)
+
+	request, err := helper.ParseEventBridgeEnvelope(sqsEvent.Records[0].Body)
+	assert.Nil(t, err)
+	assert.Equal(t, "aws.ecs", request.Source)
+	assert.Equal(t, "ECS Deployment State Change", request.DetailType)
+	assert.Equal(t, "arn:aws:ecs:us-west-2:111122223333:service/shure-content-api", request.Resources[0])
+
+	eventDetails, err := helper.ParseEventDetails(request)
+	assert.Nil(t, err)
+	assert.Equal(t, "SERVICE_DEPLOYMENT_COMPLETED", eventDetails.EventName)
+
+	_, err = helper.ParseEventBridgeEnvelope(sqsEvent.Records[1].Body)
+	assert.NotNil(t, err)
+
+	_, err = helper.ParseEventBridgeEnvelope(`{"id": "1"}`)
+	assert.NotNil(t, err)
+}
+
+func TestSQSEventResponse(t *testing.T) {
+	response := helper.SQSEventResponse{
+		BatchItemFailures: []helper.SQSBatchItemFailure{{ItemIdentifier: "2e1424d4-f796-459a-8184-9c92662be6da"}},
+	}
+
+	responseJSON, err := json.Marshal(response)
+	assert.Nil(t, err)
+	assert.Equal(t, `{"batchItemFailures":[{"itemIdentifier":"2e1424d4-f796-459a-8184-9c92662be6da"}]}`, string(responseJSON))
+}
+
+func TestGetSQSRetryCount(t *testing.T) {
+	record := events.SQSMessage{Attributes: map[string]string{"ApproximateReceiveCount": "3"}}
+	assert.Equal(t, 2, helper.GetSQSRetryCount(record))
+
+	record.Attributes["ApproximateReceiveCount"] = "1"
+	assert.Equal(t, 0, helper.GetSQSRetryCount(record))
+
+	assert.Equal(t, 0, helper.GetSQSRetryCount(events.SQSMessage{}))
+}
@@ -0,0 +1,204 @@
+package helper
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/aws/aws-lambda-go/events"
+	"strings"
+)
+
+// DefaultSlackCrashLoopTemplate is used when no
+// SSM_PARAMETER_MESSAGE_SLACK_CRASH_LOOP parameter is configured
+const DefaultSlackCrashLoopTemplate = `{
+   "text":"<!here> :rotating_light: <backquote><varbegin>.ServiceName<varend><backquote> looks crash-looping: <varbegin>.StopCount<varend> tasks stopped in <varbegin>.WindowMinutes<varend> minutes after a deployment",
+   "attachments":[
+      {
+         "fallback":"Crash loop detected for <backquote><varbegin>.ServiceName<varend><backquote>",
+         "color":"#D00000",
+         "fields":[
+            {
+               "title":"Service",
+               "value":"<varbegin>.ServiceName<varend>",
+               "short":true
+            },
+            {
+               "title":"Cluster",
+               "value":"<varbegin>.ClusterName<varend>",
+               "short":true
+            },
+            {
+               "title":"Task Definition",
+               "value":"<varbegin>.TaskDefinition<varend>",
+               "short":true
+            },
+            {
+               "title":"Deployment",
+               "value":"<varbegin>.DeploymentID<varend> <varbegin>.DeploymentStartedAt<varend>",
+               "short":true
+            },
+            {
+               "title":"Stopped Reason",
+               "value":"<varbegin>.StoppedReason<varend>",
+               "short":false
+            },
+            {
+               "title":"Container Exit Codes",
+               "value":"<varbegin>.ExitCodes<varend>",
+               "short":false
+            },
+            {
+               "title":"AWS Account",
+               "value":"<varbegin>.AWSAccount<varend>",
+               "short":true
+            },
+            {
+               "title":"AWS Region",
+               "value":"<varbegin>.AWSRegion<varend>",
+               "short":true
+            },
+            {
+               "title":"Timestamp",
+               "value":"<varbegin>.EventTimestamp<varend>",
+               "short":false
+            }
+         ]
+      }
+   ]
+}`
+
+type TaskContainer struct {
+	Name       string `json:"name"`
+	ExitCode   *int   `json:"exitCode"`
+	Reason     string `json:"reason"`
+	LastStatus string `json:"lastStatus"`
+}
+
+type TaskStateInfo struct {
+	LastStatus        string          `json:"lastStatus"`
+	DesiredStatus     string          `json:"desiredStatus"`
+	StopCode          string          `json:"stopCode"`
+	StoppedReason     string          `json:"stoppedReason"`
+	StoppedAt         string          `json:"stoppedAt"`
+	TaskARN           string          `json:"taskArn"`
+	TaskDefinitionARN string          `json:"taskDefinitionArn"`
+	ClusterARN        string          `json:"clusterArn"`
+	Group             string          `json:"group"`
+	StartedBy         string          `json:"startedBy"`
+	Containers        []TaskContainer `json:"containers"`
+}
+
+type CrashLoopNotificationFields struct {
+	ServiceName         string
+	ClusterName         string
+	TaskDefinition      string
+	StopCount           int
+	WindowMinutes       int
+	StoppedReason       string
+	ExitCodes           string
+	DeploymentID        string
+	DeploymentStartedAt string
+	AWSReference        string
+	AWSRegion           string
+	AWSAccount          string
+	EventTimestamp      string
+}
+
+func ParseTaskStateDetails(request events.CloudWatchEvent) (TaskStateInfo, error) {
+	var taskStateInfo TaskStateInfo
+
+	err := json.Unmarshal(request.Detail, &taskStateInfo)
+
+	if err != nil {
+		return taskStateInfo, WrapError("Unexpected error unmarshaling task state details", err)
+	}
+
+	if taskStateInfo.TaskARN == "" {
+		return taskStateInfo, WrapError("'taskArn' attribute not found on payload", nil)
+	}
+	if taskStateInfo.LastStatus == "" {
+		return taskStateInfo, WrapError("'lastStatus' attribute not found on payload", nil)
+	}
+
+	return taskStateInfo, nil
+}
+
+func GetServiceNameFromGroup(group string) (string, error) {
+	// tasks started by a service are in the group "service:<service name>"
+	if !strings.HasPrefix(group, "service:") {
+		return "", WrapError(fmt.Sprintf("Task group '%s' does not belong to a service", group), nil)
+	}
+
+	return strings.TrimPrefix(group, "service:"), nil
+}
+
+func IsCrashStop(taskStateInfo TaskStateInfo) bool {
+	// tasks replaced by a deployment or scaled in are stopped by the
+	// scheduler and are not crashes; failures to start, essential
+	// containers exiting and failed health checks are
+	switch taskStateInfo.StopCode {
+	case "TaskFailedToStart", "EssentialContainerExited":
+		return true
+	case "UserInitiated":
+		return false
+	}
+
+	if strings.Contains(strings.ToLower(taskStateInfo.StoppedReason), "health check") {
+		return true
+	}
+
+	for _, container := range taskStateInfo.Containers {
+		if container.ExitCode != nil && *container.ExitCode != 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+func FormatContainerExitCodes(containers []TaskContainer) string {
+	var exitCodes []string
+
+	for _, container := range containers {
+		exitCode := "n/a"
+		if container.ExitCode != nil {
+			exitCode = fmt.Sprintf("%d", *container.ExitCode)
+		}
+
+		if container.Reason != "" {
+			exitCode = fmt.Sprintf("%s (%s)", exitCode, container.Reason)
+		}
+
+		exitCodes = append(exitCodes, fmt.Sprintf("%s: %s", container.Name, exitCode))
+	}
+
+	return strings.Join(exitCodes, ", ")
+}
+
+func GenerateCrashLoopNotificationStruct(request events.CloudWatchEvent, taskStateInfo TaskStateInfo,
+	stopCount, windowMinutes int) CrashLoopNotificationFields {
+	ecsServiceName, _ := GetServiceNameFromGroup(taskStateInfo.Group)
+
+	clusterName := taskStateInfo.ClusterARN
+	if clusterSplit := strings.Split(clusterName, "cluster/"); len(clusterSplit) == 2 {
+		clusterName = clusterSplit[1]
+	}
+
+	eventTimestamp := taskStateInfo.StoppedAt
+	if eventTimestamp == "" {
+		eventTimestamp = request.Time.UTC().Format("2006-01-02T15:04:05Z")
+	}
+
+	return CrashLoopNotificationFields{
+		ServiceName:    ecsServiceName,
+		ClusterName:    clusterName,
+		TaskDefinition: GetTaskDefinitionName(taskStateInfo.TaskDefinitionARN),
+		StopCount:      stopCount,
+		WindowMinutes:  windowMinutes,
+		StoppedReason:  EscapeJSONString(taskStateInfo.StoppedReason),
+		ExitCodes:      EscapeJSONString(FormatContainerExitCodes(taskStateInfo.Containers)),
+		AWSReference:   request.ID,
+		AWSRegion:      request.Region,
+		AWSAccount:     request.AccountID,
+		EventTimestamp: eventTimestamp,
+	}
+}
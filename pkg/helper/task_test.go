@@ -0,0 +1,149 @@
+package helper_test
+
+import (
+	"deployment-notifications/pkg/helper"
+	"encoding/json"
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+const sampleTaskStoppedEvent = `
+{
+   "version": "0",
+   "id": "3317b2af-7005-947d-b652-f55e762e571a",
+   "detail-type": "ECS Task State Change",
+   "source": "aws.ecs",
+   "account": "111122223333",
+   "time": "2020-05-23T12:35:14Z",
+   "region": "us-west-2",
+   "resources": [
+        "arn:aws:ecs:us-west-2:111122223333:task/default/b99d40b3-5176-4f71-9a52-9dbd6f1cebef"
+   ],
+   "detail": {
+        "clusterArn": "arn:aws:ecs:us-west-2:111122223333:cluster/default",
+        "containers": [
+            {
+                "name": "app",
+                "exitCode": 137,
+                "reason": "OutOfMemoryError: Container killed due to memory usage",
+                "lastStatus": "STOPPED"
+            },
+            {
+                "name": "log-router",
+                "exitCode": 0,
+                "lastStatus": "STOPPED"
+            }
+        ],
+        "desiredStatus": "STOPPED",
+        "group": "service:shure-content-api",
+        "lastStatus": "STOPPED",
+        "startedBy": "ecs-svc/123",
+        "stopCode": "EssentialContainerExited",
+        "stoppedAt": "2020-05-23T12:35:10.123Z",
+        "stoppedReason": "Essential container in task exited",
+        "taskArn": "arn:aws:ecs:us-west-2:111122223333:task/default/b99d40b3-5176-4f71-9a52-9dbd6f1cebef",
+        "taskDefinitionArn": "arn:aws:ecs:us-west-2:111122223333:task-definition/shure-content-api:12"
+   }
+}
+`
+
+func TestParseTaskStateDetails(t *testing.T) {
+	var cloudwatchEvent events.CloudWatchEvent
+	err := json.Unmarshal([]byte(sampleTaskStoppedEvent), &cloudwatchEvent)
+	assert.Nil(t, err)
+
+	taskStateInfo, err := helper.ParseTaskStateDetails(cloudwatchEvent)
+	assert.Nil(t, err)
+	assert.Equal(t, "STOPPED", taskStateInfo.LastStatus)
+	assert.Equal(t, "EssentialContainerExited", taskStateInfo.StopCode)
+	assert.Equal(t, "service:shure-content-api", taskStateInfo.Group)
+	assert.Equal(t, 2, len(taskStateInfo.Containers))
+	assert.Equal(t, 137, *taskStateInfo.Containers[0].ExitCode)
+
+	cloudwatchEvent.Detail = json.RawMessage(`{"lastStatus": "STOPPED"}`)
+	_, err = helper.ParseTaskStateDetails(cloudwatchEvent)
+	assert.NotNil(t, err)
+}
+
+func TestServiceNameFromGroup(t *testing.T) {
+	serviceName, err := helper.GetServiceNameFromGroup("service:shure-content-api")
+	assert.Nil(t, err)
+	assert.Equal(t, "shure-content-api", serviceName)
+
+	_, err = helper.GetServiceNameFromGroup("family:shure-content-api")
+	assert.NotNil(t, err)
+}
+
+func TestIsCrashStop(t *testing.T) {
+	exitCodeZero := 0
+	exitCodeOne := 1
+
+	assert.True(t, helper.IsCrashStop(helper.TaskStateInfo{StopCode: "EssentialContainerExited"}))
+	assert.True(t, helper.IsCrashStop(helper.TaskStateInfo{StopCode: "TaskFailedToStart"}))
+	assert.False(t, helper.IsCrashStop(helper.TaskStateInfo{StopCode: "UserInitiated",
+		Containers: []helper.TaskContainer{{Name: "app", ExitCode: &exitCodeOne}}}))
+	assert.False(t, helper.IsCrashStop(helper.TaskStateInfo{StopCode: "ServiceSchedulerInitiated",
+		StoppedReason: "Scaling activity initiated by (deployment ecs-svc/123)",
+		Containers:    []helper.TaskContainer{{Name: "app", ExitCode: &exitCodeZero}}}))
+	assert.True(t, helper.IsCrashStop(helper.TaskStateInfo{StopCode: "ServiceSchedulerInitiated",
+		StoppedReason: "Task failed ELB health checks in (target-group arn:aws:elasticloadbalancing:...)"}))
+	assert.True(t, helper.IsCrashStop(helper.TaskStateInfo{StopCode: "ServiceSchedulerInitiated",
+		Containers: []helper.TaskContainer{{Name: "app", ExitCode: &exitCodeOne}}}))
+}
+
+func TestCrashLoopNotificationStruct(t *testing.T) {
+	var cloudwatchEvent events.CloudWatchEvent
+	err := json.Unmarshal([]byte(sampleTaskStoppedEvent), &cloudwatchEvent)
+	assert.Nil(t, err)
+
+	taskStateInfo, _ := helper.ParseTaskStateDetails(cloudwatchEvent)
+	slackStruct := helper.GenerateCrashLoopNotificationStruct(cloudwatchEvent, taskStateInfo, 3, 10)
+
+	assert.Equal(t, "shure-content-api", slackStruct.ServiceName)
+	assert.Equal(t, "default", slackStruct.ClusterName)
+	assert.Equal(t, "shure-content-api:12", slackStruct.TaskDefinition)
+	assert.Equal(t, 3, slackStruct.StopCount)
+	assert.Equal(t, "app: 137 (OutOfMemoryError: Container killed due to memory usage), log-router: 0",
+		slackStruct.ExitCodes)
+	assert.Equal(t, "2020-05-23T12:35:10.123Z", slackStruct.EventTimestamp)
+
+	parsedMessage, err := helper.GeneratePayload(helper.DefaultSlackCrashLoopTemplate, slackStruct, true)
+	assert.Nil(t, err)
+	assert.True(t, json.Valid([]byte(parsedMessage)))
+	assert.Contains(t, parsedMessage, "3 tasks stopped in 10 minutes")
+}
+
+func TestCrashLoopNotificationEscaping(t *testing.T) {
+	var cloudwatchEvent events.CloudWatchEvent
+	err := json.Unmarshal([]byte(sampleTaskStoppedEvent), &cloudwatchEvent)
+	assert.Nil(t, err)
+
+	taskStateInfo, _ := helper.ParseTaskStateDetails(cloudwatchEvent)
+	taskStateInfo.StoppedReason = `CannotPullContainerError: failed to resolve ref "docker.io/x:1" in C:\images`
+	taskStateInfo.Containers[0].Reason = `exec "/app/server" failed`
+	slackStruct := helper.GenerateCrashLoopNotificationStruct(cloudwatchEvent, taskStateInfo, 3, 10)
+
+	parsedMessage, err := helper.GeneratePayload(helper.DefaultSlackCrashLoopTemplate, slackStruct, true)
+	assert.Nil(t, err)
+	assert.True(t, json.Valid([]byte(parsedMessage)))
+
+	var payload struct {
+		Attachments []struct {
+			Fields []struct {
+				Title string `json:"title"`
+				Value string `json:"value"`
+			} `json:"fields"`
+		} `json:"attachments"`
+	}
+	err = json.Unmarshal([]byte(parsedMessage), &payload)
+	assert.Nil(t, err)
+
+	values := map[string]string{}
+	for _, field := range payload.Attachments[0].Fields {
+		values[field.Title] = field.Value
+	}
+	assert.Equal(t, `CannotPullContainerError: failed to resolve ref "docker.io/x:1" in C:\images`,
+		values["Stopped Reason"])
+	assert.Equal(t, `app: 137 (exec "/app/server" failed), log-router: 0`, values["Container Exit Codes"])
+}
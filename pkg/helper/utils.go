@@ -1,18 +1,23 @@
 package helper
 
 import (
+	"bufio"
+	"deployment-notifications/pkg/redact"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
+	"strconv"
+	"strings"
 )
 
 func WrapError(errorMessage string, err error) error {
+	// wrapped errors often carry request URLs, e.g. Slack webhooks
 	if err == nil {
-		return errors.New(errorMessage)
+		return redact.Error(errors.New(errorMessage))
 	}
 
-	return fmt.Errorf("%s: %w", errorMessage, err)
+	return redact.Error(fmt.Errorf("%s: %w", errorMessage, err))
 }
 
 func DecodeStringJSON(parameterString string) (map[string]string, error) {
@@ -24,8 +29,8 @@ func DecodeStringJSON(parameterString string) (map[string]string, error) {
 	err := json.Unmarshal([]byte(parameterString), &resultMap)
 
 	if err != nil {
-		return resultMap, WrapError(fmt.Sprintf("Could not decode parameter string\n%s", parameterString),
-			nil)
+		return resultMap, WrapError(fmt.Sprintf("Could not decode parameter string\n%s",
+			redact.String(parameterString)), err)
 	}
 
 	return resultMap, nil
@@ -40,6 +45,44 @@ func GetStringEnv(name string, defaultValue string) string {
 	return stringValue
 }
 
+func LoadEnvFile(path string) error {
+	// KEY=VALUE lines, as in local-test/env-file. Variables already set
+	// in the environment win over the file
+	file, err := os.Open(path)
+	if err != nil {
+		return WrapError(fmt.Sprintf("Error opening env file '%s'", path), err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		split := strings.SplitN(line, "=", 2)
+		if len(split) != 2 {
+			continue
+		}
+
+		if _, ok := os.LookupEnv(split[0]); !ok {
+			os.Setenv(split[0], split[1])
+		}
+	}
+
+	return scanner.Err()
+}
+
+func GetIntEnv(name string, defaultValue int) int {
+	intValue, err := strconv.Atoi(GetStringEnv(name, ""))
+	if err != nil {
+		return defaultValue
+	}
+
+	return intValue
+}
+
 func LocateValueMultiple(inputKey string, valuesMap map[string][]string) []string {
 	for key, value := range valuesMap {
 		if key == inputKey {
@@ -49,3 +92,14 @@ func LocateValueMultiple(inputKey string, valuesMap map[string][]string) []strin
 
 	return []string{}
 }
+
+func EscapeJSONString(value string) string {
+	// escapes a value that is placed inside a JSON string of a
+	// template, so quotes and newlines don't break the payload
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return value
+	}
+
+	return string(encoded[1 : len(encoded)-1])
+}
@@ -59,3 +59,19 @@ func TestLocateValueMultiple(t *testing.T) {
 	result = helper.LocateValueMultiple("key3", expectedMap)
 	assert.Equal(t, []string{}, result)
 }
+
+func TestGetIntEnv(t *testing.T) {
+	os.Setenv("INT_VAR", "42")
+	defer os.Unsetenv("INT_VAR")
+
+	assert.Equal(t, 42, helper.GetIntEnv("INT_VAR", 1))
+	assert.Equal(t, 1, helper.GetIntEnv("INT_VAR_MISSING", 1))
+
+	os.Setenv("INT_VAR", "forty-two")
+	assert.Equal(t, 1, helper.GetIntEnv("INT_VAR", 1))
+}
+
+func TestEscapeJSONString(t *testing.T) {
+	assert.Equal(t, `The \"web\" container\nexited`, helper.EscapeJSONString("The \"web\" container\nexited"))
+	assert.Equal(t, "plain", helper.EscapeJSONString("plain"))
+}
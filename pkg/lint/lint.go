@@ -0,0 +1,244 @@
+package lint
+
+import (
+	"deployment-notifications/pkg/helper"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+// slackOnlyKeys are Slack mapping entries that route notifications
+// which have no New Relic application
+var slackOnlyKeys = map[string]bool{
+	"default-service": true,
+	"dora-digest":     true,
+}
+
+type Finding struct {
+	Severity string `json:"severity"`
+	Source   string `json:"source"`
+	Message  string `json:"message"`
+}
+
+func (finding Finding) String() string {
+	return fmt.Sprintf("%s: %s: %s", finding.Severity, finding.Source, finding.Message)
+}
+
+// Config holds the raw parameter values to lint. Templates are keyed by
+// the environment variable naming their parameter, e.g.
+// SSM_PARAMETER_MESSAGE_SLACK, and ServiceActionTemplates is the JSON
+// mapping of event names to templates
+type Config struct {
+	NewRelicMapping        string
+	SlackMapping           string
+	Templates              map[string]string
+	ServiceActionTemplates string
+}
+
+func HasErrors(findings []Finding) bool {
+	for _, finding := range findings {
+		if finding.Severity == SeverityError {
+			return true
+		}
+	}
+
+	return false
+}
+
+func Run(config Config) []Finding {
+	var findings []Finding
+
+	newRelicMap, newRelicFindings := lintNewRelicMapping(config.NewRelicMapping)
+	findings = append(findings, newRelicFindings...)
+
+	slackMap, slackFindings := lintSlackMapping(config.SlackMapping)
+	findings = append(findings, slackFindings...)
+
+	if newRelicMap != nil && slackMap != nil {
+		findings = append(findings, compareMappings(newRelicMap, slackMap)...)
+	}
+
+	var templateNames []string
+	for name := range config.Templates {
+		templateNames = append(templateNames, name)
+	}
+	sort.Strings(templateNames)
+
+	for _, name := range templateNames {
+		findings = append(findings, lintTemplate(name, config.Templates[name])...)
+	}
+
+	if config.ServiceActionTemplates != "" {
+		findings = append(findings, lintServiceActionTemplates(config.ServiceActionTemplates)...)
+	}
+
+	return findings
+}
+
+func lintNewRelicMapping(mapping string) (map[string]string, []Finding) {
+	const source = "New Relic mapping"
+
+	newRelicMap, err := helper.DecodeStringJSON(mapping)
+	if err != nil {
+		return nil, []Finding{{SeverityError, source,
+			"must be a JSON object of service names to New Relic application IDs"}}
+	}
+
+	var findings []Finding
+
+	for _, serviceName := range sortedKeys(newRelicMap) {
+		appID := newRelicMap[serviceName]
+
+		if appID == "" || strings.Trim(appID, "0123456789") != "" {
+			findings = append(findings, Finding{SeverityError, source,
+				fmt.Sprintf("application ID '%s' of '%s' is not numeric", appID, serviceName)})
+		}
+	}
+
+	return newRelicMap, findings
+}
+
+func lintSlackMapping(mapping string) (map[string][]string, []Finding) {
+	const source = "Slack mapping"
+
+	slackMap, err := helper.DecodeSlackMapping(mapping)
+	if err != nil {
+		return nil, []Finding{{SeverityError, source,
+			"must be a JSON object of service names to lists of webhook URLs"}}
+	}
+
+	var findings []Finding
+
+	if helper.GetDefaultWebhook(slackMap) == "" {
+		findings = append(findings, Finding{SeverityError, source, "'default-service' webhook is not defined"})
+	}
+
+	for _, serviceName := range sortedKeys(slackMap) {
+		for _, webhook := range slackMap[serviceName] {
+			// references are only read when delivering
+			if store, name := helper.ParseWebhookReference(webhook); store != "" {
+				if name == "" {
+					findings = append(findings, Finding{SeverityError, source,
+						fmt.Sprintf("webhook of '%s' references no %s name", serviceName, store)})
+				}
+				continue
+			}
+
+			webhookURL, err := url.Parse(webhook)
+
+			switch {
+			case helper.CheckDestination(helper.SinkSlack, webhook) == nil:
+			case err != nil || webhookURL.Scheme != "https" || webhookURL.Host == "":
+				findings = append(findings, Finding{SeverityError, source,
+					fmt.Sprintf("webhook of '%s' is not an https URL", serviceName)})
+			default:
+				findings = append(findings, Finding{SeverityError, source,
+					fmt.Sprintf("webhook of '%s' goes to '%s', which is not in ALLOWED_HOSTS_SLACK", serviceName,
+						webhookURL.Hostname())})
+			}
+		}
+	}
+
+	return slackMap, findings
+}
+
+func compareMappings(newRelicMap map[string]string, slackMap map[string][]string) []Finding {
+	// not an error, Lambda functions and stacks are often only routed
+	// to one of the two, but it is the usual sign of a typo
+	var findings []Finding
+
+	for _, serviceName := range sortedKeys(newRelicMap) {
+		if _, ok := slackMap[serviceName]; !ok {
+			findings = append(findings, Finding{SeverityWarning, "mappings",
+				fmt.Sprintf("'%s' is in the New Relic mapping but not the Slack mapping", serviceName)})
+		}
+	}
+
+	for _, serviceName := range sortedKeys(slackMap) {
+		if _, ok := newRelicMap[serviceName]; !ok && !slackOnlyKeys[serviceName] {
+			findings = append(findings, Finding{SeverityWarning, "mappings",
+				fmt.Sprintf("'%s' is in the Slack mapping but not the New Relic mapping", serviceName)})
+		}
+	}
+
+	return findings
+}
+
+func lintTemplate(name, template string) []Finding {
+	samples, ok := templateSamples[name]
+	if !ok {
+		return []Finding{{SeverityError, name, "unknown template parameter"}}
+	}
+
+	var findings []Finding
+
+	for _, sample := range samples {
+		if err := renderTemplate(template, sample.values); err != nil {
+			findings = append(findings, Finding{SeverityError, name,
+				fmt.Sprintf("rendering against %s: %v", sample.description, err)})
+		}
+	}
+
+	return findings
+}
+
+func lintServiceActionTemplates(mapping string) []Finding {
+	const source = "SSM_PARAMETER_MESSAGE_SLACK_SERVICE_ACTION"
+
+	templates, err := helper.DecodeStringJSON(mapping)
+	if err != nil {
+		return []Finding{{SeverityError, source, "must be a JSON object of event names to templates"}}
+	}
+
+	var findings []Finding
+
+	for _, key := range sortedKeys(templates) {
+		for _, sample := range serviceActionSamples {
+			if err := renderTemplate(templates[key], sample.values); err != nil {
+				findings = append(findings, Finding{SeverityError, source,
+					fmt.Sprintf("template '%s' rendering against %s: %v", key, sample.description, err)})
+			}
+		}
+	}
+
+	return findings
+}
+
+func renderTemplate(template string, values interface{}) error {
+	payload, err := helper.GeneratePayload(template, values, true)
+	if err != nil {
+		return err
+	}
+
+	if !json.Valid([]byte(payload)) {
+		return helper.WrapError("output is not valid JSON", nil)
+	}
+
+	return helper.ValidateSlackPayload(payload)
+}
+
+func sortedKeys(values interface{}) []string {
+	var keys []string
+
+	switch typed := values.(type) {
+	case map[string]string:
+		for key := range typed {
+			keys = append(keys, key)
+		}
+	case map[string][]string:
+		for key := range typed {
+			keys = append(keys, key)
+		}
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
@@ -0,0 +1,135 @@
+package lint_test
+
+import (
+	"deployment-notifications/pkg/helper"
+	"deployment-notifications/pkg/lint"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+const (
+	newRelicMapping = `{"my-service": "123456", "other-service": "654321"}`
+	slackMapping    = `{"default-service": ["https://hooks.slack.com/services/T0/B0/X"],
+		"my-service": ["https://hooks.slack.com/services/T0/B1/Y"],
+		"other-service": ["https://hooks.slack.com/services/T0/B2/Z"]}`
+	slackTemplate = `{"text": "Deployed <backquote><varbegin>.ServiceName<varend><backquote>"}`
+)
+
+func TestRunValidConfig(t *testing.T) {
+	findings := lint.Run(lint.Config{
+		NewRelicMapping: newRelicMapping,
+		SlackMapping:    slackMapping,
+		Templates:       map[string]string{"SSM_PARAMETER_MESSAGE_SLACK": slackTemplate},
+	})
+
+	assert.Empty(t, findings)
+	assert.False(t, lint.HasErrors(findings))
+}
+
+func TestRunDefaultTemplates(t *testing.T) {
+	serviceActionTemplates, _ := json.Marshal(helper.DefaultServiceActionTemplates)
+
+	findings := lint.Run(lint.Config{
+		NewRelicMapping: newRelicMapping,
+		SlackMapping:    slackMapping,
+		Templates: map[string]string{
+			"SSM_PARAMETER_MESSAGE_SLACK_ROLLBACK":       helper.DefaultSlackRollbackTemplate,
+			"SSM_PARAMETER_MESSAGE_SLACK_CODEDEPLOY":     helper.DefaultSlackCodeDeployTemplate,
+			"SSM_PARAMETER_MESSAGE_SLACK_CODEPIPELINE":   helper.DefaultSlackCodePipelineTemplate,
+			"SSM_PARAMETER_MESSAGE_SLACK_LAMBDA":         helper.DefaultSlackLambdaTemplate,
+			"SSM_PARAMETER_MESSAGE_SLACK_CLOUDFORMATION": helper.DefaultSlackCloudFormationTemplate,
+			"SSM_PARAMETER_MESSAGE_SLACK_CRASH_LOOP":     helper.DefaultSlackCrashLoopTemplate,
+			"SSM_PARAMETER_MESSAGE_SLACK_ECR":            helper.DefaultSlackECRTemplate,
+			"SSM_PARAMETER_MESSAGE_SLACK_ECR_DEPLOY":     helper.DefaultSlackECRDeployWarningTemplate,
+		},
+		ServiceActionTemplates: string(serviceActionTemplates),
+	})
+
+	assert.Empty(t, findings)
+}
+
+func TestRunMappingErrors(t *testing.T) {
+	findings := lint.Run(lint.Config{
+		NewRelicMapping: `{"my-service": "my-app"}`,
+		SlackMapping:    `{"my-service": ["http://hooks.slack.com/services/T0/B1/Y"]}`,
+	})
+
+	assert.True(t, lint.HasErrors(findings))
+	assert.Len(t, findings, 3)
+	assert.Contains(t, findings[0].Message, "not numeric")
+	assert.Contains(t, findings[1].Message, "'default-service'")
+	assert.Contains(t, findings[2].Message, "not an https URL")
+
+	findings = lint.Run(lint.Config{NewRelicMapping: `["my-service"]`, SlackMapping: `{"my-service": "hook"}`})
+	assert.Len(t, findings, 2)
+	assert.Equal(t, "New Relic mapping", findings[0].Source)
+	assert.Equal(t, "Slack mapping", findings[1].Source)
+}
+
+func TestRunWebhookReferences(t *testing.T) {
+	findings := lint.Run(lint.Config{
+		NewRelicMapping: `{"my-service": "123456"}`,
+		SlackMapping: `{"default-service": ["secretsmanager:prod/slack#default"],
+			"my-service": ["ssm:/prod/slack/my-service", "secretsmanager:"]}`,
+	})
+
+	assert.Len(t, findings, 1)
+	assert.Contains(t, findings[0].Message, "references no secretsmanager name")
+}
+
+func TestRunWebhookNotAllowed(t *testing.T) {
+	findings := lint.Run(lint.Config{
+		NewRelicMapping: `{"my-service": "123456"}`,
+		SlackMapping: `{"default-service": ["https://hooks.slack.com/services/T0/B0/X"],
+			"my-service": ["https://169.254.169.254/latest/meta-data/"]}`,
+	})
+
+	assert.Len(t, findings, 1)
+	assert.Contains(t, findings[0].Message, "'169.254.169.254', which is not in ALLOWED_HOSTS_SLACK")
+}
+
+func TestRunMappingMismatch(t *testing.T) {
+	findings := lint.Run(lint.Config{
+		NewRelicMapping: `{"my-service": "123456", "only-new-relic": "654321"}`,
+		SlackMapping: `{"default-service": ["https://hooks.slack.com/services/T0/B0/X"],
+			"dora-digest": ["https://hooks.slack.com/services/T0/B0/X"],
+			"my-service": ["https://hooks.slack.com/services/T0/B1/Y"],
+			"only-slack": ["https://hooks.slack.com/services/T0/B1/Y"]}`,
+	})
+
+	assert.False(t, lint.HasErrors(findings))
+	assert.Len(t, findings, 2)
+	assert.Equal(t, lint.SeverityWarning, findings[0].Severity)
+	assert.Contains(t, findings[0].Message, "'only-new-relic'")
+	assert.Contains(t, findings[1].Message, "'only-slack'")
+}
+
+func TestRunTemplateErrors(t *testing.T) {
+	findings := lint.Run(lint.Config{
+		NewRelicMapping: newRelicMapping,
+		SlackMapping:    slackMapping,
+		Templates: map[string]string{
+			// unknown field, invalid JSON and an unknown parameter
+			"SSM_PARAMETER_MESSAGE_SLACK":          `{"text": "<varbegin>.Service<varend>"}`,
+			"SSM_PARAMETER_MESSAGE_SLACK_ROLLBACK": `{"text": "<varbegin>.ServiceName<varend>",}`,
+			"SSM_PARAMETER_MESSAGE_SLACK_OTHER":    slackTemplate,
+		},
+		ServiceActionTemplates: `{"default": "{\"text\": \"<varbegin>.Missing<varend>\"}"}`,
+	})
+
+	assert.True(t, lint.HasErrors(findings))
+	assert.Len(t, findings, 5)
+
+	var messages []string
+	for _, finding := range findings {
+		messages = append(messages, finding.String())
+	}
+	summary := strings.Join(messages, "\n")
+
+	assert.Contains(t, summary, "SSM_PARAMETER_MESSAGE_SLACK: rendering against an ECS deployment")
+	assert.Contains(t, summary, "SSM_PARAMETER_MESSAGE_SLACK_ROLLBACK: rendering against an ECS rollback")
+	assert.Contains(t, summary, "SSM_PARAMETER_MESSAGE_SLACK_OTHER: unknown template parameter")
+	assert.Contains(t, summary, "template 'default' rendering against a service action")
+}
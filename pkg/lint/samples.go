@@ -0,0 +1,151 @@
+package lint
+
+import "deployment-notifications/pkg/helper"
+
+type templateSample struct {
+	description string
+	values      interface{}
+}
+
+// templateSamples are the notification fields every template is rendered
+// against, covering both sides of the conditionals in the defaults
+var templateSamples = map[string][]templateSample{
+	"SSM_PARAMETER_MESSAGE_SLACK": {
+		{"an ECS deployment", sampleDeployment(false)},
+	},
+	"SSM_PARAMETER_MESSAGE_SLACK_ROLLBACK": {
+		{"an ECS rollback", sampleDeployment(true)},
+	},
+	"SSM_PARAMETER_MESSAGE_SLACK_CODEDEPLOY": {
+		{"a CodeDeploy success", helper.CodeDeployNotificationFields{
+			ServiceName: "my-service", Application: "AppECS-my-cluster-my-service",
+			DeploymentGroup: "DgpECS-my-cluster-my-service", DeploymentID: "d-ABCDEF123",
+			State: "SUCCESS", AWSReference: sampleReference, AWSRegion: sampleRegion,
+			AWSAccount: sampleAccount, EventTimestamp: sampleTimestamp}},
+		{"a CodeDeploy rollback", helper.CodeDeployNotificationFields{
+			ServiceName: "my-service", Application: "AppECS-my-cluster-my-service",
+			DeploymentGroup: "DgpECS-my-cluster-my-service", DeploymentID: "d-ABCDEF123",
+			State: "FAILURE", Phase: "Install", Message: "Deployment failed", IsRollback: true,
+			AWSReference: sampleReference, AWSRegion: sampleRegion, AWSAccount: sampleAccount,
+			EventTimestamp: sampleTimestamp}},
+	},
+	"SSM_PARAMETER_MESSAGE_SLACK_CODEPIPELINE": {
+		{"a CodePipeline success", helper.CodePipelineNotificationFields{
+			Pipeline: "my-pipeline", ExecutionID: "01234567-0123-0123-0123-012345678901",
+			Level: helper.CodePipelineLevelPipeline, State: "SUCCEEDED", Headline: "Pipeline my-pipeline succeeded",
+			ServiceName: "my-service", Stages: "Source: Succeeded\\nDeploy: Succeeded",
+			Deployment: "my-service: PRIMARY", ConsoleURL: "https://console.aws.amazon.com/codesuite/",
+			AWSReference: sampleReference, AWSRegion: sampleRegion, AWSAccount: sampleAccount,
+			EventTimestamp: sampleTimestamp}},
+		{"a CodePipeline action failure", helper.CodePipelineNotificationFields{
+			Pipeline: "my-pipeline", ExecutionID: "01234567-0123-0123-0123-012345678901",
+			Level: helper.CodePipelineLevelAction, State: "FAILED", Stage: "Deploy", Action: "Deploy",
+			Headline: "Action Deploy failed", FailedAction: "Deploy / Deploy",
+			FailedActionSummary: "Deployment failed", ConsoleURL: "https://console.aws.amazon.com/codesuite/",
+			AWSReference: sampleReference, AWSRegion: sampleRegion, AWSAccount: sampleAccount,
+			EventTimestamp: sampleTimestamp}},
+	},
+	"SSM_PARAMETER_MESSAGE_SLACK_LAMBDA": {
+		{"a Lambda code update", helper.LambdaNotificationFields{
+			FunctionName: "my-function", EventName: "UpdateFunctionCode", Version: "$LATEST",
+			CodeSHA256: "c0ffee", DeployedBy: "arn:aws:iam::111122223333:user/deployer",
+			AWSReference: sampleReference, AWSRegion: sampleRegion, AWSAccount: sampleAccount,
+			EventTimestamp: sampleTimestamp}},
+		{"a Lambda alias update", helper.LambdaNotificationFields{
+			FunctionName: "my-function", EventName: "UpdateAlias", Version: "7", Alias: "live",
+			CodeSHA256: "c0ffee", DeployedBy: "arn:aws:iam::111122223333:user/deployer",
+			AWSReference: sampleReference, AWSRegion: sampleRegion, AWSAccount: sampleAccount,
+			EventTimestamp: sampleTimestamp}},
+	},
+	"SSM_PARAMETER_MESSAGE_SLACK_CLOUDFORMATION": {
+		{"a CloudFormation stack update", helper.CloudFormationNotificationFields{
+			StackName: "my-stack", StackID: "arn:aws:cloudformation:eu-west-1:111122223333:stack/my-stack/1",
+			ServiceName: "my-service", Status: "UPDATE_COMPLETE", AWSReference: sampleReference,
+			AWSRegion: sampleRegion, AWSAccount: sampleAccount, EventTimestamp: sampleTimestamp}},
+		{"a CloudFormation drift", helper.CloudFormationNotificationFields{
+			StackName: "my-stack", StackID: "arn:aws:cloudformation:eu-west-1:111122223333:stack/my-stack/1",
+			ServiceName: "my-service", Status: "DRIFTED", StatusReason: "Resources drifted", IsDrift: true,
+			DriftedResourceCount: 2, AWSReference: sampleReference, AWSRegion: sampleRegion,
+			AWSAccount: sampleAccount, EventTimestamp: sampleTimestamp}},
+	},
+	"SSM_PARAMETER_MESSAGE_SLACK_CRASH_LOOP": {
+		{"a crash loop", helper.CrashLoopNotificationFields{
+			ServiceName: "my-service", ClusterName: "my-cluster", TaskDefinition: "my-service:42",
+			StopCount: 3, WindowMinutes: 10, StoppedReason: "Essential container in task exited",
+			ExitCodes: "app: 1", DeploymentID: "ecs-svc/123", DeploymentStartedAt: sampleTimestamp,
+			AWSReference: sampleReference, AWSRegion: sampleRegion, AWSAccount: sampleAccount,
+			EventTimestamp: sampleTimestamp}},
+	},
+	"SSM_PARAMETER_MESSAGE_SLACK_ECR": {
+		{"an ECR image push", helper.ECRNotificationFields{
+			ServiceName: "my-service", RepositoryName: "my-repository", EventName: "PUSH",
+			ImageDigest: "sha256:c0ffee", ImageTags: "v1.2.3", AWSReference: sampleReference,
+			AWSRegion: sampleRegion, AWSAccount: sampleAccount, EventTimestamp: sampleTimestamp}},
+		{"an ECR scan with critical findings", helper.ECRNotificationFields{
+			ServiceName: "my-service", RepositoryName: "my-repository", EventName: "SCAN",
+			ImageDigest: "sha256:c0ffee", ImageTags: "v1.2.3", CriticalCount: 2,
+			SeveritySummary: "CRITICAL: 2, HIGH: 1", AWSReference: sampleReference,
+			AWSRegion: sampleRegion, AWSAccount: sampleAccount, EventTimestamp: sampleTimestamp}},
+	},
+	"SSM_PARAMETER_MESSAGE_SLACK_ECR_DEPLOY": {
+		{"a deployment of a flagged image", helper.ECRDeployWarningFields{
+			ServiceName: "my-service", DeploymentID: "ecs-svc/123",
+			Images: "app: my-repository@sha256:c0ffee (2 critical)", AWSReference: sampleReference,
+			AWSRegion: sampleRegion, AWSAccount: sampleAccount}},
+	},
+}
+
+var serviceActionSamples = []templateSample{
+	{"a service action", helper.ServiceActionNotificationFields{
+		ServiceName: "my-service", ClusterName: "my-cluster", EventName: "SERVICE_STEADY_STATE",
+		EventType: "INFO", AWSReference: sampleReference, AWSRegion: sampleRegion, AWSAccount: sampleAccount,
+		EventTimestamp: sampleTimestamp}},
+	{"a service action after a deployment", helper.ServiceActionNotificationFields{
+		ServiceName: "my-service", ClusterName: "my-cluster", EventName: "SERVICE_TASK_PLACEMENT_FAILURE",
+		EventType: "ERROR", Reasons: "RESOURCE:MEMORY", DeploymentID: "ecs-svc/123",
+		DeploymentStartedAt: sampleTimestamp, AWSReference: sampleReference, AWSRegion: sampleRegion,
+		AWSAccount: sampleAccount, EventTimestamp: sampleTimestamp}},
+}
+
+const (
+	sampleReference = "ddca6449-b258-46c0-8653-e0e3a6example"
+	sampleRegion    = "eu-west-1"
+	sampleAccount   = "111122223333"
+	sampleTimestamp = "2021-08-01T12:00:00Z"
+)
+
+func sampleDeployment(isRollback bool) helper.SlackNotificationFields {
+	fields := helper.SlackNotificationFields{
+		ServiceName:           "my-service",
+		DeploymentRevision:    "ecs-svc/123",
+		AWSReference:          sampleReference,
+		AWSRegion:             sampleRegion,
+		AWSAccount:            sampleAccount,
+		DeploymentTimestamp:   sampleTimestamp,
+		DeploymentDescription: "ECS deployment ecs-svc/123 completed.",
+	}
+
+	if isRollback {
+		fields.IsRollback = true
+		fields.FailedRevision = "ecs-svc/124"
+		fields.RollbackTarget = "ecs-svc/123"
+	}
+
+	return fields
+}
+
+// TemplateParameters are the environment variables naming template
+// parameters, in the order they are linted
+func TemplateParameters() []string {
+	return []string{
+		"SSM_PARAMETER_MESSAGE_SLACK",
+		"SSM_PARAMETER_MESSAGE_SLACK_ROLLBACK",
+		"SSM_PARAMETER_MESSAGE_SLACK_CODEDEPLOY",
+		"SSM_PARAMETER_MESSAGE_SLACK_CODEPIPELINE",
+		"SSM_PARAMETER_MESSAGE_SLACK_LAMBDA",
+		"SSM_PARAMETER_MESSAGE_SLACK_CLOUDFORMATION",
+		"SSM_PARAMETER_MESSAGE_SLACK_CRASH_LOOP",
+		"SSM_PARAMETER_MESSAGE_SLACK_ECR",
+		"SSM_PARAMETER_MESSAGE_SLACK_ECR_DEPLOY",
+	}
+}
@@ -0,0 +1,195 @@
+package logger
+
+import (
+	"context"
+	"deployment-notifications/pkg/redact"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type Level int32
+
+const (
+	LevelDebug Level = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+// the fields every line carries, when known
+const (
+	FieldEventID      = "eventId"
+	FieldService      = "service"
+	FieldDeploymentID = "deploymentId"
+	FieldSink         = "sink"
+	FieldRequestID    = "requestId"
+	FieldMessageID    = "messageId"
+	FieldEventType    = "eventType"
+	FieldTraceID      = "traceId"
+)
+
+var levelNames = map[Level]string{
+	LevelDebug: "debug",
+	LevelInfo:  "info",
+	LevelWarn:  "warn",
+	LevelError: "error",
+}
+
+func (level Level) String() string {
+	if name, ok := levelNames[level]; ok {
+		return name
+	}
+
+	return fmt.Sprintf("level(%d)", int32(level))
+}
+
+func ParseLevel(name string) (Level, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "debug":
+		return LevelDebug, nil
+	case "info", "":
+		return LevelInfo, nil
+	case "warn", "warning":
+		return LevelWarn, nil
+	case "error":
+		return LevelError, nil
+	}
+
+	return LevelInfo, fmt.Errorf("unknown log level '%s'", name)
+}
+
+var (
+	currentLevel = int32(levelFromEnv())
+	outputMutex  sync.Mutex
+	output       io.Writer = os.Stdout
+)
+
+func levelFromEnv() Level {
+	// an invalid LOG_LEVEL logs at info rather than failing the Lambda
+	level, _ := ParseLevel(os.Getenv("LOG_LEVEL"))
+	return level
+}
+
+// SetLevel changes the level of every following line, e.g. from the
+// server's /loglevel endpoint
+func SetLevel(level Level) {
+	atomic.StoreInt32(&currentLevel, int32(level))
+}
+
+func GetLevel() Level {
+	return Level(atomic.LoadInt32(&currentLevel))
+}
+
+func SetOutput(writer io.Writer) {
+	outputMutex.Lock()
+	defer outputMutex.Unlock()
+
+	output = writer
+}
+
+type Fields map[string]string
+
+type fieldsKey struct{}
+
+// WithFields returns a context whose log lines carry the given fields
+// on top of the ones already set. Empty values are ignored
+func WithFields(ctx context.Context, fields Fields) context.Context {
+	merged := make(Fields)
+
+	for key, value := range FieldsFrom(ctx) {
+		merged[key] = value
+	}
+
+	for key, value := range fields {
+		if value != "" {
+			merged[key] = value
+		}
+	}
+
+	return context.WithValue(ctx, fieldsKey{}, merged)
+}
+
+func WithField(ctx context.Context, key, value string) context.Context {
+	return WithFields(ctx, Fields{key: value})
+}
+
+func FieldsFrom(ctx context.Context) Fields {
+	if ctx == nil {
+		return nil
+	}
+
+	fields, _ := ctx.Value(fieldsKey{}).(Fields)
+	return fields
+}
+
+func write(ctx context.Context, level Level, message string) {
+	if level < GetLevel() {
+		return
+	}
+
+	line := make(map[string]string)
+
+	for key, value := range FieldsFrom(ctx) {
+		line[key] = redact.String(value)
+	}
+
+	line["time"] = time.Now().UTC().Format(time.RFC3339Nano)
+	line["level"] = level.String()
+	line["message"] = redact.String(message)
+
+	encoded, err := json.Marshal(line)
+	if err != nil {
+		encoded = []byte(fmt.Sprintf(`{"level":"error","message":"Error encoding log line: %v"}`, err))
+	}
+
+	outputMutex.Lock()
+	defer outputMutex.Unlock()
+
+	fmt.Fprintln(output, string(encoded))
+}
+
+func Debug(ctx context.Context, message string) {
+	write(ctx, LevelDebug, message)
+}
+
+func Debugf(ctx context.Context, format string, args ...interface{}) {
+	if LevelDebug >= GetLevel() {
+		write(ctx, LevelDebug, fmt.Sprintf(format, args...))
+	}
+}
+
+func Info(ctx context.Context, message string) {
+	write(ctx, LevelInfo, message)
+}
+
+func Infof(ctx context.Context, format string, args ...interface{}) {
+	write(ctx, LevelInfo, fmt.Sprintf(format, args...))
+}
+
+func Warn(ctx context.Context, message string) {
+	write(ctx, LevelWarn, message)
+}
+
+func Warnf(ctx context.Context, format string, args ...interface{}) {
+	write(ctx, LevelWarn, fmt.Sprintf(format, args...))
+}
+
+func Error(ctx context.Context, message string) {
+	write(ctx, LevelError, message)
+}
+
+func Errorf(ctx context.Context, format string, args ...interface{}) {
+	write(ctx, LevelError, fmt.Sprintf(format, args...))
+}
+
+// Fatalf logs at error level and exits, for the commands' start up
+func Fatalf(ctx context.Context, format string, args ...interface{}) {
+	write(ctx, LevelError, fmt.Sprintf(format, args...))
+	os.Exit(1)
+}
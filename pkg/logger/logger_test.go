@@ -0,0 +1,99 @@
+package logger_test
+
+import (
+	"bytes"
+	"context"
+	"deployment-notifications/pkg/logger"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"strings"
+	"testing"
+)
+
+func captureLines(t *testing.T, log func()) []map[string]string {
+	var buffer bytes.Buffer
+
+	logger.SetOutput(&buffer)
+	defer logger.SetOutput(os.Stdout)
+
+	log()
+
+	var lines []map[string]string
+	for _, line := range strings.Split(strings.TrimSpace(buffer.String()), "\n") {
+		if line == "" {
+			continue
+		}
+
+		var decoded map[string]string
+		assert.Nil(t, json.Unmarshal([]byte(line), &decoded))
+		lines = append(lines, decoded)
+	}
+
+	return lines
+}
+
+func TestParseLevel(t *testing.T) {
+	for name, expected := range map[string]logger.Level{
+		"debug": logger.LevelDebug, "INFO": logger.LevelInfo, "": logger.LevelInfo,
+		"warning": logger.LevelWarn, "error": logger.LevelError,
+	} {
+		level, err := logger.ParseLevel(name)
+		assert.Nil(t, err)
+		assert.Equal(t, expected, level)
+	}
+
+	_, err := logger.ParseLevel("verbose")
+	assert.NotNil(t, err)
+	assert.Equal(t, "warn", logger.LevelWarn.String())
+}
+
+func TestFields(t *testing.T) {
+	ctx := logger.WithFields(context.Background(), logger.Fields{
+		logger.FieldEventID: "event-1",
+		logger.FieldService: "",
+	})
+	ctx = logger.WithField(ctx, logger.FieldService, "my-service")
+
+	lines := captureLines(t, func() {
+		logger.Infof(ctx, "Posting to %s", "Slack")
+		logger.Warn(logger.WithField(ctx, logger.FieldSink, "slack"), "100% failed")
+	})
+
+	assert.Len(t, lines, 2)
+	assert.Equal(t, "info", lines[0]["level"])
+	assert.Equal(t, "Posting to Slack", lines[0]["message"])
+	assert.Equal(t, "event-1", lines[0][logger.FieldEventID])
+	assert.Equal(t, "my-service", lines[0][logger.FieldService])
+	assert.NotContains(t, lines[0], logger.FieldSink)
+	assert.NotEmpty(t, lines[0]["time"])
+
+	assert.Equal(t, "warn", lines[1]["level"])
+	assert.Equal(t, "100% failed", lines[1]["message"])
+	assert.Equal(t, "slack", lines[1][logger.FieldSink])
+}
+
+func TestSetLevel(t *testing.T) {
+	defer logger.SetLevel(logger.GetLevel())
+
+	logger.SetLevel(logger.LevelWarn)
+
+	lines := captureLines(t, func() {
+		logger.Debugf(context.Background(), "debug")
+		logger.Info(context.Background(), "info")
+		logger.Warn(context.Background(), "warn")
+		logger.Errorf(context.Background(), "error %d", 1)
+	})
+
+	assert.Len(t, lines, 2)
+	assert.Equal(t, "warn", lines[0]["message"])
+	assert.Equal(t, "error 1", lines[1]["message"])
+
+	logger.SetLevel(logger.LevelDebug)
+
+	lines = captureLines(t, func() {
+		logger.Debug(context.Background(), "debug")
+	})
+
+	assert.Len(t, lines, 1)
+}
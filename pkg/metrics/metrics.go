@@ -0,0 +1,277 @@
+package metrics
+
+import (
+	"context"
+	"deployment-notifications/pkg/logger"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+// the metrics every invocation reports
+const (
+	EventsReceived      = "EventsReceived"
+	EventsFiltered      = "EventsFiltered"
+	EventsProcessed     = "EventsProcessed"
+	DeliveriesAttempted = "DeliveriesAttempted"
+	DeliveriesSucceeded = "DeliveriesSucceeded"
+	DeliveriesFailed    = "DeliveriesFailed"
+	Retries             = "Retries"
+	SinkLatency         = "SinkLatency"
+)
+
+const (
+	UnitCount        = "Count"
+	UnitMilliseconds = "Milliseconds"
+)
+
+const DefaultNamespace = "DeploymentNotifications"
+
+// Dimensions are taken from the log fields of the context, in this order.
+// Fields that are not set are left out of a metric's dimensions
+var Dimensions = []string{logger.FieldService, logger.FieldSink, logger.FieldEventType}
+
+// EMF accepts at most 100 values per metric in one document
+const maxValuesPerMetric = 100
+
+var (
+	outputMutex sync.Mutex
+	output      io.Writer = os.Stdout
+)
+
+func SetOutput(writer io.Writer) {
+	outputMutex.Lock()
+	defer outputMutex.Unlock()
+
+	output = writer
+}
+
+func GetNamespace() string {
+	if namespace := os.Getenv("METRICS_NAMESPACE"); namespace != "" {
+		return namespace
+	}
+
+	return DefaultNamespace
+}
+
+type metric struct {
+	unit   string
+	values []float64
+}
+
+// series are the metrics sharing the same dimension values, which
+// become one EMF document
+type series struct {
+	dimensions map[string]string
+	metrics    map[string]*metric
+}
+
+type Recorder struct {
+	mutex  sync.Mutex
+	series map[string]*series
+}
+
+type recorderKey struct{}
+
+// WithRecorder returns a context collecting the metrics of one
+// invocation until they are written by Flush
+func WithRecorder(ctx context.Context) context.Context {
+	return context.WithValue(ctx, recorderKey{}, &Recorder{series: make(map[string]*series)})
+}
+
+func recorderFrom(ctx context.Context) *Recorder {
+	if ctx == nil {
+		return nil
+	}
+
+	recorder, _ := ctx.Value(recorderKey{}).(*Recorder)
+	return recorder
+}
+
+func record(ctx context.Context, name, unit string, value float64) {
+	// metrics recorded outside of an invocation are dropped
+	recorder := recorderFrom(ctx)
+	if recorder == nil {
+		return
+	}
+
+	fields := logger.FieldsFrom(ctx)
+	dimensions := make(map[string]string)
+	key := ""
+
+	for _, dimension := range Dimensions {
+		if value, ok := fields[dimension]; ok {
+			dimensions[dimension] = value
+			key += fmt.Sprintf("%s=%q;", dimension, value)
+		}
+	}
+
+	recorder.mutex.Lock()
+	defer recorder.mutex.Unlock()
+
+	current, ok := recorder.series[key]
+	if !ok {
+		current = &series{dimensions: dimensions, metrics: make(map[string]*metric)}
+		recorder.series[key] = current
+	}
+
+	entry, ok := current.metrics[name]
+	if !ok {
+		entry = &metric{unit: unit}
+		current.metrics[name] = entry
+	}
+
+	// counts are summed, other values kept for CloudWatch's statistics
+	if unit == UnitCount && len(entry.values) > 0 {
+		entry.values[0] += value
+	} else {
+		entry.values = append(entry.values, value)
+	}
+}
+
+func Count(ctx context.Context, name string, value float64) {
+	record(ctx, name, UnitCount, value)
+}
+
+func Duration(ctx context.Context, name string, duration time.Duration) {
+	record(ctx, name, UnitMilliseconds, float64(duration)/float64(time.Millisecond))
+}
+
+type metricDefinition struct {
+	Name string `json:"Name"`
+	Unit string `json:"Unit"`
+}
+
+type metricDirective struct {
+	Namespace  string             `json:"Namespace"`
+	Dimensions [][]string         `json:"Dimensions"`
+	Metrics    []metricDefinition `json:"Metrics"`
+}
+
+type metadata struct {
+	Timestamp         int64             `json:"Timestamp"`
+	CloudWatchMetrics []metricDirective `json:"CloudWatchMetrics"`
+}
+
+// Documents renders the recorded metrics in the CloudWatch embedded
+// metric format, one document per set of dimension values
+func (recorder *Recorder) Documents(namespace string, timestamp time.Time) []map[string]interface{} {
+	recorder.mutex.Lock()
+	defer recorder.mutex.Unlock()
+
+	return renderDocuments(recorder.series, namespace, timestamp)
+}
+
+// take returns the recorded series and starts over, in one go so that
+// nothing recorded in between is lost
+func (recorder *Recorder) take() map[string]*series {
+	recorder.mutex.Lock()
+	defer recorder.mutex.Unlock()
+
+	recorded := recorder.series
+	recorder.series = make(map[string]*series)
+
+	return recorded
+}
+
+func renderDocuments(recorded map[string]*series, namespace string, timestamp time.Time) []map[string]interface{} {
+	var keys []string
+	for key := range recorded {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var documents []map[string]interface{}
+
+	for _, key := range keys {
+		current := recorded[key]
+		dimensionNames := []string{}
+
+		for _, dimension := range Dimensions {
+			if _, ok := current.dimensions[dimension]; ok {
+				dimensionNames = append(dimensionNames, dimension)
+			}
+		}
+
+		var names []string
+		chunks := 1
+		for name, entry := range current.metrics {
+			names = append(names, name)
+
+			if entryChunks := (len(entry.values) + maxValuesPerMetric - 1) / maxValuesPerMetric; entryChunks > chunks {
+				chunks = entryChunks
+			}
+		}
+		sort.Strings(names)
+
+		// values beyond what one document takes go to further documents
+		// with the same dimensions, rather than being dropped
+		for chunk := 0; chunk < chunks; chunk++ {
+			document := make(map[string]interface{})
+			directive := metricDirective{Namespace: namespace, Dimensions: [][]string{dimensionNames}}
+
+			for _, dimension := range dimensionNames {
+				document[dimension] = current.dimensions[dimension]
+			}
+
+			for _, name := range names {
+				entry := current.metrics[name]
+
+				start := chunk * maxValuesPerMetric
+				if start >= len(entry.values) {
+					continue
+				}
+
+				end := start + maxValuesPerMetric
+				if end > len(entry.values) {
+					end = len(entry.values)
+				}
+
+				directive.Metrics = append(directive.Metrics, metricDefinition{Name: name, Unit: entry.unit})
+
+				if values := entry.values[start:end]; len(values) == 1 {
+					document[name] = values[0]
+				} else {
+					document[name] = values
+				}
+			}
+
+			document["_aws"] = metadata{
+				Timestamp:         timestamp.UnixNano() / int64(time.Millisecond),
+				CloudWatchMetrics: []metricDirective{directive},
+			}
+
+			documents = append(documents, document)
+		}
+	}
+
+	return documents
+}
+
+// Flush writes the metrics recorded in the context as EMF log lines,
+// which CloudWatch Logs turns into metrics without any API calls
+func Flush(ctx context.Context) {
+	recorder := recorderFrom(ctx)
+	if recorder == nil {
+		return
+	}
+
+	documents := renderDocuments(recorder.take(), GetNamespace(), time.Now())
+
+	outputMutex.Lock()
+	defer outputMutex.Unlock()
+
+	for _, document := range documents {
+		encoded, err := json.Marshal(document)
+		if err != nil {
+			logger.Errorf(ctx, "Error encoding metrics: %v", err)
+			continue
+		}
+
+		fmt.Fprintln(output, string(encoded))
+	}
+}
@@ -0,0 +1,156 @@
+package metrics_test
+
+import (
+	"bytes"
+	"context"
+	"deployment-notifications/pkg/logger"
+	"deployment-notifications/pkg/metrics"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func flushDocuments(t *testing.T, ctx context.Context) []map[string]interface{} {
+	var buffer bytes.Buffer
+
+	metrics.SetOutput(&buffer)
+	defer metrics.SetOutput(os.Stdout)
+
+	metrics.Flush(ctx)
+
+	var documents []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(buffer.String()), "\n") {
+		if line == "" {
+			continue
+		}
+
+		var decoded map[string]interface{}
+		assert.Nil(t, json.Unmarshal([]byte(line), &decoded))
+		documents = append(documents, decoded)
+	}
+
+	return documents
+}
+
+func TestFlushEmbeddedMetricFormat(t *testing.T) {
+	ctx := metrics.WithRecorder(context.Background())
+	ctx = logger.WithField(ctx, logger.FieldEventType, "ECS Deployment State Change")
+
+	metrics.Count(ctx, metrics.EventsReceived, 1)
+
+	sinkCtx := logger.WithFields(ctx, logger.Fields{logger.FieldService: "my-service", logger.FieldSink: "slack"})
+	metrics.Count(sinkCtx, metrics.DeliveriesAttempted, 1)
+	metrics.Count(sinkCtx, metrics.DeliveriesAttempted, 1)
+	metrics.Duration(sinkCtx, metrics.SinkLatency, 150*time.Millisecond)
+	metrics.Duration(sinkCtx, metrics.SinkLatency, 50*time.Millisecond)
+
+	documents := flushDocuments(t, ctx)
+	assert.Len(t, documents, 2)
+
+	events := documents[0]
+	assert.Equal(t, "ECS Deployment State Change", events["eventType"])
+	assert.Equal(t, float64(1), events["EventsReceived"])
+
+	directive := events["_aws"].(map[string]interface{})["CloudWatchMetrics"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, metrics.DefaultNamespace, directive["Namespace"])
+	assert.Equal(t, []interface{}{[]interface{}{"eventType"}}, directive["Dimensions"])
+	assert.Equal(t, []interface{}{map[string]interface{}{"Name": "EventsReceived", "Unit": "Count"}},
+		directive["Metrics"])
+
+	deliveries := documents[1]
+	assert.Equal(t, "my-service", deliveries["service"])
+	assert.Equal(t, "slack", deliveries["sink"])
+	assert.Equal(t, float64(2), deliveries["DeliveriesAttempted"])
+	assert.Equal(t, []interface{}{float64(150), float64(50)}, deliveries["SinkLatency"])
+
+	directive = deliveries["_aws"].(map[string]interface{})["CloudWatchMetrics"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, []interface{}{[]interface{}{"service", "sink", "eventType"}}, directive["Dimensions"])
+
+	// flushing again writes nothing new
+	assert.Len(t, flushDocuments(t, ctx), 0)
+}
+
+func TestNamespace(t *testing.T) {
+	assert.Equal(t, metrics.DefaultNamespace, metrics.GetNamespace())
+
+	os.Setenv("METRICS_NAMESPACE", "Custom")
+	defer os.Unsetenv("METRICS_NAMESPACE")
+
+	assert.Equal(t, "Custom", metrics.GetNamespace())
+}
+
+func TestWithoutRecorder(t *testing.T) {
+	// metrics outside of an invocation are dropped rather than failing
+	ctx := context.Background()
+	metrics.Count(ctx, metrics.EventsReceived, 1)
+
+	assert.Len(t, flushDocuments(t, ctx), 0)
+}
+
+func TestFlushSplitsValues(t *testing.T) {
+	ctx := metrics.WithRecorder(context.Background())
+	ctx = logger.WithField(ctx, logger.FieldSink, "slack")
+
+	metrics.Count(ctx, metrics.DeliveriesAttempted, 250)
+	for i := 0; i < 250; i++ {
+		metrics.Duration(ctx, metrics.SinkLatency, time.Duration(i)*time.Millisecond)
+	}
+
+	// EMF takes 100 values per metric, the rest go to further documents
+	documents := flushDocuments(t, ctx)
+	assert.Len(t, documents, 3)
+
+	var latencies []interface{}
+	for i, document := range documents {
+		assert.Equal(t, "slack", document["sink"])
+		latencies = append(latencies, document["SinkLatency"].([]interface{})...)
+
+		directive := document["_aws"].(map[string]interface{})["CloudWatchMetrics"].([]interface{})[0].(map[string]interface{})
+		if i == 0 {
+			assert.Equal(t, float64(250), document["DeliveriesAttempted"])
+			assert.Len(t, directive["Metrics"], 2)
+		} else {
+			assert.NotContains(t, document, "DeliveriesAttempted")
+			assert.Len(t, directive["Metrics"], 1)
+		}
+	}
+
+	assert.Len(t, documents[0]["SinkLatency"], 100)
+	assert.Len(t, documents[2]["SinkLatency"], 50)
+	assert.Len(t, latencies, 250)
+	assert.Equal(t, float64(249), latencies[249])
+}
+
+func TestFlushWhileRecording(t *testing.T) {
+	ctx := metrics.WithRecorder(context.Background())
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		for i := 0; i < 1000; i++ {
+			metrics.Count(ctx, metrics.EventsReceived, 1)
+		}
+	}()
+
+	// every count ends up in exactly one flush
+	total := float64(0)
+	flushed := false
+
+	for !flushed {
+		select {
+		case <-done:
+			flushed = true
+		default:
+		}
+
+		for _, document := range flushDocuments(t, ctx) {
+			total += document["EventsReceived"].(float64)
+		}
+	}
+
+	assert.Equal(t, float64(1000), total)
+}
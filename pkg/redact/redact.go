@@ -0,0 +1,141 @@
+package redact
+
+import (
+	"net/url"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const Mask = "<redacted>"
+
+// DefaultFields are always masked when they appear as keys, e.g. in
+// JSON bodies or query strings. REDACT_FIELDS adds to them
+var DefaultFields = []string{"api_key", "apikey", "api-key", "token", "password", "secret", "authorization"}
+
+var (
+	// the last path segment of a Slack webhook is its secret, the team
+	// and channel IDs before it are kept to tell webhooks apart
+	slackWebhookPattern = regexp.MustCompile(`(https://hooks\.slack\.com/(?:services|workflows|triggers)/(?:[A-Za-z0-9]+/)*)[A-Za-z0-9]{8,}`)
+	tokenPatterns       = []*regexp.Regexp{
+		// New Relic user, license and insights keys
+		regexp.MustCompile(`\bNR(?:AK|AA|II|IQ)-[A-Za-z0-9]{10,}\b`),
+		regexp.MustCompile(`\b[a-f0-9]{32,36}NRAL\b`),
+		// Slack bot, user and app tokens
+		regexp.MustCompile(`\bxox[abeoprs]-[A-Za-z0-9-]{10,}\b`),
+		regexp.MustCompile(`\bxapp-[A-Za-z0-9-]{10,}\b`),
+	}
+
+	fieldsMutex   sync.Mutex
+	fieldsEnv     *string
+	fieldsPattern *regexp.Regexp
+
+	secretsMutex sync.RWMutex
+	secrets      = make(map[string]string)
+)
+
+// RegisterSecret masks every later occurrence of value, e.g. an API key
+// read from Secrets Manager or a webhook URL from a mapping
+func RegisterSecret(value string) {
+	value = strings.TrimSpace(value)
+
+	// short values would mask unrelated text
+	if len(value) < 8 {
+		return
+	}
+
+	replacement := Mask
+	if parsed, err := url.Parse(value); err == nil && parsed.Scheme != "" && parsed.Host != "" {
+		replacement = parsed.Scheme + "://" + parsed.Host + "/" + Mask
+	}
+
+	secretsMutex.Lock()
+	defer secretsMutex.Unlock()
+
+	secrets[value] = replacement
+}
+
+func getFieldsPattern() *regexp.Regexp {
+	extraFields := os.Getenv("REDACT_FIELDS")
+
+	fieldsMutex.Lock()
+	defer fieldsMutex.Unlock()
+
+	if fieldsEnv != nil && *fieldsEnv == extraFields {
+		return fieldsPattern
+	}
+
+	var names []string
+	for _, name := range append(DefaultFields, strings.Split(extraFields, ",")...) {
+		if trimmed := strings.TrimSpace(name); trimmed != "" {
+			names = append(names, regexp.QuoteMeta(trimmed))
+		}
+	}
+
+	// "field": "value", field=value and field: value, as printed by
+	// JSON, query strings, headers and fmt's %v of a map
+	fieldsPattern = regexp.MustCompile(`(?i)(\b(?:` + strings.Join(names, "|") +
+		`)\b"?\s*[:=]\s*"?(?:Bearer\s+)?)[^"\s,&}\]]+`)
+	fieldsEnv = &extraFields
+
+	return fieldsPattern
+}
+
+func String(value string) string {
+	if value == "" {
+		return value
+	}
+
+	value = slackWebhookPattern.ReplaceAllString(value, "${1}"+Mask)
+
+	secretsMutex.RLock()
+	registered := make([]string, 0, len(secrets))
+	for secret := range secrets {
+		registered = append(registered, secret)
+	}
+
+	// longest first, so a secret containing another is masked whole
+	sort.Slice(registered, func(i, j int) bool { return len(registered[i]) > len(registered[j]) })
+
+	for _, secret := range registered {
+		value = strings.ReplaceAll(value, secret, secrets[secret])
+	}
+	secretsMutex.RUnlock()
+
+	for _, pattern := range tokenPatterns {
+		value = pattern.ReplaceAllString(value, Mask)
+	}
+
+	return getFieldsPattern().ReplaceAllString(value, "${1}"+Mask)
+}
+
+type redactedError struct {
+	message string
+	err     error
+}
+
+func (e *redactedError) Error() string {
+	return e.message
+}
+
+func (e *redactedError) Unwrap() error {
+	return e.err
+}
+
+// Error masks the message of err while keeping it unwrappable
+func Error(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	message := err.Error()
+	redacted := String(message)
+
+	if redacted == message {
+		return err
+	}
+
+	return &redactedError{message: redacted, err: err}
+}
@@ -0,0 +1,329 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"crypto/tls"
+	"deployment-notifications/pkg/helper"
+	"deployment-notifications/pkg/logger"
+	"deployment-notifications/pkg/tracing"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// EventHandler processes one event payload, the same way the Lambda
+// entry point does
+type EventHandler func(ctx context.Context, payload json.RawMessage) (interface{}, error)
+
+// Config of the server. Without a TLS certificate and key it serves
+// plain HTTP and must sit behind a proxy or load balancer terminating
+// TLS, as API destinations only send events over HTTPS
+type Config struct {
+	Address         string
+	AuthHeader      string
+	AuthToken       string
+	MaxBodyBytes    int64
+	ShutdownTimeout time.Duration
+	TLSCertFile     string
+	TLSKeyFile      string
+}
+
+type Server struct {
+	config      Config
+	handleEvent EventHandler
+	ready       int32
+}
+
+type logLevel struct {
+	Level string `json:"level"`
+}
+
+type response struct {
+	Message string `json:"message"`
+	Error   string `json:"error,omitempty"`
+}
+
+func LoadConfig() Config {
+	// EventBridge caps events at 256 KB, which is the default body limit
+	return Config{
+		Address:         helper.GetStringEnv("SERVER_ADDRESS", ":8080"),
+		AuthHeader:      helper.GetStringEnv("SERVER_AUTH_HEADER", "Authorization"),
+		AuthToken:       helper.GetStringEnv("SERVER_AUTH_TOKEN", ""),
+		MaxBodyBytes:    int64(helper.GetIntEnv("SERVER_MAX_BODY_BYTES", 256*1024)),
+		ShutdownTimeout: time.Duration(helper.GetIntEnv("SERVER_SHUTDOWN_TIMEOUT", 30)) * time.Second,
+		TLSCertFile:     helper.GetStringEnv("TLS_CERT_FILE", ""),
+		TLSKeyFile:      helper.GetStringEnv("TLS_KEY_FILE", ""),
+	}
+}
+
+func New(config Config, handleEvent EventHandler) (*Server, error) {
+	if config.AuthToken == "" {
+		return nil, helper.WrapError("An auth token is required to accept events", nil)
+	}
+
+	if (config.TLSCertFile == "") != (config.TLSKeyFile == "") {
+		return nil, helper.WrapError("TLS needs both a certificate and a key file", nil)
+	}
+
+	if config.AuthHeader == "" {
+		config.AuthHeader = "Authorization"
+	}
+
+	return &Server{config: config, handleEvent: handleEvent}, nil
+}
+
+func (s *Server) SetReady(ready bool) {
+	var value int32
+	if ready {
+		value = 1
+	}
+
+	atomic.StoreInt32(&s.ready, value)
+}
+
+func (s *Server) IsReady() bool {
+	return atomic.LoadInt32(&s.ready) == 1
+}
+
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/events", s.serveEvents)
+	mux.HandleFunc("/healthz", s.serveHealth)
+	mux.HandleFunc("/readyz", s.serveReady)
+	mux.HandleFunc("/loglevel", s.serveLogLevel)
+
+	return mux
+}
+
+func (s *Server) ListenAndServe(ctx context.Context) error {
+	// serves until the context is done, then stops taking new requests
+	// and waits up to the shutdown timeout for in-flight events
+	httpServer := &http.Server{
+		Addr:              s.config.Address,
+		Handler:           s.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+		ReadTimeout:       30 * time.Second,
+		IdleTimeout:       120 * time.Second,
+	}
+
+	tlsEnabled := s.config.TLSCertFile != ""
+
+	// the certificate is loaded up front, so a bad one fails the start
+	// rather than every handshake
+	if tlsEnabled {
+		certificate, err := tls.LoadX509KeyPair(s.config.TLSCertFile, s.config.TLSKeyFile)
+		if err != nil {
+			return helper.WrapError("Error loading TLS certificate", err)
+		}
+
+		httpServer.TLSConfig = &tls.Config{Certificates: []tls.Certificate{certificate}, MinVersion: tls.VersionTLS12}
+	}
+
+	// the server is only ready once it holds its address
+	listener, err := net.Listen("tcp", s.config.Address)
+	if err != nil {
+		return helper.WrapError(fmt.Sprintf("Error listening on %s", s.config.Address), err)
+	}
+
+	serveErr := make(chan error, 1)
+
+	go func() {
+		if tlsEnabled {
+			logger.Infof(ctx, "Listening on %s with TLS", listener.Addr())
+			serveErr <- httpServer.ServeTLS(listener, "", "")
+			return
+		}
+
+		logger.Infof(ctx, "Listening on %s", listener.Addr())
+		serveErr <- httpServer.Serve(listener)
+	}()
+
+	s.SetReady(true)
+
+	select {
+	case err := <-serveErr:
+		s.SetReady(false)
+		return err
+	case <-ctx.Done():
+	}
+
+	logger.Info(ctx, "Shutting down, waiting for in-flight events")
+	s.SetReady(false)
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), s.config.ShutdownTimeout)
+	defer cancel()
+
+	err = httpServer.Shutdown(shutdownCtx)
+	if err != nil {
+		return helper.WrapError("Error shutting down server", err)
+	}
+
+	return nil
+}
+
+func (s *Server) serveHealth(w http.ResponseWriter, r *http.Request) {
+	writeResponse(w, http.StatusOK, response{Message: "ok"})
+}
+
+func (s *Server) serveReady(w http.ResponseWriter, r *http.Request) {
+	if !s.IsReady() {
+		writeResponse(w, http.StatusServiceUnavailable, response{Message: "not ready"})
+		return
+	}
+
+	writeResponse(w, http.StatusOK, response{Message: "ready"})
+}
+
+func (s *Server) serveEvents(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		writeResponse(w, http.StatusMethodNotAllowed, response{Message: "Only POST is supported"})
+		return
+	}
+
+	if !s.authorized(r) {
+		writeResponse(w, http.StatusUnauthorized, response{Message: "Unauthorized"})
+		return
+	}
+
+	// one byte more than allowed is read to tell a body at the limit
+	// from one that goes beyond it
+	body, err := ioutil.ReadAll(io.LimitReader(r.Body, s.config.MaxBodyBytes+1))
+
+	switch {
+	case err == nil && int64(len(body)) > s.config.MaxBodyBytes:
+		writeResponse(w, http.StatusRequestEntityTooLarge, response{Message: "Request body too large"})
+		return
+	case errors.Is(err, io.ErrUnexpectedEOF) || (err != nil && r.Context().Err() != nil):
+		// the client went away or sent less than it announced
+		writeResponse(w, http.StatusBadRequest, response{Message: "Request body is incomplete"})
+		return
+	case err != nil:
+		logger.Errorf(r.Context(), "Error reading request body: %v", err)
+		writeResponse(w, http.StatusInternalServerError, response{Message: "Error reading request body"})
+		return
+	}
+
+	if !json.Valid(body) {
+		writeResponse(w, http.StatusBadRequest, response{Message: "Request body is not valid JSON"})
+		return
+	}
+
+	ctx := logger.WithField(r.Context(), logger.FieldRequestID, requestID(r))
+
+	if parent, ok := tracing.Extract(r.Header); ok {
+		ctx = tracing.ContextWithRemoteParent(ctx, parent)
+	}
+
+	result, err := s.handleEvent(ctx, json.RawMessage(body))
+
+	if err != nil {
+		// EventBridge API destinations retry server errors
+		writeResponse(w, http.StatusInternalServerError, response{Message: resultMessage(result), Error: err.Error()})
+		return
+	}
+
+	if result == nil {
+		writeResponse(w, http.StatusOK, response{})
+		return
+	}
+
+	// the result as the Lambda would return it, e.g. with the deliveries
+	writeResponse(w, http.StatusOK, result)
+}
+
+func (s *Server) serveLogLevel(w http.ResponseWriter, r *http.Request) {
+	// GET shows the current level, PUT with {"level": "debug"} changes it
+	// until the process restarts
+	if !s.authorized(r) {
+		writeResponse(w, http.StatusUnauthorized, response{Message: "Unauthorized"})
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPut, http.MethodPost:
+		var request logLevel
+
+		err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1024)).Decode(&request)
+		if err != nil {
+			writeResponse(w, http.StatusBadRequest, response{Message: "Request body is not valid JSON"})
+			return
+		}
+
+		level, err := logger.ParseLevel(request.Level)
+		if err != nil {
+			writeResponse(w, http.StatusBadRequest, response{Message: err.Error()})
+			return
+		}
+
+		logger.SetLevel(level)
+		logger.Infof(r.Context(), "Log level set to '%s'", level)
+	default:
+		w.Header().Set("Allow", "GET, PUT, POST")
+		writeResponse(w, http.StatusMethodNotAllowed, response{Message: "Only GET, PUT and POST are supported"})
+		return
+	}
+
+	writeResponse(w, http.StatusOK, logLevel{Level: logger.GetLevel().String()})
+}
+
+func requestID(r *http.Request) string {
+	// API destinations don't send one, but proxies in front of the server may
+	if id := r.Header.Get("X-Request-Id"); id != "" {
+		return id
+	}
+
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return ""
+	}
+
+	return hex.EncodeToString(id)
+}
+
+func (s *Server) authorized(r *http.Request) bool {
+	// API destination connections send the token either as a bearer
+	// token or as the value of an API key header
+	token := r.Header.Get(s.config.AuthHeader)
+
+	if strings.EqualFold(s.config.AuthHeader, "Authorization") && strings.HasPrefix(token, "Bearer ") {
+		token = strings.TrimPrefix(token, "Bearer ")
+	}
+
+	return subtle.ConstantTimeCompare([]byte(token), []byte(s.config.AuthToken)) == 1
+}
+
+func resultMessage(result interface{}) string {
+	if messenger, ok := result.(interface{ Message() string }); ok {
+		return messenger.Message()
+	}
+
+	return ""
+}
+
+func writeResponse(w http.ResponseWriter, status int, body interface{}) {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		status = http.StatusInternalServerError
+		payload = []byte(`{"message":"Error encoding response"}`)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	if _, err := w.Write(payload); err != nil {
+		logger.Warnf(context.Background(), "Error writing response: %v", err)
+	}
+}
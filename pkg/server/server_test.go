@@ -0,0 +1,358 @@
+package server_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"deployment-notifications/pkg/logger"
+	"deployment-notifications/pkg/server"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+type messageResponse struct {
+	Reason string `json:"message"`
+}
+
+func (response messageResponse) Message() string {
+	return response.Reason
+}
+
+const sampleEvent = `{"source": "aws.ecs", "detail-type": "ECS Deployment State Change", "detail": {}}`
+
+func newTestServer(t *testing.T, handleEvent server.EventHandler) *server.Server {
+	eventServer, err := server.New(server.Config{AuthToken: "s3cret", MaxBodyBytes: 1024}, handleEvent)
+	assert.Nil(t, err)
+
+	return eventServer
+}
+
+func postEvent(eventServer *server.Server, body string, headers map[string]string) *httptest.ResponseRecorder {
+	request := httptest.NewRequest(http.MethodPost, "/events", strings.NewReader(body))
+	for name, value := range headers {
+		request.Header.Set(name, value)
+	}
+
+	recorder := httptest.NewRecorder()
+	eventServer.Handler().ServeHTTP(recorder, request)
+
+	return recorder
+}
+
+func TestNewServer(t *testing.T) {
+	_, err := server.New(server.Config{}, nil)
+	assert.NotNil(t, err)
+
+	_, err = server.New(server.Config{AuthToken: "s3cret", TLSCertFile: "cert.pem"}, nil)
+	assert.NotNil(t, err)
+}
+
+func TestServeEvents(t *testing.T) {
+	var received json.RawMessage
+
+	eventServer := newTestServer(t, func(ctx context.Context, payload json.RawMessage) (interface{}, error) {
+		received = payload
+		return messageResponse{Reason: "Notification complete!"}, nil
+	})
+
+	recorder := postEvent(eventServer, sampleEvent, map[string]string{"Authorization": "Bearer s3cret"})
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.JSONEq(t, `{"message": "Notification complete!"}`, recorder.Body.String())
+	assert.JSONEq(t, sampleEvent, string(received))
+
+	recorder = postEvent(eventServer, sampleEvent, map[string]string{"Authorization": "s3cret"})
+	assert.Equal(t, http.StatusOK, recorder.Code)
+}
+
+func TestServeEventsRejected(t *testing.T) {
+	calls := 0
+
+	eventServer := newTestServer(t, func(ctx context.Context, payload json.RawMessage) (interface{}, error) {
+		calls++
+		return messageResponse{Reason: "Notification complete!"}, nil
+	})
+
+	recorder := postEvent(eventServer, sampleEvent, nil)
+	assert.Equal(t, http.StatusUnauthorized, recorder.Code)
+
+	recorder = postEvent(eventServer, sampleEvent, map[string]string{"Authorization": "Bearer wrong"})
+	assert.Equal(t, http.StatusUnauthorized, recorder.Code)
+
+	recorder = postEvent(eventServer, `{"detail": "`+strings.Repeat("x", 2048)+`"}`,
+		map[string]string{"Authorization": "Bearer s3cret"})
+	assert.Equal(t, http.StatusRequestEntityTooLarge, recorder.Code)
+
+	recorder = postEvent(eventServer, `not json`, map[string]string{"Authorization": "Bearer s3cret"})
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	request := httptest.NewRequest(http.MethodGet, "/events", nil)
+	request.Header.Set("Authorization", "Bearer s3cret")
+	recorder = httptest.NewRecorder()
+	eventServer.Handler().ServeHTTP(recorder, request)
+	assert.Equal(t, http.StatusMethodNotAllowed, recorder.Code)
+
+	assert.Equal(t, 0, calls)
+}
+
+type failingReader struct {
+	err error
+}
+
+func (reader failingReader) Read(p []byte) (int, error) {
+	return 0, reader.err
+}
+
+func TestServeEventsBodyReadFailure(t *testing.T) {
+	eventServer := newTestServer(t, func(ctx context.Context, payload json.RawMessage) (interface{}, error) {
+		t.Error("an unread event must not be handled")
+		return nil, nil
+	})
+
+	tests := []struct {
+		err    error
+		status int
+	}{
+		{io.ErrUnexpectedEOF, http.StatusBadRequest},
+		{errors.New("connection reset by peer"), http.StatusInternalServerError},
+	}
+
+	for _, test := range tests {
+		// the body fails partway through, after the start of the event
+		body := io.MultiReader(strings.NewReader(`{"source": "aws.ecs", `), failingReader{test.err})
+		request := httptest.NewRequest(http.MethodPost, "/events", body)
+		request.Header.Set("Authorization", "Bearer s3cret")
+
+		recorder := httptest.NewRecorder()
+		eventServer.Handler().ServeHTTP(recorder, request)
+
+		assert.Equal(t, test.status, recorder.Code, test.err.Error())
+	}
+
+	// a client that went away is not the server's failure
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	body := io.MultiReader(strings.NewReader(`{"source": "aws.ecs", `), failingReader{context.Canceled})
+	request := httptest.NewRequest(http.MethodPost, "/events", body).WithContext(ctx)
+	request.Header.Set("Authorization", "Bearer s3cret")
+
+	recorder := httptest.NewRecorder()
+	eventServer.Handler().ServeHTTP(recorder, request)
+
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+}
+
+func TestServeEventsAPIKeyHeader(t *testing.T) {
+	eventServer, err := server.New(server.Config{AuthHeader: "X-Api-Key", AuthToken: "s3cret", MaxBodyBytes: 1024},
+		func(ctx context.Context, payload json.RawMessage) (interface{}, error) {
+			return messageResponse{Reason: "Notification complete!"}, nil
+		})
+	assert.Nil(t, err)
+
+	recorder := postEvent(eventServer, sampleEvent, map[string]string{"X-Api-Key": "s3cret"})
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	recorder = postEvent(eventServer, sampleEvent, map[string]string{"Authorization": "Bearer s3cret"})
+	assert.Equal(t, http.StatusUnauthorized, recorder.Code)
+}
+
+func TestServeEventsFailure(t *testing.T) {
+	eventServer := newTestServer(t, func(ctx context.Context, payload json.RawMessage) (interface{}, error) {
+		return messageResponse{Reason: "Notification incomplete!"}, errors.New("One or more notification failures")
+	})
+
+	recorder := postEvent(eventServer, sampleEvent, map[string]string{"Authorization": "Bearer s3cret"})
+	assert.Equal(t, http.StatusInternalServerError, recorder.Code)
+	assert.JSONEq(t, `{"message": "Notification incomplete!", "error": "One or more notification failures"}`,
+		recorder.Body.String())
+}
+
+func TestServeEventsRequestID(t *testing.T) {
+	var fields logger.Fields
+
+	eventServer := newTestServer(t, func(ctx context.Context, payload json.RawMessage) (interface{}, error) {
+		fields = logger.FieldsFrom(ctx)
+		return messageResponse{Reason: "Notification complete!"}, nil
+	})
+
+	postEvent(eventServer, sampleEvent, map[string]string{"Authorization": "s3cret", "X-Request-Id": "req-1"})
+	assert.Equal(t, "req-1", fields[logger.FieldRequestID])
+
+	postEvent(eventServer, sampleEvent, map[string]string{"Authorization": "s3cret"})
+	assert.Len(t, fields[logger.FieldRequestID], 32)
+}
+
+func TestServeLogLevel(t *testing.T) {
+	defer logger.SetLevel(logger.GetLevel())
+
+	eventServer := newTestServer(t, nil)
+
+	serve := func(method, body string, headers map[string]string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(method, "/loglevel", strings.NewReader(body))
+		for name, value := range headers {
+			request.Header.Set(name, value)
+		}
+
+		recorder := httptest.NewRecorder()
+		eventServer.Handler().ServeHTTP(recorder, request)
+
+		return recorder
+	}
+
+	auth := map[string]string{"Authorization": "s3cret"}
+
+	assert.Equal(t, http.StatusUnauthorized, serve(http.MethodPut, `{"level": "debug"}`, nil).Code)
+
+	recorder := serve(http.MethodPut, `{"level": "debug"}`, auth)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.JSONEq(t, `{"level": "debug"}`, recorder.Body.String())
+	assert.Equal(t, logger.LevelDebug, logger.GetLevel())
+
+	recorder = serve(http.MethodGet, "", auth)
+	assert.JSONEq(t, `{"level": "debug"}`, recorder.Body.String())
+
+	assert.Equal(t, http.StatusBadRequest, serve(http.MethodPut, `{"level": "verbose"}`, auth).Code)
+	assert.Equal(t, http.StatusBadRequest, serve(http.MethodPut, `level=debug`, auth).Code)
+	assert.Equal(t, http.StatusMethodNotAllowed, serve(http.MethodDelete, "", auth).Code)
+	assert.Equal(t, logger.LevelDebug, logger.GetLevel())
+}
+
+func TestHealthAndReadiness(t *testing.T) {
+	eventServer := newTestServer(t, nil)
+
+	for path, expected := range map[string]int{"/healthz": http.StatusOK, "/readyz": http.StatusServiceUnavailable} {
+		recorder := httptest.NewRecorder()
+		eventServer.Handler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, path, nil))
+		assert.Equal(t, expected, recorder.Code, path)
+	}
+
+	eventServer.SetReady(true)
+
+	recorder := httptest.NewRecorder()
+	eventServer.Handler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+}
+
+func TestListenAndServeShutdown(t *testing.T) {
+	eventServer, err := server.New(server.Config{Address: "127.0.0.1:0", AuthToken: "s3cret"}, nil)
+	assert.Nil(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	assert.Nil(t, eventServer.ListenAndServe(ctx))
+	assert.False(t, eventServer.IsReady())
+}
+
+func TestListenAndServeAddressInUse(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	defer listener.Close()
+
+	eventServer, err := server.New(server.Config{Address: listener.Addr().String(), AuthToken: "s3cret"}, nil)
+	assert.Nil(t, err)
+
+	// the bind fails before the server is ever reported ready
+	err = eventServer.ListenAndServe(context.Background())
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "Error listening on")
+	assert.False(t, eventServer.IsReady())
+}
+
+func writeCertificate(t *testing.T) (string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.Nil(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+
+	certificate, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	assert.Nil(t, err)
+
+	encodedKey, err := x509.MarshalECPrivateKey(key)
+	assert.Nil(t, err)
+
+	directory := t.TempDir()
+	certFile := filepath.Join(directory, "cert.pem")
+	keyFile := filepath.Join(directory, "key.pem")
+
+	assert.Nil(t, ioutil.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certificate}),
+		0600))
+	assert.Nil(t, ioutil.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: encodedKey}),
+		0600))
+
+	return certFile, keyFile
+}
+
+func TestListenAndServeTLS(t *testing.T) {
+	certFile, keyFile := writeCertificate(t)
+
+	// a free port, the server binds it itself
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	address := listener.Addr().String()
+	listener.Close()
+
+	eventServer, err := server.New(server.Config{Address: address, AuthToken: "s3cret",
+		TLSCertFile: certFile, TLSKeyFile: keyFile, ShutdownTimeout: time.Second}, nil)
+	assert.Nil(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error, 1)
+
+	go func() {
+		served <- eventServer.ListenAndServe(ctx)
+	}()
+
+	assert.Eventually(t, eventServer.IsReady, time.Second, 10*time.Millisecond)
+
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}}}
+
+	response, err := client.Get("https://" + address + "/healthz")
+	assert.Nil(t, err)
+	if response != nil {
+		response.Body.Close()
+		assert.Equal(t, http.StatusOK, response.StatusCode)
+	}
+
+	// plain HTTP is not answered as such
+	response, err = http.Get("http://" + address + "/healthz")
+	if err == nil {
+		response.Body.Close()
+		assert.Equal(t, http.StatusBadRequest, response.StatusCode)
+	}
+
+	cancel()
+	assert.Nil(t, <-served)
+}
+
+func TestListenAndServeBadCertificate(t *testing.T) {
+	eventServer, err := server.New(server.Config{Address: "127.0.0.1:0", AuthToken: "s3cret",
+		TLSCertFile: "missing-cert.pem", TLSKeyFile: "missing-key.pem"}, nil)
+	assert.Nil(t, err)
+
+	err = eventServer.ListenAndServe(context.Background())
+	assert.NotNil(t, err)
+	assert.False(t, eventServer.IsReady())
+}
@@ -0,0 +1,168 @@
+package tracing
+
+import (
+	"context"
+	"deployment-notifications/pkg/logger"
+	"os"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// recorder is the span processor of one invocation. It keeps the spans
+// ended until Flush, so invocations handled at the same time by the
+// server do not export each other's spans
+type recorder struct {
+	mutex sync.Mutex
+	spans []sdktrace.ReadOnlySpan
+}
+
+func (recorder *recorder) OnStart(context.Context, sdktrace.ReadWriteSpan) {}
+
+func (recorder *recorder) OnEnd(span sdktrace.ReadOnlySpan) {
+	recorder.mutex.Lock()
+	defer recorder.mutex.Unlock()
+
+	recorder.spans = append(recorder.spans, span)
+}
+
+func (recorder *recorder) Shutdown(context.Context) error {
+	return nil
+}
+
+func (recorder *recorder) ForceFlush(context.Context) error {
+	return nil
+}
+
+func (recorder *recorder) take() []sdktrace.ReadOnlySpan {
+	recorder.mutex.Lock()
+	defer recorder.mutex.Unlock()
+
+	pending := recorder.spans
+	recorder.spans = nil
+
+	return pending
+}
+
+type invocation struct {
+	provider *sdktrace.TracerProvider
+	recorder *recorder
+}
+
+type invocationKey struct{}
+
+// WithRecorder returns a context the spans of an invocation are started
+// in. Spans are only recorded inside one, and exported by Flush
+func WithRecorder(ctx context.Context) context.Context {
+	if !IsEnabled() {
+		return ctx
+	}
+
+	recorder := &recorder{}
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithSpanProcessor(recorder),
+		// traces the caller did not sample are not sampled here either
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.AlwaysSample())),
+		sdktrace.WithResource(newResource()),
+	)
+
+	return context.WithValue(ctx, invocationKey{}, &invocation{provider: provider, recorder: recorder})
+}
+
+func invocationFrom(ctx context.Context) (*invocation, bool) {
+	if ctx == nil {
+		return nil, false
+	}
+
+	current, ok := ctx.Value(invocationKey{}).(*invocation)
+	return current, ok
+}
+
+func newResource() *resource.Resource {
+	attributes := []attribute.KeyValue{
+		attribute.String("service.name", GetServiceName()),
+		attribute.String("cloud.provider", "aws"),
+	}
+
+	if region := os.Getenv("AWS_REGION"); region != "" {
+		attributes = append(attributes, attribute.String("cloud.region", region))
+	}
+
+	if functionName := os.Getenv("AWS_LAMBDA_FUNCTION_NAME"); functionName != "" {
+		attributes = append(attributes, attribute.String("faas.name", functionName),
+			attribute.String("faas.version", os.Getenv("AWS_LAMBDA_FUNCTION_VERSION")))
+	}
+
+	return resource.NewSchemaless(attributes...)
+}
+
+var (
+	exportersMutex sync.Mutex
+	exporters      = make(map[string]*otlptrace.Exporter)
+)
+
+// exporterFor returns the exporter of an endpoint, made once so its
+// connections to the collector are reused across invocations. Headers
+// are read from OTEL_EXPORTER_OTLP_HEADERS by the exporter itself
+func exporterFor(ctx context.Context, endpoint string) (*otlptrace.Exporter, error) {
+	exportersMutex.Lock()
+	defer exportersMutex.Unlock()
+
+	if exporter, ok := exporters[endpoint]; ok {
+		return exporter, nil
+	}
+
+	// a failed export is logged rather than retried, the
+	// invocation is not held up waiting for the collector
+	exporter, err := otlptracehttp.New(ctx,
+		otlptracehttp.WithEndpointURL(endpoint),
+		otlptracehttp.WithTimeout(5*time.Second),
+		otlptracehttp.WithRetry(otlptracehttp.RetryConfig{Enabled: false}),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	exporters[endpoint] = exporter
+
+	return exporter, nil
+}
+
+// Flush exports the spans the invocation in ctx ended so far. Lambda
+// freezes the process after an invocation, so this is called before
+// returning rather than in the background. Export failures are logged
+// and otherwise ignored
+func Flush(ctx context.Context) {
+	current, ok := invocationFrom(ctx)
+	if !ok {
+		return
+	}
+
+	pending := current.recorder.take()
+	if len(pending) == 0 {
+		return
+	}
+
+	endpoint := GetEndpoint()
+
+	// the invocation may already be cancelled, the spans are still sent
+	exportCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	exporter, err := exporterFor(exportCtx, endpoint)
+	if err == nil {
+		err = exporter.ExportSpans(exportCtx, pending)
+	}
+
+	if err != nil {
+		logger.Warnf(ctx, "Error exporting %d span(s): %v", len(pending), err)
+		return
+	}
+
+	logger.Debugf(ctx, "Exported %d span(s) to '%s'", len(pending), endpoint)
+}
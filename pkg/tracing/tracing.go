@@ -0,0 +1,246 @@
+package tracing
+
+import (
+	"context"
+	"deployment-notifications/pkg/logger"
+	"deployment-notifications/pkg/redact"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type SpanKind = trace.SpanKind
+
+const (
+	KindInternal = trace.SpanKindInternal
+	KindServer   = trace.SpanKindServer
+	KindClient   = trace.SpanKindClient
+)
+
+const DefaultServiceName = "deployment-notifications"
+
+// the ADOT Lambda layer runs a collector taking OTLP over HTTP here
+const adotCollectorEndpoint = "http://localhost:4318"
+
+const traceparentHeader = "traceparent"
+
+type SpanContext struct {
+	TraceID [16]byte
+	SpanID  [8]byte
+	Sampled bool
+}
+
+func (spanContext SpanContext) IsValid() bool {
+	return spanContext.TraceID != [16]byte{} && spanContext.SpanID != [8]byte{}
+}
+
+func fromOTel(spanContext trace.SpanContext) SpanContext {
+	return SpanContext{
+		TraceID: spanContext.TraceID(),
+		SpanID:  spanContext.SpanID(),
+		Sampled: spanContext.IsSampled(),
+	}
+}
+
+// Span is one timed operation. A nil span, as handed out when tracing
+// is disabled, ignores every call
+type Span struct {
+	span trace.Span
+}
+
+// GetEndpoint is where spans are exported to. Tracing is off when it
+// is empty, unless the ADOT Lambda layer provides a collector
+func GetEndpoint() string {
+	if endpoint := os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT"); endpoint != "" {
+		return endpoint
+	}
+
+	endpoint := os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT")
+
+	if endpoint == "" && strings.HasPrefix(os.Getenv("AWS_LAMBDA_EXEC_WRAPPER"), "/opt/otel") {
+		endpoint = adotCollectorEndpoint
+	}
+
+	if endpoint == "" {
+		return ""
+	}
+
+	return strings.TrimSuffix(endpoint, "/") + "/v1/traces"
+}
+
+func IsEnabled() bool {
+	return os.Getenv("OTEL_SDK_DISABLED") != "true" && GetEndpoint() != ""
+}
+
+func GetServiceName() string {
+	if name := os.Getenv("OTEL_SERVICE_NAME"); name != "" {
+		return name
+	}
+
+	return DefaultServiceName
+}
+
+func SpanFromContext(ctx context.Context) *Span {
+	if ctx == nil {
+		return nil
+	}
+
+	span := trace.SpanFromContext(ctx)
+	if !span.SpanContext().IsValid() {
+		return nil
+	}
+
+	return &Span{span: span}
+}
+
+// ContextWithRemoteParent makes the next span started a child of a span
+// of another process, e.g. the caller of the server
+func ContextWithRemoteParent(ctx context.Context, parent SpanContext) context.Context {
+	if !parent.IsValid() {
+		return ctx
+	}
+
+	var flags trace.TraceFlags
+	if parent.Sampled {
+		flags = trace.FlagsSampled
+	}
+
+	return trace.ContextWithRemoteSpanContext(ctx, trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    parent.TraceID,
+		SpanID:     parent.SpanID,
+		TraceFlags: flags,
+		Remote:     true,
+	}))
+}
+
+// Start begins a span as a child of the one in ctx and returns a context
+// carrying it. Spans are only recorded within WithRecorder, and must be
+// ended to be exported
+func Start(ctx context.Context, name string, kind SpanKind) (context.Context, *Span) {
+	current, ok := invocationFrom(ctx)
+	if !ok {
+		return ctx, nil
+	}
+
+	ctx, span := current.provider.Tracer(DefaultServiceName).Start(ctx, name, trace.WithSpanKind(kind))
+	ctx = logger.WithField(ctx, logger.FieldTraceID, span.SpanContext().TraceID().String())
+
+	return ctx, &Span{span: span}
+}
+
+func (span *Span) SpanContext() SpanContext {
+	if span == nil {
+		return SpanContext{}
+	}
+
+	return fromOTel(span.span.SpanContext())
+}
+
+func (span *Span) SetAttribute(key string, value interface{}) {
+	if span == nil {
+		return
+	}
+
+	switch typed := value.(type) {
+	case string:
+		span.span.SetAttributes(attribute.String(key, redact.String(typed)))
+	case bool:
+		span.span.SetAttributes(attribute.Bool(key, typed))
+	case int:
+		span.span.SetAttributes(attribute.Int(key, typed))
+	case int64:
+		span.span.SetAttributes(attribute.Int64(key, typed))
+	case float64:
+		span.span.SetAttributes(attribute.Float64(key, typed))
+	default:
+		span.span.SetAttributes(attribute.String(key, redact.String(fmt.Sprintf("%v", value))))
+	}
+}
+
+// RecordError marks the span as failed, a nil error leaves it as it is
+func (span *Span) RecordError(err error) {
+	if span == nil || err == nil {
+		return
+	}
+
+	span.span.SetStatus(codes.Error, redact.String(err.Error()))
+}
+
+// End hands the span to the recorder of its invocation, later calls
+// are ignored
+func (span *Span) End() {
+	if span == nil {
+		return
+	}
+
+	span.span.End()
+}
+
+// Inject sets the W3C traceparent header of an outgoing request to the
+// span in ctx, so the receiving side can continue the trace. It is only
+// meant for services we run, sinks are not handed our trace
+func Inject(ctx context.Context, header http.Header) {
+	propagation.TraceContext{}.Inject(ctx, propagation.HeaderCarrier(header))
+}
+
+// Extract reads the W3C traceparent header of an incoming request
+func Extract(header http.Header) (SpanContext, bool) {
+	remote := trace.SpanContextFromContext(
+		propagation.TraceContext{}.Extract(context.Background(), propagation.HeaderCarrier(header)))
+
+	return fromOTel(remote), remote.IsValid()
+}
+
+func ParseTraceparent(value string) (SpanContext, bool) {
+	header := make(http.Header)
+	header.Set(traceparentHeader, strings.TrimSpace(value))
+
+	return Extract(header)
+}
+
+// ParseXRayTraceHeader reads the trace Lambda sets in _X_AMZN_TRACE_ID,
+// e.g. "Root=1-5759e988-bd862e3fe1be46a994272793;Parent=53995c3f42cd8ad8;Sampled=1",
+// so spans join the invocation's trace
+func ParseXRayTraceHeader(value string) (SpanContext, bool) {
+	var spanContext SpanContext
+
+	for _, part := range strings.Split(value, ";") {
+		keyValue := strings.SplitN(strings.TrimSpace(part), "=", 2)
+		if len(keyValue) != 2 {
+			continue
+		}
+
+		switch keyValue[0] {
+		case "Root":
+			root := strings.Split(keyValue[1], "-")
+			if len(root) != 3 || !decodeID(root[1]+root[2], spanContext.TraceID[:]) {
+				return spanContext, false
+			}
+		case "Parent":
+			if !decodeID(keyValue[1], spanContext.SpanID[:]) {
+				return spanContext, false
+			}
+		case "Sampled":
+			spanContext.Sampled = keyValue[1] == "1"
+		}
+	}
+
+	return spanContext, spanContext.IsValid()
+}
+
+func decodeID(value string, id []byte) bool {
+	decoded, err := hex.DecodeString(value)
+	if err != nil || len(decoded) != len(id) {
+		return false
+	}
+
+	copy(id, decoded)
+	return true
+}
@@ -0,0 +1,220 @@
+package tracing_test
+
+import (
+	"context"
+	"deployment-notifications/pkg/tracing"
+	"encoding/hex"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/protobuf/proto"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+func collector(t *testing.T, exported *[]*tracepb.Span, headers *http.Header) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/traces", r.URL.Path)
+		*headers = r.Header
+
+		body, _ := ioutil.ReadAll(r.Body)
+
+		var request coltracepb.ExportTraceServiceRequest
+		assert.Nil(t, proto.Unmarshal(body, &request))
+
+		for _, resourceSpans := range request.ResourceSpans {
+			for _, scopeSpans := range resourceSpans.ScopeSpans {
+				*exported = append(*exported, scopeSpans.Spans...)
+			}
+		}
+	}))
+}
+
+func attributeOf(span *tracepb.Span, key string) *commonpb.AnyValue {
+	for _, attribute := range span.Attributes {
+		if attribute.Key == key {
+			return attribute.Value
+		}
+	}
+
+	return nil
+}
+
+func TestDisabled(t *testing.T) {
+	os.Unsetenv("OTEL_EXPORTER_OTLP_ENDPOINT")
+	assert.False(t, tracing.IsEnabled())
+
+	ctx, span := tracing.Start(context.Background(), "disabled", tracing.KindInternal)
+	assert.Nil(t, span)
+	assert.Nil(t, tracing.SpanFromContext(ctx))
+
+	// a nil span accepts every call
+	span.SetAttribute("key", "value")
+	span.RecordError(errors.New("failed"))
+	span.End()
+	tracing.Flush(ctx)
+}
+
+func TestEndpoint(t *testing.T) {
+	os.Setenv("AWS_LAMBDA_EXEC_WRAPPER", "/opt/otel-handler")
+	assert.Equal(t, "http://localhost:4318/v1/traces", tracing.GetEndpoint())
+	os.Unsetenv("AWS_LAMBDA_EXEC_WRAPPER")
+
+	os.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "http://collector:4318/")
+	defer os.Unsetenv("OTEL_EXPORTER_OTLP_ENDPOINT")
+	assert.Equal(t, "http://collector:4318/v1/traces", tracing.GetEndpoint())
+
+	os.Setenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", "http://collector:4318/custom")
+	defer os.Unsetenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT")
+	assert.Equal(t, "http://collector:4318/custom", tracing.GetEndpoint())
+}
+
+func TestExport(t *testing.T) {
+	var exported []*tracepb.Span
+	var headers http.Header
+
+	server := collector(t, &exported, &headers)
+	defer server.Close()
+
+	os.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", server.URL)
+	os.Setenv("OTEL_EXPORTER_OTLP_HEADERS", "x-team=platform")
+	defer os.Unsetenv("OTEL_EXPORTER_OTLP_ENDPOINT")
+	defer os.Unsetenv("OTEL_EXPORTER_OTLP_HEADERS")
+
+	remote, ok := tracing.ParseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	assert.True(t, ok)
+
+	ctx := tracing.WithRecorder(context.Background())
+	ctx = tracing.ContextWithRemoteParent(ctx, remote)
+	ctx, parent := tracing.Start(ctx, "parent", tracing.KindServer)
+
+	childCtx, child := tracing.Start(ctx, "child", tracing.KindClient)
+	child.SetAttribute("url.full", "https://hooks.slack.com/services/T0000/B0000/XXXXXXXXXXXXXXXX")
+	child.SetAttribute("http.response.status_code", 500)
+	child.RecordError(errors.New("status 500"))
+
+	request, _ := http.NewRequest(http.MethodPost, "https://example.com", nil)
+	tracing.Inject(childCtx, request.Header)
+
+	child.End()
+	parent.End()
+	tracing.Flush(ctx)
+
+	assert.Equal(t, "platform", headers.Get("x-team"))
+	assert.Len(t, exported, 2)
+
+	exportedChild, exportedParent := exported[0], exported[1]
+
+	assert.Equal(t, "parent", exportedParent.Name)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", hex.EncodeToString(exportedParent.TraceId))
+	assert.Equal(t, "00f067aa0ba902b7", hex.EncodeToString(exportedParent.ParentSpanId))
+	assert.Equal(t, tracepb.Span_SPAN_KIND_SERVER, exportedParent.Kind)
+
+	assert.Equal(t, "child", exportedChild.Name)
+	assert.Equal(t, exportedParent.TraceId, exportedChild.TraceId)
+	assert.Equal(t, exportedParent.SpanId, exportedChild.ParentSpanId)
+	assert.Equal(t, tracepb.Status_STATUS_CODE_ERROR, exportedChild.Status.Code)
+	assert.Equal(t, "status 500", exportedChild.Status.Message)
+	assert.Equal(t, int64(500), attributeOf(exportedChild, "http.response.status_code").GetIntValue())
+	assert.Equal(t, "https://hooks.slack.com/services/T0000/B0000/<redacted>",
+		attributeOf(exportedChild, "url.full").GetStringValue())
+
+	assert.Equal(t, "00-4bf92f3577b34da6a3ce929d0e0e4736-"+hex.EncodeToString(exportedChild.SpanId)+"-01",
+		request.Header.Get("traceparent"))
+}
+
+func TestExportPerInvocation(t *testing.T) {
+	var exported []*tracepb.Span
+	var headers http.Header
+
+	server := collector(t, &exported, &headers)
+	defer server.Close()
+
+	os.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", server.URL)
+	defer os.Unsetenv("OTEL_EXPORTER_OTLP_ENDPOINT")
+
+	// two invocations handled at the same time by the server
+	firstCtx, first := tracing.Start(tracing.WithRecorder(context.Background()), "first", tracing.KindServer)
+	secondCtx, second := tracing.Start(tracing.WithRecorder(context.Background()), "second", tracing.KindServer)
+
+	first.End()
+	second.End()
+
+	tracing.Flush(firstCtx)
+	assert.Len(t, exported, 1)
+	assert.Equal(t, "first", exported[0].Name)
+
+	tracing.Flush(secondCtx)
+	assert.Len(t, exported, 2)
+	assert.Equal(t, "second", exported[1].Name)
+
+	// spans outside an invocation are not recorded
+	_, span := tracing.Start(context.Background(), "outside", tracing.KindInternal)
+	assert.Nil(t, span)
+}
+
+func TestExportUnsampledParent(t *testing.T) {
+	var exported []*tracepb.Span
+	var headers http.Header
+
+	server := collector(t, &exported, &headers)
+	defer server.Close()
+
+	os.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", server.URL)
+	defer os.Unsetenv("OTEL_EXPORTER_OTLP_ENDPOINT")
+
+	remote, ok := tracing.ParseXRayTraceHeader(
+		"Root=1-5759e988-bd862e3fe1be46a994272793;Parent=53995c3f42cd8ad8;Sampled=0")
+	assert.True(t, ok)
+	assert.False(t, remote.Sampled)
+
+	ctx := tracing.ContextWithRemoteParent(tracing.WithRecorder(context.Background()), remote)
+	ctx, span := tracing.Start(ctx, "unsampled", tracing.KindServer)
+
+	request, _ := http.NewRequest(http.MethodPost, "https://example.com", nil)
+	tracing.Inject(ctx, request.Header)
+
+	span.End()
+	tracing.Flush(ctx)
+
+	spanContext := span.SpanContext()
+
+	assert.Empty(t, exported)
+	assert.Equal(t, "5759e988bd862e3fe1be46a994272793", hex.EncodeToString(spanContext.TraceID[:]))
+	assert.False(t, spanContext.Sampled)
+	assert.True(t, strings.HasSuffix(request.Header.Get("traceparent"), "-00"))
+}
+
+func TestParseTraceparent(t *testing.T) {
+	header := make(http.Header)
+	header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+
+	spanContext, ok := tracing.Extract(header)
+	assert.True(t, ok)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", hex.EncodeToString(spanContext.TraceID[:]))
+	assert.Equal(t, "00f067aa0ba902b7", hex.EncodeToString(spanContext.SpanID[:]))
+	assert.True(t, spanContext.Sampled)
+
+	for _, invalid := range []string{"", "00-abc-def-01", "ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01"} {
+		_, ok = tracing.ParseTraceparent(invalid)
+		assert.False(t, ok, invalid)
+	}
+}
+
+func TestParseXRayTraceHeader(t *testing.T) {
+	spanContext, ok := tracing.ParseXRayTraceHeader(
+		"Root=1-5759e988-bd862e3fe1be46a994272793;Parent=53995c3f42cd8ad8;Sampled=1")
+	assert.True(t, ok)
+	assert.Equal(t, "5759e988bd862e3fe1be46a994272793", hex.EncodeToString(spanContext.TraceID[:]))
+	assert.Equal(t, "53995c3f42cd8ad8", hex.EncodeToString(spanContext.SpanID[:]))
+
+	_, ok = tracing.ParseXRayTraceHeader("Root=1-5759e988-bd862e3fe1be46a994272793")
+	assert.False(t, ok)
+}
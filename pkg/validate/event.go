@@ -8,26 +8,93 @@ import (
 	"github.com/aws/aws-lambda-go/events"
 )
 
+var supportedSources = []string{"aws.ecs", "aws.codedeploy", "aws.codepipeline", "aws.lambda", "aws.cloudformation", "aws.ecr"}
+
 func SourceValidate(request events.CloudWatchEvent) (string, error) {
-	// ignore non ECS events
-	if strings.ToLower(request.Source) != "aws.ecs" {
-		outMessage := fmt.Sprintf("Event '%s' received. We only respond to 'aws.ecs' events", request.Source)
-		return outMessage, helper.WrapError(outMessage, nil)
+	// ignore events from sources we have no handlers for
+	for _, source := range supportedSources {
+		if strings.ToLower(request.Source) == source {
+			return "", nil
+		}
 	}
 
-	return "", nil
+	outMessage := fmt.Sprintf("Event '%s' received. We only respond to '%s' events",
+		request.Source, strings.Join(supportedSources, "', '"))
+	return outMessage, helper.WrapError(outMessage, nil)
 }
 
 func DetailValidate(request events.CloudWatchEvent) (string, error) {
-	// ignore non deployment ECS events
-	if strings.ToLower(request.DetailType) != "ecs deployment state change" {
-		outMessage := fmt.Sprintf("ECS Event '%s' received. We only respond to 'ECS Deployment State Change' events", request.DetailType)
+	// ignore ECS events other than deployments and service actions
+	detailType := strings.ToLower(request.DetailType)
+
+	if detailType != "ecs deployment state change" && detailType != "ecs service action" &&
+		detailType != "ecs task state change" {
+		outMessage := fmt.Sprintf("ECS Event '%s' received. We only respond to 'ECS Deployment State Change', "+
+			"'ECS Service Action' and 'ECS Task State Change' events", request.DetailType)
 		return outMessage, helper.WrapError(outMessage, nil)
 	}
 
 	return "", nil
 }
 
+func ResourcesValidate(request events.CloudWatchEvent) (string, error) {
+	// ECS deployment and service action events name the service they are
+	// about as their first resource, an event without one is malformed
+	if len(request.Resources) == 0 {
+		outMessage := fmt.Sprintf("ECS Event '%s' received without resources", request.DetailType)
+		return outMessage, helper.InvalidEventError(outMessage, nil)
+	}
+
+	return "", nil
+}
+
+func IsServiceActionEvent(request events.CloudWatchEvent) bool {
+	return strings.ToLower(request.Source) == "aws.ecs" &&
+		strings.ToLower(request.DetailType) == "ecs service action"
+}
+
+func IsScheduledEvent(request events.CloudWatchEvent) bool {
+	// EventBridge schedules drive the DORA digest
+	return strings.ToLower(request.Source) == "aws.events" &&
+		strings.ToLower(request.DetailType) == "scheduled event"
+}
+
+func IsTaskStateChangeEvent(request events.CloudWatchEvent) bool {
+	return strings.ToLower(request.Source) == "aws.ecs" &&
+		strings.ToLower(request.DetailType) == "ecs task state change"
+}
+
+func IsCodeDeployEvent(request events.CloudWatchEvent) bool {
+	return strings.ToLower(request.Source) == "aws.codedeploy" &&
+		strings.ToLower(request.DetailType) == "codedeploy deployment state-change notification"
+}
+
+func IsCodePipelineEvent(request events.CloudWatchEvent) bool {
+	// pipeline, stage and action execution events are all handled
+	return strings.ToLower(request.Source) == "aws.codepipeline" &&
+		helper.GetCodePipelineLevel(request.DetailType) != ""
+}
+
+func IsLambdaDeployEvent(request events.CloudWatchEvent) bool {
+	// Lambda deployments are only visible through CloudTrail API calls
+	return strings.ToLower(request.Source) == "aws.lambda" &&
+		strings.ToLower(request.DetailType) == "aws api call via cloudtrail"
+}
+
+func IsCloudFormationEvent(request events.CloudWatchEvent) bool {
+	detailType := strings.ToLower(request.DetailType)
+
+	return strings.ToLower(request.Source) == "aws.cloudformation" &&
+		(detailType == "cloudformation stack status change" || helper.IsCloudFormationDriftEvent(detailType))
+}
+
+func IsECREvent(request events.CloudWatchEvent) bool {
+	detailType := strings.ToLower(request.DetailType)
+
+	return strings.ToLower(request.Source) == "aws.ecr" &&
+		(detailType == "ecr image action" || helper.IsECRImageScanEvent(detailType))
+}
+
 func EnvValidate() (map[string]string, error) {
 	result := make(map[string]string)
 
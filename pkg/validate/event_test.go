@@ -1,6 +1,7 @@
 package validate_test
 
 import (
+	"deployment-notifications/pkg/helper"
 	"deployment-notifications/pkg/validate"
 	"encoding/json"
 	"github.com/aws/aws-lambda-go/events"
@@ -138,6 +139,23 @@ func TestDetailValidateFail(t *testing.T) {
 	assert.NotNil(t, err)
 }
 
+func TestResourcesValidate(t *testing.T) {
+	request := events.CloudWatchEvent{DetailType: "ECS Deployment State Change",
+		Resources: []string{"arn:aws:ecs:us-west-2:111122223333:service/my-cluster/my-service"}}
+
+	validateMessage, err := validate.ResourcesValidate(request)
+
+	assert.Equal(t, "", validateMessage)
+	assert.Nil(t, err)
+
+	request.Resources = []string{}
+	validateMessage, err = validate.ResourcesValidate(request)
+
+	assert.Equal(t, "ECS Event 'ECS Deployment State Change' received without resources", validateMessage)
+	assert.Equal(t, helper.ErrorKindInvalidEvent, helper.GetErrorKind(err))
+	assert.False(t, helper.IsRetryable(err))
+}
+
 func TestEnvValidatePass(t *testing.T) {
 	os.Setenv("SSM_PARAMETER_NAME_NEW_RELIC", "param1")
 	os.Setenv("SSM_PARAMETER_NAME_SLACK", "param2")
@@ -243,3 +261,163 @@ func TestEnvValidateFailNewRelicAPIToken(t *testing.T) {
 	assert.NotNil(t, err)
 	assert.True(t, strings.Contains(err.Error(), "NEW_RELIC_API_TOKEN"))
 }
+
+func TestIsScheduledEvent(t *testing.T) {
+	sampleEvent := `
+{
+   "version": "0",
+   "id": "89d1a02d-5ec7-412e-82f5-13505f849b41",
+   "detail-type": "Scheduled Event",
+   "source": "aws.events",
+   "account": "111122223333",
+   "time": "2020-05-23T00:00:00Z",
+   "region": "us-west-2",
+   "resources": [
+        "arn:aws:events:us-west-2:111122223333:rule/weekly-dora-digest"
+   ],
+   "detail": {}
+}
+`
+	var cloudwatchEvent events.CloudWatchEvent
+	err := json.Unmarshal([]byte(sampleEvent), &cloudwatchEvent)
+	assert.Nil(t, err)
+
+	assert.True(t, validate.IsScheduledEvent(cloudwatchEvent))
+
+	cloudwatchEvent.Source = "aws.ecs"
+	assert.False(t, validate.IsScheduledEvent(cloudwatchEvent))
+}
+
+func TestDetailValidateServiceAction(t *testing.T) {
+	sampleEvent := `
+{
+   "version": "0",
+   "id": "ddca6449-b258-46c0-8653-e0e3a6EXAMPLE",
+   "detail-type": "ECS Service Action",
+   "source": "aws.ecs",
+   "account": "111122223333",
+   "time": "2020-05-23T12:31:14Z",
+   "region": "us-west-2",
+   "resources": [
+        "arn:aws:ecs:us-west-2:111122223333:service/default/shure-content-api"
+   ],
+   "detail": {
+        "eventType": "INFO",
+        "eventName": "SERVICE_STEADY_STATE",
+        "clusterArn": "arn:aws:ecs:us-west-2:111122223333:cluster/default",
+        "createdAt": "2020-05-23T12:31:14.123Z"
+   }
+}
+`
+	var cloudwatchEvent events.CloudWatchEvent
+	err := json.Unmarshal([]byte(sampleEvent), &cloudwatchEvent)
+	assert.Nil(t, err)
+
+	validateMessage, err := validate.DetailValidate(cloudwatchEvent)
+
+	assert.Equal(t, "", validateMessage)
+	assert.Nil(t, err)
+	assert.True(t, validate.IsServiceActionEvent(cloudwatchEvent))
+
+	cloudwatchEvent.DetailType = "ECS Deployment State Change"
+	assert.False(t, validate.IsServiceActionEvent(cloudwatchEvent))
+}
+
+func TestIsTaskStateChangeEvent(t *testing.T) {
+	cloudwatchEvent := events.CloudWatchEvent{Source: "aws.ecs", DetailType: "ECS Task State Change"}
+
+	validateMessage, err := validate.DetailValidate(cloudwatchEvent)
+	assert.Equal(t, "", validateMessage)
+	assert.Nil(t, err)
+	assert.True(t, validate.IsTaskStateChangeEvent(cloudwatchEvent))
+
+	cloudwatchEvent.DetailType = "ECS Service Action"
+	assert.False(t, validate.IsTaskStateChangeEvent(cloudwatchEvent))
+}
+
+func TestCodeDeployEventValidate(t *testing.T) {
+	cloudwatchEvent := events.CloudWatchEvent{
+		Source:     "aws.codedeploy",
+		DetailType: "CodeDeploy Deployment State-change Notification",
+	}
+
+	validateMessage, err := validate.SourceValidate(cloudwatchEvent)
+	assert.Equal(t, "", validateMessage)
+	assert.Nil(t, err)
+	assert.True(t, validate.IsCodeDeployEvent(cloudwatchEvent))
+
+	cloudwatchEvent.DetailType = "CodeDeploy Instance State-change Notification"
+	assert.False(t, validate.IsCodeDeployEvent(cloudwatchEvent))
+}
+
+func TestCodePipelineEventValidate(t *testing.T) {
+	cloudwatchEvent := events.CloudWatchEvent{
+		Source:     "aws.codepipeline",
+		DetailType: "CodePipeline Pipeline Execution State Change",
+	}
+
+	validateMessage, err := validate.SourceValidate(cloudwatchEvent)
+	assert.Equal(t, "", validateMessage)
+	assert.Nil(t, err)
+	assert.True(t, validate.IsCodePipelineEvent(cloudwatchEvent))
+
+	cloudwatchEvent.DetailType = "CodePipeline Stage Execution State Change"
+	assert.True(t, validate.IsCodePipelineEvent(cloudwatchEvent))
+
+	cloudwatchEvent.DetailType = "CodePipeline Action Execution State Change"
+	assert.True(t, validate.IsCodePipelineEvent(cloudwatchEvent))
+
+	cloudwatchEvent.Source = "aws.codedeploy"
+	assert.False(t, validate.IsCodePipelineEvent(cloudwatchEvent))
+}
+
+func TestLambdaDeployEventValidate(t *testing.T) {
+	cloudwatchEvent := events.CloudWatchEvent{
+		Source:     "aws.lambda",
+		DetailType: "AWS API Call via CloudTrail",
+	}
+
+	validateMessage, err := validate.SourceValidate(cloudwatchEvent)
+	assert.Equal(t, "", validateMessage)
+	assert.Nil(t, err)
+	assert.True(t, validate.IsLambdaDeployEvent(cloudwatchEvent))
+
+	cloudwatchEvent.Source = "aws.s3"
+	assert.False(t, validate.IsLambdaDeployEvent(cloudwatchEvent))
+}
+
+func TestCloudFormationEventValidate(t *testing.T) {
+	cloudwatchEvent := events.CloudWatchEvent{
+		Source:     "aws.cloudformation",
+		DetailType: "CloudFormation Stack Status Change",
+	}
+
+	validateMessage, err := validate.SourceValidate(cloudwatchEvent)
+	assert.Equal(t, "", validateMessage)
+	assert.Nil(t, err)
+	assert.True(t, validate.IsCloudFormationEvent(cloudwatchEvent))
+
+	cloudwatchEvent.DetailType = "CloudFormation Drift Detection Status Change"
+	assert.True(t, validate.IsCloudFormationEvent(cloudwatchEvent))
+
+	cloudwatchEvent.DetailType = "CloudFormation Resource Status Change"
+	assert.False(t, validate.IsCloudFormationEvent(cloudwatchEvent))
+}
+
+func TestECREventValidate(t *testing.T) {
+	cloudwatchEvent := events.CloudWatchEvent{
+		Source:     "aws.ecr",
+		DetailType: "ECR Image Action",
+	}
+
+	validateMessage, err := validate.SourceValidate(cloudwatchEvent)
+	assert.Equal(t, "", validateMessage)
+	assert.Nil(t, err)
+	assert.True(t, validate.IsECREvent(cloudwatchEvent))
+
+	cloudwatchEvent.DetailType = "ECR Image Scan"
+	assert.True(t, validate.IsECREvent(cloudwatchEvent))
+
+	cloudwatchEvent.DetailType = "ECR Replication Action"
+	assert.False(t, validate.IsECREvent(cloudwatchEvent))
+}